@@ -1,114 +1,606 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"filmoteka/internal/cache"
+	"filmoteka/internal/config"
+	"filmoteka/internal/delivery/grpc/grpcserver"
+	"filmoteka/internal/delivery/http/handlers/graphqlhandlers"
+	"filmoteka/internal/delivery/http/middleware"
 	"filmoteka/internal/delivery/http/routes"
 	"filmoteka/internal/domain/usecase"
 	"filmoteka/internal/domain/usecase/actormovieusecase"
 	"filmoteka/internal/domain/usecase/actorusecase"
+	"filmoteka/internal/domain/usecase/analyticsusecase"
 	"filmoteka/internal/domain/usecase/movieusecase"
+	"filmoteka/internal/domain/usecase/recommendationusecase"
 	"filmoteka/internal/domain/usecase/userusecase"
+	"filmoteka/internal/health"
+	"filmoteka/internal/logging"
+	"filmoteka/internal/mail"
+	"filmoteka/internal/metrics"
+	"filmoteka/internal/password"
+	"filmoteka/internal/ratelimit"
 	"filmoteka/internal/storage/actormoviestorage"
 	"filmoteka/internal/storage/actorstorage"
+	"filmoteka/internal/storage/analyticsstorage"
+	"filmoteka/internal/storage/cachedstorage"
+	"filmoteka/internal/storage/convert"
+	"filmoteka/internal/storage/instrumentedstorage"
+	"filmoteka/internal/storage/memorystorage"
+	"filmoteka/internal/storage/migrations"
 	"filmoteka/internal/storage/moviestorage"
+	"filmoteka/internal/storage/sqlitestorage"
 	"filmoteka/internal/storage/userstorage"
+	"filmoteka/internal/tracing"
+	"flag"
+	"fmt"
 	"github.com/alexedwards/scs/v2"
 	"github.com/alexedwards/scs/v2/memstore"
 	_ "github.com/jackc/pgconn"
 	_ "github.com/jackc/pgx/v4"
 	_ "github.com/jackc/pgx/v4/stdlib"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"google.golang.org/grpc"
 	"log"
+	"log/slog"
+	"math/rand/v2"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
-var count uint8
-
 func main() {
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		printConfig(os.Args[3:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "convert" {
+		convertDB(os.Args[2:])
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal("Error loading configuration: ", err)
+	}
+	if err = cfg.Validate(); err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		log.Fatal("Error creating logger: ", err)
+	}
+	// also routes the standard logger, and with it the log mailer, through
+	// the handler
+	slog.SetDefault(logger)
 
-	conn := connectToDB()
-	defer conn.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	sessionManager := newSessionManager()
+	tracerProvider := newTracerProvider(ctx, cfg.Tracing)
 
-	userStorage := userstorage.New(conn)
-	movieStorage := moviestorage.New(conn)
-	actorStorage := actorstorage.New(conn)
-	actormovieStorage := actormoviestorage.New(conn)
+	sessionManager := newSessionManager(cfg.Session)
 
-	userUseCase := userusecase.New(userStorage)
+	storageCache := cache.NewLRU(cfg.Cache.Size, cfg.Cache.TTL)
+
+	metrics.RegisterSessions(sessionManager)
+
+	var conn *sql.DB
+	var userStorage *instrumentedstorage.UserStorage
+	var movieBackend *instrumentedstorage.MovieStorage
+	var actorBackend *instrumentedstorage.ActorStorage
+	var actormovieBackend *instrumentedstorage.ActorMovieStorage
+	var analyticsUseCase *analyticsusecase.AnalyticsUseCase
+	switch {
+	case cfg.Storage == "memory":
+		store := newMemoryStorage(cfg.Fixtures)
+		userStorage = instrumentedstorage.NewUserStorage(store)
+		movieBackend = instrumentedstorage.NewMovieStorage(store)
+		actorBackend = instrumentedstorage.NewActorStorage(store)
+		actormovieBackend = instrumentedstorage.NewActorMovieStorage(store)
+		analyticsUseCase = analyticsusecase.New(store, cfg.Analytics.TTL)
+	case sqlitestorage.IsDSN(cfg.DB.DSN):
+		conn, err = connectToDB(ctx, cfg.DB)
+		if err != nil {
+			fatal("Error connecting to database", err)
+		}
+		defer conn.Close()
+
+		err = sqlitestorage.Migrate(conn, cfg.DB.MigrationTimeout)
+		if err != nil {
+			fatal("Error running migrations", err)
+		}
+		metrics.RegisterDB(conn)
+
+		store := sqlitestorage.New(conn, cfg.DB.Timeout, cfg.DB.AnalyticsTimeout)
+		userStorage = instrumentedstorage.NewUserStorage(store)
+		movieBackend = instrumentedstorage.NewMovieStorage(store)
+		actorBackend = instrumentedstorage.NewActorStorage(store)
+		actormovieBackend = instrumentedstorage.NewActorMovieStorage(store)
+		analyticsUseCase = analyticsusecase.New(store, cfg.Analytics.TTL)
+	default:
+		conn, err = connectToDB(ctx, cfg.DB)
+		if err != nil {
+			fatal("Error connecting to database", err)
+		}
+		defer conn.Close()
+
+		err = migrations.Run(conn, cfg.DB.MigrationTimeout)
+		if err != nil {
+			fatal("Error running migrations", err)
+		}
+		metrics.RegisterDB(conn)
+
+		userStorage = instrumentedstorage.NewUserStorage(userstorage.New(conn, cfg.DB.Timeout))
+		movieBackend = instrumentedstorage.NewMovieStorage(moviestorage.New(conn, cfg.DB.Timeout))
+		actorBackend = instrumentedstorage.NewActorStorage(actorstorage.New(conn, cfg.DB.Timeout))
+		actormovieBackend = instrumentedstorage.NewActorMovieStorage(actormoviestorage.New(conn, cfg.DB.Timeout))
+		analyticsUseCase = analyticsusecase.New(analyticsstorage.New(conn, cfg.DB.AnalyticsTimeout), cfg.Analytics.TTL)
+	}
+
+	movieStorage := cachedstorage.NewMovieStorage(movieBackend, storageCache)
+	actorStorage := cachedstorage.NewActorStorage(actorBackend, storageCache)
+	actormovieStorage := cachedstorage.NewActorMovieStorage(actormovieBackend, storageCache)
+
+	userUseCase, err := userusecase.New(userStorage, newLockoutPolicy(cfg.Lockout), newMailer(cfg.Mail), cfg.Mail.ResetURL, cfg.Mail.ResetTTL, newPasswordHasher(cfg.Password), newPasswordPolicy(cfg.Password))
+	if err != nil {
+		fatal("Error creating user usecase", err)
+	}
 	movieUseCase := movieusecase.New(movieStorage)
 	actorUseCase := actorusecase.New(actorStorage)
 	actormovieUseCase := actormovieusecase.New(actormovieStorage)
+	recommendationUseCase := recommendationusecase.New(movieStorage, actormovieStorage, cfg.Recommendations.IndexTTL)
+
+	userUseCase.OnLogin(metrics.ObserveLogin)
+	movieUseCase.OnChange(recommendationUseCase.Invalidate)
+	actorUseCase.OnChange(recommendationUseCase.Invalidate)
+	actormovieUseCase.OnChange(recommendationUseCase.Invalidate)
 
 	uc := usecase.UseCase{
 		UserUseCase:       userUseCase,
 		MovieUseCase:      movieUseCase,
 		ActorUseCase:      actorUseCase,
 		ActorMovieUseCase: actormovieUseCase,
+
+		RecommendationUseCase: recommendationUseCase,
+		AnalyticsUseCase:      analyticsUseCase,
 	}
 
-	r := routes.Routes(&uc, sessionManager)
+	checker := newHealthChecker(cfg.Health, conn, cfg.DB.DSN, sessionManager)
+
+	rateLimits := newRateLimits(cfg.RateLimit)
+	rateLimitStore := newRateLimitStore(cfg.RateLimit, conn)
+	r := routes.Routes(&uc, sessionManager, storageCache, rateLimitStore, newCORS(cfg.CORS), checker, routes.Options{
+		GraphQL: graphqlhandlers.Limits{
+			MaxDepth:       cfg.GraphQL.MaxDepth,
+			MaxParallelism: cfg.GraphQL.MaxParallelism,
+			MaxQueryLength: cfg.GraphQL.MaxQueryLength,
+		},
+		RateLimits: rateLimits,
+	})
 
-	srv := http.Server{
-		Addr:    os.Getenv("PORT"),
-		Handler: r,
+	srv := &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		Handler:           r,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
 	}
 
-	log.Println("Starting server on port: ", srv.Addr)
+	grpcSrv := grpcserver.New(&uc, sessionManager, rateLimitStore, rateLimits.Login)
+	lis, err := net.Listen("tcp", cfg.GRPC.Addr)
+	if err != nil {
+		fatal("Error listening for gRPC", err)
+	}
 
-	err := srv.ListenAndServe()
+	errs := make(chan error, 2)
+	go func() {
+		slog.Info("Starting gRPC server", "addr", cfg.GRPC.Addr)
+		errs <- grpcSrv.Serve(lis)
+	}()
+	go func() {
+		slog.Info("Starting server", "addr", srv.Addr)
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err = <-errs:
+		slog.Error("Error starting server", "err", err)
+	case <-ctx.Done():
+		slog.Info("Shutting down")
+	}
+	// a second signal kills the process right away
+	stop()
+
+	checker.Shutdown()
+	if err == nil && cfg.HTTP.ShutdownDelay > 0 {
+		slog.Info("Waiting for load balancers to notice", "delay", cfg.HTTP.ShutdownDelay)
+		time.Sleep(cfg.HTTP.ShutdownDelay)
+	}
+
+	shutdown(srv, grpcSrv, cfg.HTTP.ShutdownTimeout)
+	// password reset mails are sent after their requests were answered
+	userUseCase.Wait()
+	flushTraces(tracerProvider, cfg.HTTP.ShutdownTimeout)
 	if err != nil {
-		log.Println("Error starting server: ", err)
+		if conn != nil {
+			conn.Close()
+		}
+		os.Exit(1)
 	}
 }
 
-func connectToDB() *sql.DB {
-	// connect to postgres
-	dsn := os.Getenv("DSN")
+// shutdown stops accepting connections and waits up to timeout for the
+// requests in flight on both servers, then cuts the remaining ones.
+func shutdown(srv *http.Server, grpcSrv *grpc.Server, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	for {
-		connection, err := openDB(dsn)
-		if err != nil {
-			log.Println("Postgres not ready...")
-			count++
-		} else {
-			log.Println("Connected to database!")
-			return connection
+	stopped := make(chan struct{})
+	go func() {
+		grpcSrv.GracefulStop()
+		close(stopped)
+	}()
+
+	err := srv.Shutdown(ctx)
+	if err != nil {
+		slog.Error("Error draining HTTP requests", "err", err)
+		srv.Close()
+	}
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.Error("Error draining gRPC calls", "err", ctx.Err())
+		grpcSrv.Stop()
+	}
+	slog.Info("Server stopped")
+}
+
+// printConfig implements "config print": the effective configuration with
+// secrets redacted, followed by any validation errors.
+func printConfig(args []string) {
+	cfg, err := config.Load(args)
+	if err != nil {
+		log.Fatal("Error loading configuration: ", err)
+	}
+	if err = config.Print(os.Stdout, cfg); err != nil {
+		log.Fatal("Error printing configuration: ", err)
+	}
+	if err = cfg.Validate(); err != nil {
+		log.Fatal("Invalid configuration:\n", err)
+	}
+}
+
+// convertDB implements "convert": it copies users and the catalog from the
+// database of -from to the empty database of -to, either of which may be
+// Postgres or SQLite.
+func convertDB(args []string) {
+	flags := flag.NewFlagSet("convert", flag.ExitOnError)
+	from := flags.String("from", "", "DSN of the database to copy from")
+	to := flags.String("to", "", "DSN of the empty database to copy to")
+	_ = flags.Parse(args)
+	if *from == "" || *to == "" {
+		log.Fatal("Both -from and -to are required")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	defaults := config.Default().DB
+	src, err := openMigratedDB(ctx, *from, defaults)
+	if err != nil {
+		log.Fatal("Error opening source database: ", err)
+	}
+	defer src.Close()
+	dst, err := openMigratedDB(ctx, *to, defaults)
+	if err != nil {
+		log.Fatal("Error opening destination database: ", err)
+	}
+	defer dst.Close()
+
+	counts, err := convert.Copy(ctx, src, dst, !sqlitestorage.IsDSN(*to))
+	if err != nil {
+		log.Fatal("Error converting database: ", err)
+	}
+	slog.Info("Converted database", "users", counts.Users, "movies", counts.Movies, "actors", counts.Actors, "credits", counts.Credits)
+}
+
+// openMigratedDB opens the database of dsn and brings its schema up to
+// date, with the timeouts of cfg.
+func openMigratedDB(ctx context.Context, dsn string, cfg config.DB) (*sql.DB, error) {
+	db, err := openDB(ctx, dsn, cfg.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	if sqlitestorage.IsDSN(dsn) {
+		err = sqlitestorage.Migrate(db, cfg.MigrationTimeout)
+	} else {
+		err = migrations.Run(db, cfg.MigrationTimeout)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// connectToDB retries until the database answers. After each failed attempt it
+// waits for a random time between half and all of the backoff, which
+// starts at ConnectBackoff and doubles up to ConnectMaxBackoff, so that
+// replicas restarted together do not retry in lockstep.
+func connectToDB(ctx context.Context, cfg config.DB) (*sql.DB, error) {
+	backoff := cfg.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		connection, err := openDB(ctx, cfg.DSN, cfg.Timeout)
+		if err == nil {
+			slog.Info("Connected to database")
+			return connection, nil
 		}
+		slog.Warn("Database not ready", "err", err)
 
-		if count > 10 {
-			log.Println(err)
-			return nil
+		if attempt >= cfg.ConnectAttempts {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
 		}
 
-		log.Println("Backing off for three seconds...")
-		time.Sleep(3 * time.Second)
-		continue
+		wait := backoff/2 + rand.N(backoff/2+1)
+		slog.Info("Backing off", "wait", wait.Round(time.Millisecond))
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff = min(2*backoff, cfg.ConnectMaxBackoff)
 	}
 }
 
-func openDB(dsn string) (*sql.DB, error) {
-	db, err := sql.Open("pgx", dsn)
+// openDB opens a SQLite database for a DSN of the form sqlite:<path>, and
+// Postgres otherwise.
+func openDB(ctx context.Context, dsn string, timeout time.Duration) (*sql.DB, error) {
+	driverName := "pgx"
+	if sqlitestorage.IsDSN(dsn) {
+		driverName, dsn = sqlitestorage.DriverName, sqlitestorage.DriverDSN(dsn)
+	}
+	db, err := tracing.OpenDB(driverName, dsn)
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.Ping()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err = db.PingContext(ctx)
 	if err != nil {
+		db.Close()
 		return nil, err
 	}
 
 	return db, nil
 }
 
-func newSessionManager() *scs.SessionManager {
+// newHealthChecker checks that the database, if there is one, answers and
+// has the schema of this build and that the session store can be read.
+func newHealthChecker(cfg config.Health, db *sql.DB, dsn string, sessionManager *scs.SessionManager) *health.Checker {
+	checker := health.New(cfg.Timeout)
+	if db != nil {
+		version, latest := migrations.Version, migrations.Latest()
+		if sqlitestorage.IsDSN(dsn) {
+			version, latest = sqlitestorage.Version, sqlitestorage.LatestVersion()
+		}
+		checker.Add("database", db.PingContext)
+		checker.Add("migrations", func(ctx context.Context) error {
+			version, err := version(ctx, db)
+			if err != nil {
+				return err
+			}
+			if version < latest {
+				return fmt.Errorf("schema at version %d, want %d", version, latest)
+			}
+			return nil
+		})
+	}
+	checker.Add("sessions", func(ctx context.Context) error {
+		_, _, err := sessionManager.Store.Find("readyz")
+		return err
+	})
+	return checker
+}
+
+// newMemoryStorage keeps the catalog in memory for development, seeded
+// from the fixture file if there is one.
+func newMemoryStorage(fixtures string) *memorystorage.Storage {
+	store := memorystorage.New()
+	if fixtures != "" {
+		if err := store.LoadFixtures(fixtures); err != nil {
+			fatal("Error loading fixtures", err)
+		}
+	}
+	slog.Warn("Using in-memory storage, data is lost on exit")
+	return store
+}
+
+// newRateLimits returns the request budgets of the routes.
+func newRateLimits(cfg config.RateLimit) routes.RateLimits {
+	return routes.RateLimits{
+		Default:   ratelimit.Policy{Name: "default", Rate: cfg.DefaultRate, Burst: cfg.DefaultBurst, PerIP: cfg.DefaultPerIP},
+		Login:     ratelimit.Policy{Name: "login", Rate: cfg.LoginRate, Burst: cfg.LoginBurst, PerIP: cfg.LoginPerIP},
+		Expensive: ratelimit.Policy{Name: "expensive", Rate: cfg.ExpensiveRate, Burst: cfg.ExpensiveBurst, PerIP: cfg.ExpensivePerIP},
+	}
+}
+
+func newLockoutPolicy(cfg config.Lockout) userusecase.LockoutPolicy {
+	return userusecase.LockoutPolicy{
+		Account:    userusecase.Limits{Free: cfg.AccountFree, LockAfter: cfg.AccountLockAfter},
+		IP:         userusecase.Limits{Free: cfg.IPFree, LockAfter: cfg.IPLockAfter},
+		BaseDelay:  cfg.BaseDelay,
+		MaxDelay:   cfg.MaxDelay,
+		LockFor:    cfg.LockFor,
+		ResetAfter: cfg.ResetAfter,
+	}
+}
+
+// newRateLimitStore keeps request budgets in memory for a single instance
+// or in Postgres to share them between replicas.
+func newRateLimitStore(cfg config.RateLimit, db *sql.DB) ratelimit.Store {
+	if cfg.Store == "postgres" {
+		return ratelimit.NewPostgresStore(db, cfg.Timeout)
+	}
+	return ratelimit.NewMemoryStore()
+}
+
+// newCORS refuses cross-origin requests unless origins are allowed.
+func newCORS(cfg config.CORS) *middleware.CORS {
+	cors, err := middleware.NewCORS(middleware.CORSOptions{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowedMethods:   cfg.AllowedMethods,
+		AllowedHeaders:   cfg.AllowedHeaders,
+		ExposedHeaders:   cfg.ExposedHeaders,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	})
+	if err != nil {
+		fatal("Invalid CORS settings", err)
+	}
+	return cors
+}
+
+// newMailer writes password reset mails to a file or the log for local
+// development, or sends them through SMTP.
+func newMailer(cfg config.Mail) mail.Mailer {
+	if cfg.Mailer == "smtp" {
+		return mail.NewSMTP(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+	}
+	if cfg.File == "" {
+		return mail.NewLog(log.Writer())
+	}
+	f, err := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		fatal("Error opening mail file", err)
+	}
+	return mail.NewLog(f)
+}
+
+// newPasswordHasher hashes new passwords with the configured algorithm,
+// existing hashes are upgraded on login.
+func newPasswordHasher(cfg config.Password) *password.Hasher {
+	params := password.Argon2Params{
+		Memory:  uint32(cfg.Argon2Memory),
+		Time:    uint32(cfg.Argon2Time),
+		Threads: uint8(cfg.Argon2Threads),
+	}
+	hasher, err := password.NewHasher(cfg.Hash, cfg.BcryptCost, params)
+	if err != nil {
+		fatal("Invalid password hash settings", err)
+	}
+	return hasher
+}
+
+// newPasswordPolicy loads the breached password blocklist, a file of
+// passwords or their SHA-1 hashes, one per line.
+func newPasswordPolicy(cfg config.Password) *password.Policy {
+	policy := &password.Policy{MinLength: cfg.MinLength, MaxLength: password.MaxLength}
+	if cfg.Blocklist != "" {
+		f, err := os.Open(cfg.Blocklist)
+		if err != nil {
+			fatal("Error opening password blocklist", err)
+		}
+		defer f.Close()
+		err = policy.LoadBreached(f)
+		if err != nil {
+			fatal("Error reading password blocklist", err)
+		}
+		slog.Info("Loaded breached passwords", "count", len(policy.Breached))
+	}
+	return policy
+}
+
+var sameSite = map[string]http.SameSite{
+	"lax":    http.SameSiteLaxMode,
+	"strict": http.SameSiteStrictMode,
+	"none":   http.SameSiteNoneMode,
+}
+
+// newTracerProvider installs the tracer provider exporting to the exporter
+// of cfg and the W3C trace context propagator. Without an exporter spans are
+// not recorded, but incoming trace IDs still end up in the logs.
+func newTracerProvider(ctx context.Context, cfg config.Tracing) *sdktrace.TracerProvider {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "none":
+		return nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "file":
+		f, ferr := os.OpenFile(cfg.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if ferr != nil {
+			fatal("Error opening trace file", ferr)
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	case "otlp":
+		// without an endpoint the exporter follows the OTEL_EXPORTER_OTLP_*
+		// variables
+		var opts []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	}
+	if err != nil {
+		fatal("Error creating trace exporter", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		fatal("Error describing trace resource", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	slog.Info("Exporting traces", "exporter", cfg.Exporter, "sample_ratio", cfg.SampleRatio)
+	return provider
+}
+
+// flushTraces exports the spans still buffered by provider, if any.
+func flushTraces(provider *sdktrace.TracerProvider, timeout time.Duration) {
+	if provider == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := provider.Shutdown(ctx); err != nil {
+		slog.Error("Error flushing traces", "err", err)
+	}
+}
+
+func newSessionManager(cfg config.Session) *scs.SessionManager {
 	sessionManager := scs.New()
-	sessionManager.Lifetime = 24 * time.Hour
+	sessionManager.Lifetime = cfg.Lifetime
 	sessionManager.Cookie.Persist = true
-	sessionManager.Cookie.SameSite = http.SameSiteLaxMode
-	sessionManager.Cookie.Secure = true
+	sessionManager.Cookie.SameSite = sameSite[cfg.CookieSameSite]
+	sessionManager.Cookie.Secure = cfg.CookieSecure
 	sessionManager.Store = memstore.New()
 	return sessionManager
 }
+
+// fatal logs err at error level and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
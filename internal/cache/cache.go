@@ -0,0 +1,164 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache is the store used by the caching storage decorators.
+//
+// Every Delete and DeletePrefix increments the generation. A value loaded
+// while the generation was gen is stored with SetAt, which drops it if an
+// invalidation happened since, as the value may predate the write behind it.
+type Cache interface {
+	Get(key string) (any, bool)
+	Set(key string, value any)
+	SetAt(key string, value any, gen uint64)
+	Generation() uint64
+	Delete(key string)
+	DeletePrefix(prefix string)
+	Stats() Stats
+}
+
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+	Capacity  int    `json:"capacity"`
+}
+
+// LRU is an in-process Cache holding at most capacity entries, each for at
+// most ttl. The least recently used entry is evicted first. A capacity of
+// zero disables caching.
+type LRU struct {
+	capacity int
+	ttl      time.Duration
+
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	order      *list.List
+	generation uint64
+	stats      Stats
+}
+
+type entry struct {
+	key     string
+	value   any
+	expires time.Time
+}
+
+func NewLRU(capacity int, ttl time.Duration) *LRU {
+	return &LRU{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+func (c *LRU) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if time.Now().After(e.expires) {
+		c.remove(el)
+		c.stats.Misses++
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	c.stats.Hits++
+	return e.value, true
+}
+
+func (c *LRU) Set(key string, value any) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.set(key, value)
+}
+
+// SetAt stores value unless the generation moved past gen.
+func (c *LRU) SetAt(key string, value any, gen uint64) {
+	if c.capacity <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != gen {
+		return
+	}
+	c.set(key, value)
+}
+
+func (c *LRU) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.generation
+}
+
+func (c *LRU) set(key string, value any) {
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*entry)
+		e.value = value
+		e.expires = time.Now().Add(c.ttl)
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&entry{key: key, value: value, expires: time.Now().Add(c.ttl)})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *LRU) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+}
+
+// DeletePrefix removes every entry whose key starts with prefix.
+func (c *LRU) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for key, el := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(el)
+		}
+	}
+}
+
+func (c *LRU) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Size = c.order.Len()
+	stats.Capacity = c.capacity
+	return stats
+}
+
+func (c *LRU) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*entry).key)
+}
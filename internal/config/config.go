@@ -0,0 +1,341 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Config is the whole configuration of the API. Every setting has a
+// default, and can be set by its dotted path in a JSON config file, by the
+// environment variable in its env tag, by a file named in that variable
+// with a _FILE suffix (Docker secrets), or by a flag named like its path,
+// in increasing order of precedence. Settings tagged secret are redacted
+// when printed. Storage db keeps the catalog in the database of DB.DSN,
+// Postgres, or SQLite for a DSN of the form sqlite:<path>. Storage memory
+// keeps everything in memory, seeded from the Fixtures file if one is
+// named, for development without a database.
+type Config struct {
+	HTTP            HTTP            `json:"http"`
+	GRPC            GRPC            `json:"grpc"`
+	Storage         string          `json:"storage" env:"STORAGE"`
+	Fixtures        string          `json:"fixtures" env:"FIXTURES"`
+	DB              DB              `json:"db"`
+	Session         Session         `json:"session"`
+	Health          Health          `json:"health"`
+	Cache           Cache           `json:"cache"`
+	Analytics       Analytics       `json:"analytics"`
+	GraphQL         GraphQL         `json:"graphql"`
+	Recommendations Recommendations `json:"recommendations"`
+	RateLimit       RateLimit       `json:"rate_limit"`
+	Lockout         Lockout         `json:"lockout"`
+	Mail            Mail            `json:"mail"`
+	Password        Password        `json:"password"`
+	CORS            CORS            `json:"cors"`
+	Log             Log             `json:"log"`
+	Tracing         Tracing         `json:"tracing"`
+}
+
+type HTTP struct {
+	Addr              string        `json:"addr" env:"PORT"`
+	ReadTimeout       time.Duration `json:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `json:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `json:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `json:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	ShutdownTimeout   time.Duration `json:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	ShutdownDelay     time.Duration `json:"shutdown_delay" env:"SHUTDOWN_DELAY"`
+}
+
+type GRPC struct {
+	Addr string `json:"addr" env:"GRPC_PORT"`
+}
+
+type DB struct {
+	DSN               string        `json:"dsn" env:"DSN" secret:"true"`
+	Timeout           time.Duration `json:"timeout" env:"DB_TIMEOUT"`
+	AnalyticsTimeout  time.Duration `json:"analytics_timeout" env:"DB_ANALYTICS_TIMEOUT"`
+	MigrationTimeout  time.Duration `json:"migration_timeout" env:"DB_MIGRATION_TIMEOUT"`
+	ConnectAttempts   int           `json:"connect_attempts" env:"DB_CONNECT_ATTEMPTS"`
+	ConnectBackoff    time.Duration `json:"connect_backoff" env:"DB_CONNECT_BACKOFF"`
+	ConnectMaxBackoff time.Duration `json:"connect_max_backoff" env:"DB_CONNECT_MAX_BACKOFF"`
+}
+
+type Session struct {
+	Lifetime       time.Duration `json:"lifetime" env:"SESSION_LIFETIME"`
+	CookieSecure   bool          `json:"cookie_secure" env:"SESSION_COOKIE_SECURE"`
+	CookieSameSite string        `json:"cookie_same_site" env:"SESSION_COOKIE_SAMESITE"`
+}
+
+type Health struct {
+	Timeout time.Duration `json:"timeout" env:"HEALTH_TIMEOUT"`
+}
+
+type Cache struct {
+	Size int           `json:"size" env:"CACHE_SIZE"`
+	TTL  time.Duration `json:"ttl" env:"CACHE_TTL"`
+}
+
+type Analytics struct {
+	TTL time.Duration `json:"ttl" env:"ANALYTICS_TTL"`
+}
+
+type Recommendations struct {
+	IndexTTL time.Duration `json:"index_ttl" env:"RECOMMENDATIONS_INDEX_TTL"`
+}
+
+type GraphQL struct {
+	MaxDepth       int `json:"max_depth" env:"GRAPHQL_MAX_DEPTH"`
+	MaxParallelism int `json:"max_parallelism" env:"GRAPHQL_MAX_PARALLELISM"`
+	MaxQueryLength int `json:"max_query_length" env:"GRAPHQL_MAX_QUERY_LENGTH"`
+}
+
+// RateLimit budgets are in requests per second and requests at once. The
+// default budget applies to every request, login guards logging in and
+// password resets, expensive the routes that walk the whole catalog.
+type RateLimit struct {
+	Store          string        `json:"store" env:"RATE_LIMIT_STORE"`
+	Timeout        time.Duration `json:"timeout" env:"RATE_LIMIT_TIMEOUT"`
+	DefaultRate    float64       `json:"default_rate" env:"RATE_LIMIT_DEFAULT_RATE"`
+	DefaultBurst   int           `json:"default_burst" env:"RATE_LIMIT_DEFAULT_BURST"`
+	DefaultPerIP   bool          `json:"default_per_ip" env:"RATE_LIMIT_DEFAULT_PER_IP"`
+	LoginRate      float64       `json:"login_rate" env:"RATE_LIMIT_LOGIN_RATE"`
+	LoginBurst     int           `json:"login_burst" env:"RATE_LIMIT_LOGIN_BURST"`
+	LoginPerIP     bool          `json:"login_per_ip" env:"RATE_LIMIT_LOGIN_PER_IP"`
+	ExpensiveRate  float64       `json:"expensive_rate" env:"RATE_LIMIT_EXPENSIVE_RATE"`
+	ExpensiveBurst int           `json:"expensive_burst" env:"RATE_LIMIT_EXPENSIVE_BURST"`
+	ExpensivePerIP bool          `json:"expensive_per_ip" env:"RATE_LIMIT_EXPENSIVE_PER_IP"`
+}
+
+// Lockout throttles failed logins per account and per client address. After
+// the free failures every attempt waits base_delay, doubling up to
+// max_delay, after lock_after failures the key is locked for lock_for.
+// Failures are forgotten after reset_after.
+type Lockout struct {
+	AccountFree      int           `json:"account_free" env:"LOCKOUT_ACCOUNT_FREE"`
+	AccountLockAfter int           `json:"account_lock_after" env:"LOCKOUT_ACCOUNT_LOCK_AFTER"`
+	IPFree           int           `json:"ip_free" env:"LOCKOUT_IP_FREE"`
+	IPLockAfter      int           `json:"ip_lock_after" env:"LOCKOUT_IP_LOCK_AFTER"`
+	BaseDelay        time.Duration `json:"base_delay" env:"LOCKOUT_BASE_DELAY"`
+	MaxDelay         time.Duration `json:"max_delay" env:"LOCKOUT_MAX_DELAY"`
+	LockFor          time.Duration `json:"lock_for" env:"LOCKOUT_LOCK_FOR"`
+	ResetAfter       time.Duration `json:"reset_after" env:"LOCKOUT_RESET_AFTER"`
+}
+
+type Mail struct {
+	Mailer       string        `json:"mailer" env:"MAILER"`
+	File         string        `json:"file" env:"MAIL_FILE"`
+	From         string        `json:"from" env:"MAIL_FROM"`
+	SMTPAddr     string        `json:"smtp_addr" env:"SMTP_ADDR"`
+	SMTPUsername string        `json:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string        `json:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	ResetURL     string        `json:"reset_url" env:"PASSWORD_RESET_URL"`
+	ResetTTL     time.Duration `json:"reset_ttl" env:"PASSWORD_RESET_TTL"`
+}
+
+type Password struct {
+	Hash          string `json:"hash" env:"PASSWORD_HASH"`
+	BcryptCost    int    `json:"bcrypt_cost" env:"BCRYPT_COST"`
+	Argon2Memory  int    `json:"argon2_memory" env:"ARGON2_MEMORY"`
+	Argon2Time    int    `json:"argon2_time" env:"ARGON2_TIME"`
+	Argon2Threads int    `json:"argon2_threads" env:"ARGON2_THREADS"`
+	MinLength     int    `json:"min_length" env:"PASSWORD_MIN_LENGTH"`
+	Blocklist     string `json:"blocklist" env:"PASSWORD_BLOCKLIST"`
+}
+
+type CORS struct {
+	AllowedOrigins   []string      `json:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `json:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `json:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string      `json:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool          `json:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `json:"max_age" env:"CORS_MAX_AGE"`
+}
+
+type Log struct {
+	Level  string `json:"level" env:"LOG_LEVEL"`
+	Format string `json:"format" env:"LOG_FORMAT"`
+}
+
+type Tracing struct {
+	Exporter     string  `json:"exporter" env:"TRACING_EXPORTER"`
+	File         string  `json:"file" env:"TRACING_FILE"`
+	OTLPEndpoint string  `json:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT"`
+	SampleRatio  float64 `json:"sample_ratio" env:"TRACING_SAMPLE_RATIO"`
+	ServiceName  string  `json:"service_name" env:"TRACING_SERVICE_NAME"`
+}
+
+// Default returns the configuration used for everything not set otherwise.
+func Default() *Config {
+	return &Config{
+		HTTP: HTTP{
+			Addr:              ":80",
+			ReadTimeout:       15 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		GRPC:    GRPC{Addr: ":9090"},
+		Storage: "db",
+		DB: DB{
+			Timeout:           5 * time.Second,
+			AnalyticsTimeout:  10 * time.Second,
+			MigrationTimeout:  time.Minute,
+			ConnectAttempts:   11,
+			ConnectBackoff:    time.Second,
+			ConnectMaxBackoff: 30 * time.Second,
+		},
+		Session: Session{
+			Lifetime:       24 * time.Hour,
+			CookieSecure:   true,
+			CookieSameSite: "lax",
+		},
+		Health:          Health{Timeout: 2 * time.Second},
+		Cache:           Cache{Size: 1000, TTL: time.Minute},
+		Analytics:       Analytics{TTL: 5 * time.Minute},
+		GraphQL:         GraphQL{MaxDepth: 6, MaxParallelism: 10, MaxQueryLength: 4096},
+		Recommendations: Recommendations{IndexTTL: 10 * time.Minute},
+		RateLimit: RateLimit{
+			Store:          "memory",
+			Timeout:        time.Second,
+			DefaultRate:    20,
+			DefaultBurst:   40,
+			LoginRate:      5.0 / 60,
+			LoginBurst:     5,
+			LoginPerIP:     true,
+			ExpensiveRate:  1,
+			ExpensiveBurst: 5,
+		},
+		// lenient enough per address for users sharing a NAT, while a
+		// single account can only be guessed a few times an hour
+		Lockout: Lockout{
+			AccountFree:      3,
+			AccountLockAfter: 10,
+			IPFree:           10,
+			IPLockAfter:      50,
+			BaseDelay:        time.Second,
+			MaxDelay:         time.Minute,
+			LockFor:          15 * time.Minute,
+			ResetAfter:       15 * time.Minute,
+		},
+		Mail: Mail{Mailer: "log", ResetTTL: time.Hour},
+		// argon2id parameters of the second recommended option of RFC 9106,
+		// memory in KiB.
+		Password: Password{
+			Hash:          "bcrypt",
+			BcryptCost:    10,
+			Argon2Memory:  64 * 1024,
+			Argon2Time:    3,
+			Argon2Threads: 4,
+			MinLength:     10,
+		},
+		CORS: CORS{
+			AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "If-Modified-Since", "If-None-Match", "X-CSRF-Token", "X-Request-ID", "traceparent", "tracestate"},
+			ExposedHeaders: []string{"Deprecation", "ETag", "Last-Modified", "Link", "Location", "RateLimit-Limit", "RateLimit-Policy", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "X-Request-ID"},
+			MaxAge:         10 * time.Minute,
+		},
+		Log:     Log{Level: "info", Format: "text"},
+		Tracing: Tracing{Exporter: "none", SampleRatio: 1, ServiceName: "filmoteka"},
+	}
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, setting string, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s (%s): %s", setting, envName(setting), fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(validAddr(c.HTTP.Addr), "http.addr", "must be a listen address such as :80, got %q", c.HTTP.Addr)
+	check(c.HTTP.ReadTimeout >= 0, "http.read_timeout", "must not be negative, 0 means none")
+	check(c.HTTP.ReadHeaderTimeout >= 0, "http.read_header_timeout", "must not be negative, 0 means http.read_timeout")
+	check(c.HTTP.WriteTimeout >= 0, "http.write_timeout", "must not be negative, 0 means none")
+	check(c.HTTP.IdleTimeout >= 0, "http.idle_timeout", "must not be negative, 0 means http.read_timeout")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout", "must be positive")
+	check(c.HTTP.ShutdownDelay >= 0, "http.shutdown_delay", "must not be negative")
+	check(validAddr(c.GRPC.Addr), "grpc.addr", "must be a listen address such as :9090, got %q", c.GRPC.Addr)
+	check(oneOf(c.Storage, "db", "memory"), "storage", "must be db or memory, got %q", c.Storage)
+	check(c.Fixtures == "" || c.Storage == "memory", "fixtures", "requires storage memory")
+	check(c.DB.DSN != "" || c.Storage != "db", "db.dsn", "is required with storage db")
+	check(c.DB.Timeout > 0, "db.timeout", "must be positive")
+	check(c.DB.AnalyticsTimeout > 0, "db.analytics_timeout", "must be positive")
+	check(c.DB.MigrationTimeout > 0, "db.migration_timeout", "must be positive")
+	check(c.DB.ConnectAttempts > 0, "db.connect_attempts", "must be at least 1")
+	check(c.DB.ConnectBackoff >= 0, "db.connect_backoff", "must not be negative")
+	check(c.DB.ConnectMaxBackoff >= c.DB.ConnectBackoff, "db.connect_max_backoff", "must not be less than db.connect_backoff")
+	check(c.Session.Lifetime > 0, "session.lifetime", "must be positive")
+	check(oneOf(c.Session.CookieSameSite, "lax", "strict", "none"), "session.cookie_same_site", "must be lax, strict or none, got %q", c.Session.CookieSameSite)
+	check(c.Session.CookieSameSite != "none" || c.Session.CookieSecure, "session.cookie_same_site", "none requires session.cookie_secure")
+	check(c.Health.Timeout > 0, "health.timeout", "must be positive")
+	check(c.Cache.Size >= 0, "cache.size", "must not be negative, 0 disables the cache")
+	check(c.Cache.TTL > 0, "cache.ttl", "must be positive")
+	check(c.Analytics.TTL >= 0, "analytics.ttl", "must not be negative")
+	check(c.Recommendations.IndexTTL > 0, "recommendations.index_ttl", "must be positive")
+	check(c.GraphQL.MaxDepth > 0, "graphql.max_depth", "must be positive")
+	check(c.GraphQL.MaxParallelism > 0, "graphql.max_parallelism", "must be positive")
+	check(c.GraphQL.MaxQueryLength > 0, "graphql.max_query_length", "must be positive")
+	check(oneOf(c.RateLimit.Store, "memory", "postgres"), "rate_limit.store", "must be memory or postgres, got %q", c.RateLimit.Store)
+	check(c.RateLimit.Store != "postgres" || c.Storage == "db" && !strings.HasPrefix(c.DB.DSN, "sqlite:"), "rate_limit.store", "postgres requires storage db with a Postgres db.dsn")
+	check(c.RateLimit.Timeout > 0, "rate_limit.timeout", "must be positive")
+	check(c.RateLimit.DefaultRate > 0, "rate_limit.default_rate", "must be positive")
+	check(c.RateLimit.DefaultBurst >= 1, "rate_limit.default_burst", "must be at least 1")
+	check(c.RateLimit.LoginRate > 0, "rate_limit.login_rate", "must be positive")
+	check(c.RateLimit.LoginBurst >= 1, "rate_limit.login_burst", "must be at least 1")
+	check(c.RateLimit.ExpensiveRate > 0, "rate_limit.expensive_rate", "must be positive")
+	check(c.RateLimit.ExpensiveBurst >= 1, "rate_limit.expensive_burst", "must be at least 1")
+	check(c.Lockout.AccountFree >= 0, "lockout.account_free", "must not be negative")
+	check(c.Lockout.AccountLockAfter > c.Lockout.AccountFree, "lockout.account_lock_after", "must be more than lockout.account_free")
+	check(c.Lockout.IPFree >= 0, "lockout.ip_free", "must not be negative")
+	check(c.Lockout.IPLockAfter > c.Lockout.IPFree, "lockout.ip_lock_after", "must be more than lockout.ip_free")
+	check(c.Lockout.BaseDelay > 0, "lockout.base_delay", "must be positive")
+	check(c.Lockout.MaxDelay >= c.Lockout.BaseDelay, "lockout.max_delay", "must not be less than lockout.base_delay")
+	check(c.Lockout.LockFor > 0, "lockout.lock_for", "must be positive")
+	check(c.Lockout.ResetAfter > 0, "lockout.reset_after", "must be positive")
+	check(oneOf(c.Mail.Mailer, "log", "smtp"), "mail.mailer", "must be log or smtp, got %q", c.Mail.Mailer)
+	check(c.Mail.ResetTTL > 0, "mail.reset_ttl", "must be positive")
+	if c.Mail.Mailer == "smtp" {
+		check(validAddr(c.Mail.SMTPAddr) && !strings.HasPrefix(c.Mail.SMTPAddr, ":"), "mail.smtp_addr", "must be host:port with mail.mailer smtp, got %q", c.Mail.SMTPAddr)
+		_, err := mail.ParseAddress(c.Mail.From)
+		check(err == nil, "mail.from", "must be an email address with mail.mailer smtp, got %q", c.Mail.From)
+	}
+	check(oneOf(c.Password.Hash, "bcrypt", "argon2id"), "password.hash", "must be bcrypt or argon2id, got %q", c.Password.Hash)
+	check(c.Password.BcryptCost >= 4 && c.Password.BcryptCost <= 31, "password.bcrypt_cost", "must be between 4 and 31")
+	check(c.Password.Argon2Threads >= 1 && c.Password.Argon2Threads <= 255, "password.argon2_threads", "must be between 1 and 255")
+	check(c.Password.Argon2Time >= 1, "password.argon2_time", "must be at least 1")
+	check(c.Password.Argon2Memory >= 8*c.Password.Argon2Threads, "password.argon2_memory", "must be at least 8 KiB per thread")
+	check(c.Password.MinLength >= 1, "password.min_length", "must be at least 1")
+	check(!c.CORS.AllowCredentials || !contains(c.CORS.AllowedOrigins, "*"), "cors.allow_credentials", "cannot be combined with the allowed origin *")
+	check(c.CORS.MaxAge >= 0, "cors.max_age", "must not be negative")
+	check(oneOf(strings.ToLower(c.Log.Level), "debug", "info", "warn", "error"), "log.level", "must be debug, info, warn or error, got %q", c.Log.Level)
+	check(oneOf(c.Log.Format, "text", "json"), "log.format", "must be text or json, got %q", c.Log.Format)
+	check(oneOf(c.Tracing.Exporter, "none", "stdout", "file", "otlp"), "tracing.exporter", "must be none, stdout, file or otlp, got %q", c.Tracing.Exporter)
+	check(c.Tracing.Exporter != "file" || c.Tracing.File != "", "tracing.file", "is required with tracing.exporter file")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be between 0 and 1")
+	check(c.Tracing.ServiceName != "", "tracing.service_name", "is required")
+
+	return errors.Join(errs...)
+}
+
+func validAddr(addr string) bool {
+	_, port, err := net.SplitHostPort(addr)
+	return err == nil && port != ""
+}
+
+func oneOf(v string, options ...string) bool {
+	return contains(options, v)
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,271 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting is one leaf field of Config.
+type setting struct {
+	path   string
+	env    string
+	secret bool
+	value  reflect.Value
+}
+
+func settings(c *Config) []setting {
+	var list []setting
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			path := prefix + f.Tag.Get("json")
+			if f.Type.Kind() == reflect.Struct {
+				walk(path+".", v.Field(i))
+				continue
+			}
+			list = append(list, setting{path: path, env: f.Tag.Get("env"), secret: f.Tag.Get("secret") == "true", value: v.Field(i)})
+		}
+	}
+	walk("", reflect.ValueOf(c).Elem())
+	return list
+}
+
+// envName returns the environment variable of the setting at path.
+func envName(path string) string {
+	for _, s := range settings(&Config{}) {
+		if s.path == path {
+			return s.env
+		}
+	}
+	return ""
+}
+
+// Load builds the configuration from the defaults, the JSON file named by
+// the -config flag or CONFIG_FILE, the environment and the flags in args.
+// It only fails on values that cannot be parsed, see Validate for the rest.
+func Load(args []string) (*Config, error) {
+	c := Default()
+	list := settings(c)
+
+	fs := flag.NewFlagSet("filmoteka", flag.ContinueOnError)
+	file := fs.String("config", os.Getenv("CONFIG_FILE"), "JSON config file (CONFIG_FILE)")
+	flags := make(map[string]*flagValue)
+	for _, s := range list {
+		v := &flagValue{setting: s}
+		flags[s.path] = v
+		fs.Var(v, s.path, "overrides "+s.env)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+
+	if *file != "" {
+		if err := loadFile(list, *file); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range list {
+		raw, ok, err := lookupEnv(s.env)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if err = set(s.value, raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.env, err)
+		}
+	}
+
+	for _, s := range list {
+		v := flags[s.path]
+		if !v.set {
+			continue
+		}
+		if err := set(s.value, v.raw); err != nil {
+			return nil, fmt.Errorf("-%s: %w", s.path, err)
+		}
+	}
+
+	return c, nil
+}
+
+// lookupEnv reads the variable key, or the file named by key_FILE.
+func lookupEnv(key string) (string, bool, error) {
+	raw, ok := os.LookupEnv(key)
+	path, fromFile := os.LookupEnv(key + "_FILE")
+	if !fromFile {
+		return raw, ok, nil
+	}
+	if ok {
+		return "", false, fmt.Errorf("only one of %s and %s_FILE can be set", key, key)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %w", key, err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), true, nil
+}
+
+// loadFile applies a JSON file shaped like the output of Print.
+func loadFile(list []setting, path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	var doc map[string]any
+	if err = json.Unmarshal(b, &doc); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	values := make(map[string]any)
+	var flatten func(prefix string, m map[string]any)
+	flatten = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			if sub, ok := v.(map[string]any); ok {
+				flatten(prefix+k+".", sub)
+				continue
+			}
+			values[prefix+k] = v
+		}
+	}
+	flatten("", doc)
+
+	for _, s := range list {
+		v, ok := values[s.path]
+		if !ok {
+			continue
+		}
+		delete(values, s.path)
+
+		var raw string
+		switch v := v.(type) {
+		case nil:
+			s.value.Set(reflect.Zero(s.value.Type()))
+			continue
+		case string:
+			raw = v
+		case bool:
+			raw = strconv.FormatBool(v)
+		case float64:
+			raw = strconv.FormatFloat(v, 'f', -1, 64)
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			raw = strings.Join(items, ",")
+		default:
+			return fmt.Errorf("config file %s: %s: unsupported value %v", path, s.path, v)
+		}
+		if err = set(s.value, raw); err != nil {
+			return fmt.Errorf("config file %s: %s: %w", path, s.path, err)
+		}
+	}
+
+	if len(values) > 0 {
+		unknown := make([]string, 0, len(values))
+		for k := range values {
+			unknown = append(unknown, k)
+		}
+		sort.Strings(unknown)
+		return fmt.Errorf("config file %s: unknown settings %s", path, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses raw into v. Lists are comma separated.
+func set(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return errors.New("invalid duration " + strconv.Quote(raw) + ", use a Go duration such as 90s or 5m")
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.New("invalid integer " + strconv.Quote(raw))
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return errors.New("invalid number " + strconv.Quote(raw))
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("invalid boolean " + strconv.Quote(raw))
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// flagValue records a flag so that it can be applied after the file and
+// the environment.
+type flagValue struct {
+	setting
+	raw string
+	set bool
+}
+
+func (f *flagValue) String() string {
+	if f == nil || !f.value.IsValid() {
+		return ""
+	}
+	return format(f.value)
+}
+
+// IsBoolFlag lets boolean settings be turned on by a bare flag, such as
+// -session.cookie_secure.
+func (f *flagValue) IsBoolFlag() bool {
+	return f != nil && f.value.IsValid() && f.value.Kind() == reflect.Bool
+}
+
+func (f *flagValue) Set(raw string) error {
+	f.raw, f.set = raw, true
+	return nil
+}
+
+func format(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.json", `{
+		"cache": {"size": 10, "ttl": "2m"},
+		"log": {"level": "debug"},
+		"db": {"dsn": "postgres://file"},
+		"session": {"cookie_secure": false}
+	}`)
+	t.Setenv("CACHE_TTL", "3m")
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("DSN_FILE", writeFile(t, "dsn", "postgres://secret-file\n"))
+	t.Setenv("SESSION_COOKIE_SECURE", "false")
+
+	c, err := Load([]string{"-config", file, "-log.level=error", "-session.cookie_secure", "-rate_limit.default_per_ip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		setting string
+		got     any
+		want    any
+	}{
+		{"health.timeout from the defaults", c.Health.Timeout, 2 * time.Second},
+		{"cache.size from the file", c.Cache.Size, 10},
+		{"cache.ttl from the environment over the file", c.Cache.TTL, 3 * time.Minute},
+		{"db.dsn from DSN_FILE over the file", c.DB.DSN, "postgres://secret-file"},
+		{"log.level from a flag over the environment", c.Log.Level, "error"},
+		{"session.cookie_secure from a bare flag over the environment", c.Session.CookieSecure, true},
+		{"rate_limit.default_per_ip from a bare flag", c.RateLimit.DefaultPerIP, true},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.setting, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadBoolFlagValue(t *testing.T) {
+	c, err := Load([]string{"-session.cookie_secure=false"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Session.CookieSecure {
+		t.Error("-session.cookie_secure=false: got cookie_secure true")
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	unknown := writeFile(t, "unknown.json", `{"cache": {"colour": "red"}}`)
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		wantErr string
+	}{
+		{"variable and file", nil, map[string]string{"DSN": "postgres://env", "DSN_FILE": "dsn"}, "only one of DSN and DSN_FILE"},
+		{"invalid environment", nil, map[string]string{"CACHE_SIZE": "many"}, "CACHE_SIZE: invalid integer"},
+		{"invalid flag", []string{"-cache.ttl=soon"}, nil, "-cache.ttl: invalid duration"},
+		{"unknown file setting", []string{"-config", unknown}, nil, "unknown settings cache.colour"},
+		{"arguments", []string{"serve"}, nil, "unexpected arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load(tt.args)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrintLoadsBack(t *testing.T) {
+	var b strings.Builder
+	if err := Print(&b, Default()); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load([]string{"-config", writeFile(t, "config.json", b.String())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c, Default()) {
+		t.Errorf("loading the printed defaults: got %+v, want %+v", c, Default())
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// Print writes c as a JSON config file, with secrets that are set replaced
+// by REDACTED.
+func Print(w io.Writer, c *Config) error {
+	doc := make(map[string]any)
+	for _, s := range settings(c) {
+		var v any = s.value.Interface()
+		switch {
+		case s.secret && !s.value.IsZero():
+			v = redacted
+		case s.value.Type() == durationType:
+			v = time.Duration(s.value.Int()).String()
+		case s.value.Kind() == reflect.Slice && s.value.IsNil():
+			v = []string{}
+		}
+
+		section := doc
+		parts := strings.Split(s.path, ".")
+		for _, p := range parts[:len(parts)-1] {
+			sub, ok := section[p].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				section[p] = sub
+			}
+			section = sub
+		}
+		section[parts[len(parts)-1]] = v
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
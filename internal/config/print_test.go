@@ -0,0 +1,53 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	c := Default()
+	c.DB.DSN = "postgres://filmoteka:hunter2@db/filmoteka"
+	c.Mail.SMTPPassword = "correct horse"
+	c.Mail.SMTPUsername = "mailer"
+
+	var b strings.Builder
+	if err := Print(&b, c); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2", "correct horse"} {
+		if strings.Contains(b.String(), secret) {
+			t.Errorf("output contains the secret %q:\n%s", secret, b.String())
+		}
+	}
+
+	var doc struct {
+		DB struct {
+			DSN string `json:"dsn"`
+		} `json:"db"`
+		Mail struct {
+			SMTPUsername string `json:"smtp_username"`
+			SMTPPassword string `json:"smtp_password"`
+		} `json:"mail"`
+	}
+	if err := json.Unmarshal([]byte(b.String()), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.DB.DSN != redacted || doc.Mail.SMTPPassword != redacted {
+		t.Errorf("got db.dsn %q and mail.smtp_password %q, want both %s", doc.DB.DSN, doc.Mail.SMTPPassword, redacted)
+	}
+	if doc.Mail.SMTPUsername != "mailer" {
+		t.Errorf("got mail.smtp_username %q, want it printed", doc.Mail.SMTPUsername)
+	}
+}
+
+func TestPrintLeavesUnsetSecretsEmpty(t *testing.T) {
+	var b strings.Builder
+	if err := Print(&b, Default()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), redacted) {
+		t.Errorf("unset secrets are redacted:\n%s", b.String())
+	}
+}
@@ -0,0 +1,81 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"filmoteka/internal/delivery/grpc/pb"
+	"filmoteka/internal/domain/models"
+	"google.golang.org/grpc"
+)
+
+type actorService struct {
+	pb.UnimplementedActorServiceServer
+	actorUseCase actorUseCase
+}
+
+func (s *actorService) ListActors(req *pb.ListActorsRequest, stream grpc.ServerStreamingServer[pb.Actor]) error {
+	actors, err := s.actorUseCase.GetAllActors(stream.Context())
+	if errors.Is(err, models.ErrNoRecord) {
+		return nil
+	} else if err != nil {
+		return toStatus(stream.Context(), err, "error getting actors")
+	}
+
+	for _, a := range actors {
+		if err := stream.Send(toPBActor(a)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *actorService) GetActor(ctx context.Context, req *pb.GetActorRequest) (*pb.Actor, error) {
+	id, err := parseID(req.GetActorId())
+	if err != nil {
+		return nil, err
+	}
+	actor, err := s.actorUseCase.GetActorByID(ctx, id)
+	if err != nil {
+		return nil, toStatus(ctx, err, "error getting actor")
+	}
+	return toPBActor(actor), nil
+}
+
+func (s *actorService) CreateActor(ctx context.Context, req *pb.CreateActorRequest) (*pb.Actor, error) {
+	actor, err := fromPBActor(req.GetActor())
+	if err != nil {
+		return nil, err
+	}
+	actor, err = s.actorUseCase.CreateActor(ctx, actor)
+	if err != nil {
+		return nil, toStatus(ctx, err, "error creating actor")
+	}
+	return toPBActor(actor), nil
+}
+
+func (s *actorService) UpdateActor(ctx context.Context, req *pb.UpdateActorRequest) (*pb.Actor, error) {
+	actor, err := fromPBActor(req.GetActor())
+	if err != nil {
+		return nil, err
+	}
+	if _, err = parseID(int64(actor.ActorID)); err != nil {
+		return nil, err
+	}
+	actor, err = s.actorUseCase.UpdateActor(ctx, actor)
+	if err != nil {
+		return nil, toStatus(ctx, err, "error updating actor")
+	}
+	return toPBActor(actor), nil
+}
+
+func (s *actorService) DeleteActor(ctx context.Context, req *pb.DeleteActorRequest) (*pb.DeleteActorResponse, error) {
+	id, err := parseID(req.GetActorId())
+	if err != nil {
+		return nil, err
+	}
+	err = s.actorUseCase.DeleteActor(ctx, id)
+	if err != nil {
+		return nil, toStatus(ctx, err, "error deleting actor")
+	}
+	return &pb.DeleteActorResponse{}, nil
+}
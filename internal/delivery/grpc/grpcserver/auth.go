@@ -0,0 +1,122 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"filmoteka/internal/delivery/grpc/pb"
+	"filmoteka/internal/domain/models"
+	"github.com/alexedwards/scs/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// adminMethods lists the RPCs that need an admin session, mirroring the
+// write endpoints of the HTTP API.
+var adminMethods = map[string]bool{
+	pb.MovieService_CreateMovie_FullMethodName:   true,
+	pb.MovieService_UpdateMovie_FullMethodName:   true,
+	pb.MovieService_DeleteMovie_FullMethodName:   true,
+	pb.ActorService_CreateActor_FullMethodName:   true,
+	pb.ActorService_UpdateActor_FullMethodName:   true,
+	pb.ActorService_DeleteActor_FullMethodName:   true,
+	pb.CreditService_AddCredit_FullMethodName:    true,
+	pb.CreditService_RemoveCredit_FullMethodName: true,
+}
+
+type auth struct {
+	sessionManager *scs.SessionManager
+}
+
+// authorize loads the session named by the bearer token, if any, and checks
+// the role required by the method.
+func (a *auth) authorize(ctx context.Context, method string) (context.Context, error) {
+	token := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("authorization") {
+			if t, ok := strings.CutPrefix(v, "Bearer "); ok {
+				token = t
+			}
+		}
+	}
+
+	loaded, err := a.sessionManager.Load(ctx, token)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error loading session", "err", err)
+		return nil, status.Error(codes.Internal, "error loading session")
+	}
+	ctx = loaded
+
+	if adminMethods[method] && a.sessionManager.GetString(ctx, "role") != "admin" {
+		if token == "" {
+			return nil, status.Error(codes.Unauthenticated, "unauthorized")
+		}
+		return nil, status.Error(codes.PermissionDenied, "unauthorized")
+	}
+	return ctx, nil
+}
+
+func (a *auth) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *auth) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+type authService struct {
+	pb.UnimplementedAuthServiceServer
+	userUseCase    userUseCase
+	sessionManager *scs.SessionManager
+}
+
+func (s *authService) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	user, err := s.userUseCase.Login(ctx, req.GetEmail(), req.GetPassword(), peerIP(ctx))
+	var throttled *models.LoginThrottledError
+	if errors.As(err, &throttled) {
+		return nil, status.Errorf(codes.ResourceExhausted, "%s, retry in %s", throttled, throttled.RetryAfter.Round(time.Second))
+	} else if errors.Is(err, models.ErrInvalidCredentials) {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error logging in", "err", err)
+		return nil, status.Error(codes.Internal, "error logging in")
+	}
+
+	// the interceptor loaded an empty session for this call, storing the role
+	// in it and committing issues a new token
+	err = s.sessionManager.RenewToken(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error renewing session token", "err", err)
+		return nil, status.Error(codes.Internal, "error creating session")
+	}
+	s.sessionManager.Put(ctx, "role", user.Role)
+	s.sessionManager.Put(ctx, "email", user.Email)
+	token, expiry, err := s.sessionManager.Commit(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error committing session", "err", err)
+		return nil, status.Error(codes.Internal, "error creating session")
+	}
+
+	return &pb.LoginResponse{Token: token, Role: user.Role, ExpiresAtUnix: expiry.Unix()}, nil
+}
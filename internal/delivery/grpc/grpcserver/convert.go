@@ -0,0 +1,83 @@
+package grpcserver
+
+import (
+	"database/sql"
+	"filmoteka/internal/delivery/grpc/pb"
+	"filmoteka/internal/domain/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+func formatDate(d models.Date) string {
+	if !d.Valid {
+		return ""
+	}
+	return d.Time.Format(dateLayout)
+}
+
+func parseDate(s string) (models.Date, error) {
+	if s == "" {
+		return models.Date{}, nil
+	}
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return models.Date{}, status.Error(codes.InvalidArgument, "dates must be formatted as YYYY-MM-DD")
+	}
+	return models.Date{NullTime: sql.NullTime{Time: t, Valid: true}}, nil
+}
+
+func parseID(id int64) (int, error) {
+	if id < 1 {
+		return 0, status.Error(codes.InvalidArgument, "invalid id")
+	}
+	return int(id), nil
+}
+
+func toPBMovie(m *models.Movie) *pb.Movie {
+	return &pb.Movie{
+		MovieId:     int64(m.MovieID),
+		Title:       m.Title,
+		Description: m.Description,
+		Rating:      m.Rating,
+		ReleaseDate: formatDate(m.ReleaseDate),
+	}
+}
+
+func toPBActor(a *models.Actor) *pb.Actor {
+	return &pb.Actor{
+		ActorId:     int64(a.ActorID),
+		Name:        a.Name,
+		Gender:      a.Gender,
+		DateOfBirth: formatDate(a.DateOfBirth),
+	}
+}
+
+func fromPBMovie(m *pb.Movie) (*models.Movie, error) {
+	releaseDate, err := parseDate(m.GetReleaseDate())
+	if err != nil {
+		return nil, err
+	}
+	return &models.Movie{
+		MovieID:     int(m.GetMovieId()),
+		Title:       m.GetTitle(),
+		Description: m.GetDescription(),
+		Rating:      m.GetRating(),
+		ReleaseDate: releaseDate,
+	}, nil
+}
+
+func fromPBActor(a *pb.Actor) (*models.Actor, error) {
+	dateOfBirth, err := parseDate(a.GetDateOfBirth())
+	if err != nil {
+		return nil, err
+	}
+	return &models.Actor{
+		ActorID:     int(a.GetActorId()),
+		Name:        a.GetName(),
+		Gender:      a.GetGender(),
+		DateOfBirth: dateOfBirth,
+	}, nil
+}
@@ -0,0 +1,105 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"filmoteka/internal/delivery/grpc/pb"
+	"filmoteka/internal/domain/models"
+	"google.golang.org/grpc"
+)
+
+type creditService struct {
+	pb.UnimplementedCreditServiceServer
+	actorMovieUseCase actorMovieUseCase
+}
+
+func (s *creditService) ListActorsForMovie(req *pb.ListActorsForMovieRequest, stream grpc.ServerStreamingServer[pb.Actor]) error {
+	id, err := parseID(req.GetMovieId())
+	if err != nil {
+		return err
+	}
+	actors, _, err := s.actorMovieUseCase.GetActorsForMovie(stream.Context(), id)
+	if err != nil {
+		return toStatus(stream.Context(), err, "error getting actors for movie")
+	}
+
+	for _, a := range actors {
+		if err := stream.Send(toPBActor(a)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *creditService) ListMoviesForActor(req *pb.ListMoviesForActorRequest, stream grpc.ServerStreamingServer[pb.Movie]) error {
+	id, err := parseID(req.GetActorId())
+	if err != nil {
+		return err
+	}
+	movies, _, err := s.actorMovieUseCase.GetMoviesForActor(stream.Context(), id)
+	if err != nil {
+		return toStatus(stream.Context(), err, "error getting movies for actor")
+	}
+
+	for _, m := range movies {
+		if err := stream.Send(toPBMovie(m)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *creditService) ListMoviesByActorName(req *pb.ListMoviesByActorNameRequest, stream grpc.ServerStreamingServer[pb.MovieWithActor]) error {
+	movies, err := s.actorMovieUseCase.GetMovieByActorName(stream.Context(), req.GetFirstname(), req.GetLastname())
+	if errors.Is(err, models.ErrNoRecord) {
+		return nil
+	} else if err != nil {
+		return toStatus(stream.Context(), err, "error getting movies")
+	}
+
+	for _, m := range movies {
+		movie := toPBMovie(&models.Movie{
+			MovieID:     m.MovieID,
+			Title:       m.Title,
+			Description: m.Description,
+			Rating:      m.Rating,
+			ReleaseDate: m.ReleaseDate,
+		})
+		if err := stream.Send(&pb.MovieWithActor{Movie: movie, ActorName: m.ActorName}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *creditService) AddCredit(ctx context.Context, req *pb.CreditRequest) (*pb.Credit, error) {
+	movieid, err := parseID(req.GetMovieId())
+	if err != nil {
+		return nil, err
+	}
+	actorid, err := parseID(req.GetActorId())
+	if err != nil {
+		return nil, err
+	}
+	actor, movie, _, err := s.actorMovieUseCase.AddActorToMovie(ctx, actorid, movieid)
+	if err != nil {
+		return nil, toStatus(ctx, err, "error adding actor to movie")
+	}
+	return &pb.Credit{Movie: toPBMovie(movie), Actor: toPBActor(actor)}, nil
+}
+
+func (s *creditService) RemoveCredit(ctx context.Context, req *pb.CreditRequest) (*pb.Credit, error) {
+	movieid, err := parseID(req.GetMovieId())
+	if err != nil {
+		return nil, err
+	}
+	actorid, err := parseID(req.GetActorId())
+	if err != nil {
+		return nil, err
+	}
+	actor, movie, err := s.actorMovieUseCase.DeleteActorFromMovie(ctx, actorid, movieid)
+	if err != nil {
+		return nil, toStatus(ctx, err, "error deleting actor from movie")
+	}
+	return &pb.Credit{Movie: toPBMovie(movie), Actor: toPBActor(actor)}, nil
+}
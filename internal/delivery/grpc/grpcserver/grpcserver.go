@@ -0,0 +1,69 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"filmoteka/internal/delivery/grpc/pb"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/domain/usecase"
+	"filmoteka/internal/ratelimit"
+	"github.com/alexedwards/scs/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log/slog"
+)
+
+type movieUseCase interface {
+	GetAllMovies(ctx context.Context, param string) ([]*models.Movie, error)
+	CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	GetMovieByID(ctx context.Context, id int) (*models.Movie, error)
+	UpdateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	DeleteMovie(ctx context.Context, id int) error
+	GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error)
+}
+
+type actorUseCase interface {
+	GetAllActors(ctx context.Context) ([]*models.Actor, error)
+	CreateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	GetActorByID(ctx context.Context, id int) (*models.Actor, error)
+	UpdateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	DeleteActor(ctx context.Context, id int) error
+}
+
+type actorMovieUseCase interface {
+	GetActorsForMovie(ctx context.Context, movieid int) ([]*models.Actor, *models.Movie, error)
+	GetMoviesForActor(ctx context.Context, actorid int) ([]*models.Movie, *models.Actor, error)
+	GetMovieByActorName(ctx context.Context, firstname string, lastname string) ([]*models.MovieWithActor, error)
+	AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error)
+	DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error)
+}
+
+type userUseCase interface {
+	Login(ctx context.Context, email string, password string, ip string) (*models.User, error)
+}
+
+// New returns a gRPC server exposing the catalog through the same usecases,
+// session store and login budget as the HTTP API.
+func New(uc *usecase.UseCase, manager *scs.SessionManager, limits ratelimit.Store, login ratelimit.Policy) *grpc.Server {
+	a := &auth{sessionManager: manager}
+	l := &loginLimiter{store: limits, policy: login}
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(tracingUnaryInterceptor, requestIDUnaryInterceptor, l.unaryInterceptor, a.unaryInterceptor),
+		grpc.ChainStreamInterceptor(tracingStreamInterceptor, requestIDStreamInterceptor, a.streamInterceptor),
+	)
+	pb.RegisterAuthServiceServer(srv, &authService{userUseCase: uc.UserUseCase, sessionManager: manager})
+	pb.RegisterMovieServiceServer(srv, &movieService{movieUseCase: uc.MovieUseCase})
+	pb.RegisterActorServiceServer(srv, &actorService{actorUseCase: uc.ActorUseCase})
+	pb.RegisterCreditServiceServer(srv, &creditService{actorMovieUseCase: uc.ActorMovieUseCase})
+	return srv
+}
+
+// toStatus maps usecase errors to gRPC status errors, hiding internal details.
+func toStatus(ctx context.Context, err error, msg string) error {
+	if errors.Is(err, models.ErrNoRecord) {
+		return status.Error(codes.NotFound, models.ErrNoRecord.Error())
+	}
+	slog.ErrorContext(ctx, msg, "err", err)
+	return status.Error(codes.Internal, msg)
+}
@@ -0,0 +1,95 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"filmoteka/internal/delivery/grpc/pb"
+	"filmoteka/internal/domain/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type movieService struct {
+	pb.UnimplementedMovieServiceServer
+	movieUseCase movieUseCase
+}
+
+func (s *movieService) ListMovies(req *pb.ListMoviesRequest, stream grpc.ServerStreamingServer[pb.Movie]) error {
+	switch req.GetSort() {
+	case "", "rating", "date", "title":
+	default:
+		return status.Error(codes.InvalidArgument, "invalid sort parameter")
+	}
+
+	var movies []*models.Movie
+	var err error
+	if req.GetSearch() != "" {
+		movies, err = s.movieUseCase.GetMovieByMovieName(stream.Context(), req.GetSearch())
+	} else {
+		movies, err = s.movieUseCase.GetAllMovies(stream.Context(), req.GetSort())
+	}
+	if errors.Is(err, models.ErrNoRecord) {
+		return nil
+	} else if err != nil {
+		return toStatus(stream.Context(), err, "error getting movies")
+	}
+
+	for _, m := range movies {
+		if err := stream.Send(toPBMovie(m)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *movieService) GetMovie(ctx context.Context, req *pb.GetMovieRequest) (*pb.Movie, error) {
+	id, err := parseID(req.GetMovieId())
+	if err != nil {
+		return nil, err
+	}
+	movie, err := s.movieUseCase.GetMovieByID(ctx, id)
+	if err != nil {
+		return nil, toStatus(ctx, err, "error getting movie")
+	}
+	return toPBMovie(movie), nil
+}
+
+func (s *movieService) CreateMovie(ctx context.Context, req *pb.CreateMovieRequest) (*pb.Movie, error) {
+	movie, err := fromPBMovie(req.GetMovie())
+	if err != nil {
+		return nil, err
+	}
+	movie, err = s.movieUseCase.CreateMovie(ctx, movie)
+	if err != nil {
+		return nil, toStatus(ctx, err, "error creating movie")
+	}
+	return toPBMovie(movie), nil
+}
+
+func (s *movieService) UpdateMovie(ctx context.Context, req *pb.UpdateMovieRequest) (*pb.Movie, error) {
+	movie, err := fromPBMovie(req.GetMovie())
+	if err != nil {
+		return nil, err
+	}
+	if _, err = parseID(int64(movie.MovieID)); err != nil {
+		return nil, err
+	}
+	movie, err = s.movieUseCase.UpdateMovie(ctx, movie)
+	if err != nil {
+		return nil, toStatus(ctx, err, "error updating movie")
+	}
+	return toPBMovie(movie), nil
+}
+
+func (s *movieService) DeleteMovie(ctx context.Context, req *pb.DeleteMovieRequest) (*pb.DeleteMovieResponse, error) {
+	id, err := parseID(req.GetMovieId())
+	if err != nil {
+		return nil, err
+	}
+	err = s.movieUseCase.DeleteMovie(ctx, id)
+	if err != nil {
+		return nil, toStatus(ctx, err, "error deleting movie")
+	}
+	return &pb.DeleteMovieResponse{}, nil
+}
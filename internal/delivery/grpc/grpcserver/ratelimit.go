@@ -0,0 +1,47 @@
+package grpcserver
+
+import (
+	"context"
+	"filmoteka/internal/delivery/grpc/pb"
+	"filmoteka/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"net"
+	"time"
+)
+
+// loginLimiter takes the login policy of the HTTP API on Login, from the
+// same buckets, so that switching transports does not reset the budget of
+// a client address. If the store fails the call is let through.
+type loginLimiter struct {
+	store  ratelimit.Store
+	policy ratelimit.Policy
+}
+
+func (l *loginLimiter) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if info.FullMethod != pb.AuthService_Login_FullMethodName {
+		return handler(ctx, req)
+	}
+
+	res, err := l.store.Take(ctx, l.policy.Name+":ip:"+peerIP(ctx), l.policy)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error checking rate limit", "err", err)
+		return handler(ctx, req)
+	}
+	if !res.Allowed {
+		return nil, status.Errorf(codes.ResourceExhausted, "too many requests, retry in %s", res.RetryAfter.Round(time.Second))
+	}
+	return handler(ctx, req)
+}
+
+// peerIP returns the address of the client of the call.
+func peerIP(ctx context.Context) string {
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		ip, _, _ = net.SplitHostPort(p.Addr.String())
+	}
+	return ip
+}
@@ -0,0 +1,43 @@
+package grpcserver
+
+import (
+	"context"
+	"filmoteka/internal/delivery/grpc/pb"
+	"filmoteka/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"net"
+	"testing"
+)
+
+func TestLoginLimited(t *testing.T) {
+	l := &loginLimiter{
+		store:  ratelimit.NewMemoryStore(),
+		policy: ratelimit.Policy{Name: "login", Rate: 1.0 / 60, Burst: 2, PerIP: true},
+	}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	}
+	call := func(ip string, method string) error {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 4000}})
+		_, err := l.unaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+
+	for i := range 2 {
+		if err := call("192.0.2.1", pb.AuthService_Login_FullMethodName); err != nil {
+			t.Fatalf("login %d: %v", i+1, err)
+		}
+	}
+	if err := call("192.0.2.1", pb.AuthService_Login_FullMethodName); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("login over budget: got %v, want ResourceExhausted", err)
+	}
+	if err := call("192.0.2.2", pb.AuthService_Login_FullMethodName); err != nil {
+		t.Errorf("login from another address: %v", err)
+	}
+	if err := call("192.0.2.1", pb.MovieService_ListMovies_FullMethodName); err != nil {
+		t.Errorf("other method: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package grpcserver
+
+import (
+	"context"
+	"filmoteka/internal/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"time"
+)
+
+// requestIDKey is the metadata key carrying the request ID, the gRPC
+// counterpart of the X-Request-ID header.
+const requestIDKey = "x-request-id"
+
+// withRequestID tags ctx with the request ID sent by the client or a new one
+// and returns it to the client in the response header.
+func withRequestID(ctx context.Context) context.Context {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDKey); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if !logging.ValidRequestID(id) {
+		id = logging.NewRequestID()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	return logging.WithRequestID(ctx, id)
+}
+
+func logCall(ctx context.Context, method string, start time.Time, err error) {
+	slog.InfoContext(ctx, "Call served",
+		"method", method,
+		"code", status.Code(err).String(),
+		"duration", time.Since(start),
+	)
+}
+
+func requestIDUnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx = withRequestID(ctx)
+	res, err := handler(ctx, req)
+	logCall(ctx, info.FullMethod, start, err)
+	return res, err
+}
+
+func requestIDStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx := withRequestID(ss.Context())
+	err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	logCall(ctx, info.FullMethod, start, err)
+	return err
+}
@@ -0,0 +1,901 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.3
+// source: filmoteka.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AuthService_Login_FullMethodName = "/filmoteka.v1.AuthService/Login"
+)
+
+// AuthServiceClient is the client API for AuthService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AuthServiceClient interface {
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error)
+}
+
+type authServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAuthServiceClient(cc grpc.ClientConnInterface) AuthServiceClient {
+	return &authServiceClient{cc}
+}
+
+func (c *authServiceClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*LoginResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginResponse)
+	err := c.cc.Invoke(ctx, AuthService_Login_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AuthServiceServer is the server API for AuthService service.
+// All implementations must embed UnimplementedAuthServiceServer
+// for forward compatibility.
+type AuthServiceServer interface {
+	Login(context.Context, *LoginRequest) (*LoginResponse, error)
+	mustEmbedUnimplementedAuthServiceServer()
+}
+
+// UnimplementedAuthServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAuthServiceServer struct{}
+
+func (UnimplementedAuthServiceServer) Login(context.Context, *LoginRequest) (*LoginResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedAuthServiceServer) mustEmbedUnimplementedAuthServiceServer() {}
+func (UnimplementedAuthServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeAuthServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AuthServiceServer will
+// result in compilation errors.
+type UnsafeAuthServiceServer interface {
+	mustEmbedUnimplementedAuthServiceServer()
+}
+
+func RegisterAuthServiceServer(s grpc.ServiceRegistrar, srv AuthServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAuthServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AuthService_ServiceDesc, srv)
+}
+
+func _AuthService_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthServiceServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AuthService_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthServiceServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AuthService_ServiceDesc is the grpc.ServiceDesc for AuthService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AuthService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "filmoteka.v1.AuthService",
+	HandlerType: (*AuthServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Login",
+			Handler:    _AuthService_Login_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "filmoteka.proto",
+}
+
+const (
+	MovieService_ListMovies_FullMethodName  = "/filmoteka.v1.MovieService/ListMovies"
+	MovieService_GetMovie_FullMethodName    = "/filmoteka.v1.MovieService/GetMovie"
+	MovieService_CreateMovie_FullMethodName = "/filmoteka.v1.MovieService/CreateMovie"
+	MovieService_UpdateMovie_FullMethodName = "/filmoteka.v1.MovieService/UpdateMovie"
+	MovieService_DeleteMovie_FullMethodName = "/filmoteka.v1.MovieService/DeleteMovie"
+)
+
+// MovieServiceClient is the client API for MovieService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MovieServiceClient interface {
+	ListMovies(ctx context.Context, in *ListMoviesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Movie], error)
+	GetMovie(ctx context.Context, in *GetMovieRequest, opts ...grpc.CallOption) (*Movie, error)
+	CreateMovie(ctx context.Context, in *CreateMovieRequest, opts ...grpc.CallOption) (*Movie, error)
+	UpdateMovie(ctx context.Context, in *UpdateMovieRequest, opts ...grpc.CallOption) (*Movie, error)
+	DeleteMovie(ctx context.Context, in *DeleteMovieRequest, opts ...grpc.CallOption) (*DeleteMovieResponse, error)
+}
+
+type movieServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMovieServiceClient(cc grpc.ClientConnInterface) MovieServiceClient {
+	return &movieServiceClient{cc}
+}
+
+func (c *movieServiceClient) ListMovies(ctx context.Context, in *ListMoviesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Movie], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MovieService_ServiceDesc.Streams[0], MovieService_ListMovies_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListMoviesRequest, Movie]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MovieService_ListMoviesClient = grpc.ServerStreamingClient[Movie]
+
+func (c *movieServiceClient) GetMovie(ctx context.Context, in *GetMovieRequest, opts ...grpc.CallOption) (*Movie, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Movie)
+	err := c.cc.Invoke(ctx, MovieService_GetMovie_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *movieServiceClient) CreateMovie(ctx context.Context, in *CreateMovieRequest, opts ...grpc.CallOption) (*Movie, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Movie)
+	err := c.cc.Invoke(ctx, MovieService_CreateMovie_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *movieServiceClient) UpdateMovie(ctx context.Context, in *UpdateMovieRequest, opts ...grpc.CallOption) (*Movie, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Movie)
+	err := c.cc.Invoke(ctx, MovieService_UpdateMovie_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *movieServiceClient) DeleteMovie(ctx context.Context, in *DeleteMovieRequest, opts ...grpc.CallOption) (*DeleteMovieResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteMovieResponse)
+	err := c.cc.Invoke(ctx, MovieService_DeleteMovie_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MovieServiceServer is the server API for MovieService service.
+// All implementations must embed UnimplementedMovieServiceServer
+// for forward compatibility.
+type MovieServiceServer interface {
+	ListMovies(*ListMoviesRequest, grpc.ServerStreamingServer[Movie]) error
+	GetMovie(context.Context, *GetMovieRequest) (*Movie, error)
+	CreateMovie(context.Context, *CreateMovieRequest) (*Movie, error)
+	UpdateMovie(context.Context, *UpdateMovieRequest) (*Movie, error)
+	DeleteMovie(context.Context, *DeleteMovieRequest) (*DeleteMovieResponse, error)
+	mustEmbedUnimplementedMovieServiceServer()
+}
+
+// UnimplementedMovieServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMovieServiceServer struct{}
+
+func (UnimplementedMovieServiceServer) ListMovies(*ListMoviesRequest, grpc.ServerStreamingServer[Movie]) error {
+	return status.Errorf(codes.Unimplemented, "method ListMovies not implemented")
+}
+func (UnimplementedMovieServiceServer) GetMovie(context.Context, *GetMovieRequest) (*Movie, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMovie not implemented")
+}
+func (UnimplementedMovieServiceServer) CreateMovie(context.Context, *CreateMovieRequest) (*Movie, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateMovie not implemented")
+}
+func (UnimplementedMovieServiceServer) UpdateMovie(context.Context, *UpdateMovieRequest) (*Movie, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateMovie not implemented")
+}
+func (UnimplementedMovieServiceServer) DeleteMovie(context.Context, *DeleteMovieRequest) (*DeleteMovieResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMovie not implemented")
+}
+func (UnimplementedMovieServiceServer) mustEmbedUnimplementedMovieServiceServer() {}
+func (UnimplementedMovieServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeMovieServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MovieServiceServer will
+// result in compilation errors.
+type UnsafeMovieServiceServer interface {
+	mustEmbedUnimplementedMovieServiceServer()
+}
+
+func RegisterMovieServiceServer(s grpc.ServiceRegistrar, srv MovieServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMovieServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MovieService_ServiceDesc, srv)
+}
+
+func _MovieService_ListMovies_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListMoviesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MovieServiceServer).ListMovies(m, &grpc.GenericServerStream[ListMoviesRequest, Movie]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MovieService_ListMoviesServer = grpc.ServerStreamingServer[Movie]
+
+func _MovieService_GetMovie_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMovieRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MovieServiceServer).GetMovie(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MovieService_GetMovie_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MovieServiceServer).GetMovie(ctx, req.(*GetMovieRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MovieService_CreateMovie_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateMovieRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MovieServiceServer).CreateMovie(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MovieService_CreateMovie_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MovieServiceServer).CreateMovie(ctx, req.(*CreateMovieRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MovieService_UpdateMovie_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateMovieRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MovieServiceServer).UpdateMovie(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MovieService_UpdateMovie_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MovieServiceServer).UpdateMovie(ctx, req.(*UpdateMovieRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MovieService_DeleteMovie_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteMovieRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MovieServiceServer).DeleteMovie(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MovieService_DeleteMovie_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MovieServiceServer).DeleteMovie(ctx, req.(*DeleteMovieRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MovieService_ServiceDesc is the grpc.ServiceDesc for MovieService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MovieService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "filmoteka.v1.MovieService",
+	HandlerType: (*MovieServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetMovie",
+			Handler:    _MovieService_GetMovie_Handler,
+		},
+		{
+			MethodName: "CreateMovie",
+			Handler:    _MovieService_CreateMovie_Handler,
+		},
+		{
+			MethodName: "UpdateMovie",
+			Handler:    _MovieService_UpdateMovie_Handler,
+		},
+		{
+			MethodName: "DeleteMovie",
+			Handler:    _MovieService_DeleteMovie_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListMovies",
+			Handler:       _MovieService_ListMovies_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "filmoteka.proto",
+}
+
+const (
+	ActorService_ListActors_FullMethodName  = "/filmoteka.v1.ActorService/ListActors"
+	ActorService_GetActor_FullMethodName    = "/filmoteka.v1.ActorService/GetActor"
+	ActorService_CreateActor_FullMethodName = "/filmoteka.v1.ActorService/CreateActor"
+	ActorService_UpdateActor_FullMethodName = "/filmoteka.v1.ActorService/UpdateActor"
+	ActorService_DeleteActor_FullMethodName = "/filmoteka.v1.ActorService/DeleteActor"
+)
+
+// ActorServiceClient is the client API for ActorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ActorServiceClient interface {
+	ListActors(ctx context.Context, in *ListActorsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Actor], error)
+	GetActor(ctx context.Context, in *GetActorRequest, opts ...grpc.CallOption) (*Actor, error)
+	CreateActor(ctx context.Context, in *CreateActorRequest, opts ...grpc.CallOption) (*Actor, error)
+	UpdateActor(ctx context.Context, in *UpdateActorRequest, opts ...grpc.CallOption) (*Actor, error)
+	DeleteActor(ctx context.Context, in *DeleteActorRequest, opts ...grpc.CallOption) (*DeleteActorResponse, error)
+}
+
+type actorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewActorServiceClient(cc grpc.ClientConnInterface) ActorServiceClient {
+	return &actorServiceClient{cc}
+}
+
+func (c *actorServiceClient) ListActors(ctx context.Context, in *ListActorsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Actor], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ActorService_ServiceDesc.Streams[0], ActorService_ListActors_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListActorsRequest, Actor]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ActorService_ListActorsClient = grpc.ServerStreamingClient[Actor]
+
+func (c *actorServiceClient) GetActor(ctx context.Context, in *GetActorRequest, opts ...grpc.CallOption) (*Actor, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Actor)
+	err := c.cc.Invoke(ctx, ActorService_GetActor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *actorServiceClient) CreateActor(ctx context.Context, in *CreateActorRequest, opts ...grpc.CallOption) (*Actor, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Actor)
+	err := c.cc.Invoke(ctx, ActorService_CreateActor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *actorServiceClient) UpdateActor(ctx context.Context, in *UpdateActorRequest, opts ...grpc.CallOption) (*Actor, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Actor)
+	err := c.cc.Invoke(ctx, ActorService_UpdateActor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *actorServiceClient) DeleteActor(ctx context.Context, in *DeleteActorRequest, opts ...grpc.CallOption) (*DeleteActorResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteActorResponse)
+	err := c.cc.Invoke(ctx, ActorService_DeleteActor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ActorServiceServer is the server API for ActorService service.
+// All implementations must embed UnimplementedActorServiceServer
+// for forward compatibility.
+type ActorServiceServer interface {
+	ListActors(*ListActorsRequest, grpc.ServerStreamingServer[Actor]) error
+	GetActor(context.Context, *GetActorRequest) (*Actor, error)
+	CreateActor(context.Context, *CreateActorRequest) (*Actor, error)
+	UpdateActor(context.Context, *UpdateActorRequest) (*Actor, error)
+	DeleteActor(context.Context, *DeleteActorRequest) (*DeleteActorResponse, error)
+	mustEmbedUnimplementedActorServiceServer()
+}
+
+// UnimplementedActorServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedActorServiceServer struct{}
+
+func (UnimplementedActorServiceServer) ListActors(*ListActorsRequest, grpc.ServerStreamingServer[Actor]) error {
+	return status.Errorf(codes.Unimplemented, "method ListActors not implemented")
+}
+func (UnimplementedActorServiceServer) GetActor(context.Context, *GetActorRequest) (*Actor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetActor not implemented")
+}
+func (UnimplementedActorServiceServer) CreateActor(context.Context, *CreateActorRequest) (*Actor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateActor not implemented")
+}
+func (UnimplementedActorServiceServer) UpdateActor(context.Context, *UpdateActorRequest) (*Actor, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateActor not implemented")
+}
+func (UnimplementedActorServiceServer) DeleteActor(context.Context, *DeleteActorRequest) (*DeleteActorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteActor not implemented")
+}
+func (UnimplementedActorServiceServer) mustEmbedUnimplementedActorServiceServer() {}
+func (UnimplementedActorServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeActorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ActorServiceServer will
+// result in compilation errors.
+type UnsafeActorServiceServer interface {
+	mustEmbedUnimplementedActorServiceServer()
+}
+
+func RegisterActorServiceServer(s grpc.ServiceRegistrar, srv ActorServiceServer) {
+	// If the following call pancis, it indicates UnimplementedActorServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ActorService_ServiceDesc, srv)
+}
+
+func _ActorService_ListActors_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListActorsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ActorServiceServer).ListActors(m, &grpc.GenericServerStream[ListActorsRequest, Actor]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ActorService_ListActorsServer = grpc.ServerStreamingServer[Actor]
+
+func _ActorService_GetActor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetActorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ActorServiceServer).GetActor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ActorService_GetActor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ActorServiceServer).GetActor(ctx, req.(*GetActorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ActorService_CreateActor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateActorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ActorServiceServer).CreateActor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ActorService_CreateActor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ActorServiceServer).CreateActor(ctx, req.(*CreateActorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ActorService_UpdateActor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateActorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ActorServiceServer).UpdateActor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ActorService_UpdateActor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ActorServiceServer).UpdateActor(ctx, req.(*UpdateActorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ActorService_DeleteActor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteActorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ActorServiceServer).DeleteActor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ActorService_DeleteActor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ActorServiceServer).DeleteActor(ctx, req.(*DeleteActorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ActorService_ServiceDesc is the grpc.ServiceDesc for ActorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ActorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "filmoteka.v1.ActorService",
+	HandlerType: (*ActorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetActor",
+			Handler:    _ActorService_GetActor_Handler,
+		},
+		{
+			MethodName: "CreateActor",
+			Handler:    _ActorService_CreateActor_Handler,
+		},
+		{
+			MethodName: "UpdateActor",
+			Handler:    _ActorService_UpdateActor_Handler,
+		},
+		{
+			MethodName: "DeleteActor",
+			Handler:    _ActorService_DeleteActor_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListActors",
+			Handler:       _ActorService_ListActors_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "filmoteka.proto",
+}
+
+const (
+	CreditService_ListActorsForMovie_FullMethodName    = "/filmoteka.v1.CreditService/ListActorsForMovie"
+	CreditService_ListMoviesForActor_FullMethodName    = "/filmoteka.v1.CreditService/ListMoviesForActor"
+	CreditService_ListMoviesByActorName_FullMethodName = "/filmoteka.v1.CreditService/ListMoviesByActorName"
+	CreditService_AddCredit_FullMethodName             = "/filmoteka.v1.CreditService/AddCredit"
+	CreditService_RemoveCredit_FullMethodName          = "/filmoteka.v1.CreditService/RemoveCredit"
+)
+
+// CreditServiceClient is the client API for CreditService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CreditServiceClient interface {
+	ListActorsForMovie(ctx context.Context, in *ListActorsForMovieRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Actor], error)
+	ListMoviesForActor(ctx context.Context, in *ListMoviesForActorRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Movie], error)
+	ListMoviesByActorName(ctx context.Context, in *ListMoviesByActorNameRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MovieWithActor], error)
+	AddCredit(ctx context.Context, in *CreditRequest, opts ...grpc.CallOption) (*Credit, error)
+	RemoveCredit(ctx context.Context, in *CreditRequest, opts ...grpc.CallOption) (*Credit, error)
+}
+
+type creditServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCreditServiceClient(cc grpc.ClientConnInterface) CreditServiceClient {
+	return &creditServiceClient{cc}
+}
+
+func (c *creditServiceClient) ListActorsForMovie(ctx context.Context, in *ListActorsForMovieRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Actor], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CreditService_ServiceDesc.Streams[0], CreditService_ListActorsForMovie_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListActorsForMovieRequest, Actor]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CreditService_ListActorsForMovieClient = grpc.ServerStreamingClient[Actor]
+
+func (c *creditServiceClient) ListMoviesForActor(ctx context.Context, in *ListMoviesForActorRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Movie], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CreditService_ServiceDesc.Streams[1], CreditService_ListMoviesForActor_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListMoviesForActorRequest, Movie]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CreditService_ListMoviesForActorClient = grpc.ServerStreamingClient[Movie]
+
+func (c *creditServiceClient) ListMoviesByActorName(ctx context.Context, in *ListMoviesByActorNameRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MovieWithActor], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CreditService_ServiceDesc.Streams[2], CreditService_ListMoviesByActorName_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ListMoviesByActorNameRequest, MovieWithActor]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CreditService_ListMoviesByActorNameClient = grpc.ServerStreamingClient[MovieWithActor]
+
+func (c *creditServiceClient) AddCredit(ctx context.Context, in *CreditRequest, opts ...grpc.CallOption) (*Credit, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Credit)
+	err := c.cc.Invoke(ctx, CreditService_AddCredit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *creditServiceClient) RemoveCredit(ctx context.Context, in *CreditRequest, opts ...grpc.CallOption) (*Credit, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Credit)
+	err := c.cc.Invoke(ctx, CreditService_RemoveCredit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreditServiceServer is the server API for CreditService service.
+// All implementations must embed UnimplementedCreditServiceServer
+// for forward compatibility.
+type CreditServiceServer interface {
+	ListActorsForMovie(*ListActorsForMovieRequest, grpc.ServerStreamingServer[Actor]) error
+	ListMoviesForActor(*ListMoviesForActorRequest, grpc.ServerStreamingServer[Movie]) error
+	ListMoviesByActorName(*ListMoviesByActorNameRequest, grpc.ServerStreamingServer[MovieWithActor]) error
+	AddCredit(context.Context, *CreditRequest) (*Credit, error)
+	RemoveCredit(context.Context, *CreditRequest) (*Credit, error)
+	mustEmbedUnimplementedCreditServiceServer()
+}
+
+// UnimplementedCreditServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCreditServiceServer struct{}
+
+func (UnimplementedCreditServiceServer) ListActorsForMovie(*ListActorsForMovieRequest, grpc.ServerStreamingServer[Actor]) error {
+	return status.Errorf(codes.Unimplemented, "method ListActorsForMovie not implemented")
+}
+func (UnimplementedCreditServiceServer) ListMoviesForActor(*ListMoviesForActorRequest, grpc.ServerStreamingServer[Movie]) error {
+	return status.Errorf(codes.Unimplemented, "method ListMoviesForActor not implemented")
+}
+func (UnimplementedCreditServiceServer) ListMoviesByActorName(*ListMoviesByActorNameRequest, grpc.ServerStreamingServer[MovieWithActor]) error {
+	return status.Errorf(codes.Unimplemented, "method ListMoviesByActorName not implemented")
+}
+func (UnimplementedCreditServiceServer) AddCredit(context.Context, *CreditRequest) (*Credit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCredit not implemented")
+}
+func (UnimplementedCreditServiceServer) RemoveCredit(context.Context, *CreditRequest) (*Credit, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveCredit not implemented")
+}
+func (UnimplementedCreditServiceServer) mustEmbedUnimplementedCreditServiceServer() {}
+func (UnimplementedCreditServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeCreditServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CreditServiceServer will
+// result in compilation errors.
+type UnsafeCreditServiceServer interface {
+	mustEmbedUnimplementedCreditServiceServer()
+}
+
+func RegisterCreditServiceServer(s grpc.ServiceRegistrar, srv CreditServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCreditServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CreditService_ServiceDesc, srv)
+}
+
+func _CreditService_ListActorsForMovie_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListActorsForMovieRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CreditServiceServer).ListActorsForMovie(m, &grpc.GenericServerStream[ListActorsForMovieRequest, Actor]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CreditService_ListActorsForMovieServer = grpc.ServerStreamingServer[Actor]
+
+func _CreditService_ListMoviesForActor_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListMoviesForActorRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CreditServiceServer).ListMoviesForActor(m, &grpc.GenericServerStream[ListMoviesForActorRequest, Movie]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CreditService_ListMoviesForActorServer = grpc.ServerStreamingServer[Movie]
+
+func _CreditService_ListMoviesByActorName_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListMoviesByActorNameRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CreditServiceServer).ListMoviesByActorName(m, &grpc.GenericServerStream[ListMoviesByActorNameRequest, MovieWithActor]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CreditService_ListMoviesByActorNameServer = grpc.ServerStreamingServer[MovieWithActor]
+
+func _CreditService_AddCredit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).AddCredit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_AddCredit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).AddCredit(ctx, req.(*CreditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CreditService_RemoveCredit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CreditServiceServer).RemoveCredit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CreditService_RemoveCredit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CreditServiceServer).RemoveCredit(ctx, req.(*CreditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CreditService_ServiceDesc is the grpc.ServiceDesc for CreditService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CreditService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "filmoteka.v1.CreditService",
+	HandlerType: (*CreditServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddCredit",
+			Handler:    _CreditService_AddCredit_Handler,
+		},
+		{
+			MethodName: "RemoveCredit",
+			Handler:    _CreditService_RemoveCredit_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListActorsForMovie",
+			Handler:       _CreditService_ListActorsForMovie_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListMoviesForActor",
+			Handler:       _CreditService_ListMoviesForActor_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListMoviesByActorName",
+			Handler:       _CreditService_ListMoviesByActorName_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "filmoteka.proto",
+}
@@ -0,0 +1,5 @@
+// Package pb holds the protobuf definitions of the gRPC API and the code
+// generated from them.
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative filmoteka.proto
@@ -1,13 +1,16 @@
 package actorhandlers
 
 import (
+	"context"
 	"errors"
 	"filmoteka/internal/domain/models"
 	"filmoteka/internal/utils"
+	"fmt"
 	"github.com/alexedwards/scs/v2"
-	"log"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type ActorHandler struct {
@@ -23,11 +26,12 @@ func New(useCase actorUseCase, manager *scs.SessionManager) *ActorHandler {
 }
 
 type actorUseCase interface {
-	GetAllActors() ([]*models.Actor, error)
-	CreateActor(a *models.Actor) (*models.Actor, error)
-	GetActorByID(id int) (*models.Actor, error)
-	UpdateActor(a *models.Actor) (*models.Actor, error)
-	DeleteActor(id int) error
+	GetAllActors(ctx context.Context) ([]*models.Actor, error)
+	CreateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	GetActorByID(ctx context.Context, id int) (*models.Actor, error)
+	UpdateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	DeleteActor(ctx context.Context, id int) error
+	GetActorsModifiedAt(ctx context.Context) (time.Time, error)
 }
 
 func (h *ActorHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -60,7 +64,7 @@ func (h *ActorHandler) getActorsById(w http.ResponseWriter, r *http.Request) {
 
 	for param := range r.URL.Query() {
 		if !expectedParams[param] {
-			log.Println("Invalid request parameter: ", param)
+			slog.InfoContext(r.Context(), "Invalid request parameter", "param", param)
 			utils.ErrorJSON(w, errors.New("invalid request parameter "), http.StatusBadRequest)
 			return
 		}
@@ -70,16 +74,17 @@ func (h *ActorHandler) getActorsById(w http.ResponseWriter, r *http.Request) {
 	//utils.ReadJSON(r, w, &actor)
 	actorID, err := strconv.Atoi(r.URL.Query().Get("id"))
 	if err != nil {
-		log.Println("Error getting actor, invalid value", err)
+		slog.ErrorContext(r.Context(), "Error getting actor, invalid value", "err", err)
 		utils.ErrorJSON(w, errors.New("invalid request"), http.StatusBadRequest)
 		return
 	}
-	actor, err := h.actorUseCase.GetActorByID(actorID)
+	actor, err := h.actorUseCase.GetActorByID(r.Context(), actorID)
 	if err != nil {
-		log.Println("Error getting actor", err)
+		slog.ErrorContext(r.Context(), "Error getting actor", "err", err)
 		utils.ErrorJSON(w, err, http.StatusInternalServerError)
 		return
 	}
+	utils.SetLastModified(w, actor.UpdatedAt)
 	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Actor retrieved", Data: actor})
 }
 
@@ -88,34 +93,39 @@ func (h *ActorHandler) createActor(w http.ResponseWriter, r *http.Request) {
 	var actor *models.Actor
 
 	err := utils.ReadJSON(r, w, &actor)
-	log.Println("Actor: ", actor)
 	if err != nil {
-		log.Println("Error reading request", err)
+		slog.ErrorContext(r.Context(), "Error reading request", "err", err)
 		utils.ErrorJSON(w, errors.New("error reading request"), http.StatusBadRequest)
 		return
 	}
 
-	_, err = h.actorUseCase.CreateActor(actor)
+	_, err = h.actorUseCase.CreateActor(r.Context(), actor)
 	if err != nil {
-		log.Println("Error creating actor", err)
+		slog.ErrorContext(r.Context(), "Error creating actor", "err", err)
 		utils.ErrorJSON(w, errors.New("error creating actor"), http.StatusInternalServerError)
 		return
 	}
-	utils.WriteJSON(w, http.StatusCreated, utils.JsonResponse{Error: false, Message: "Actor created", Data: actor})
+	headers := http.Header{"Location": {fmt.Sprintf("/actors/%d", actor.ActorID)}}
+	utils.WriteJSON(w, http.StatusCreated, utils.JsonResponse{Error: false, Message: "Actor created", Data: actor}, headers)
 }
 
 func (h *ActorHandler) getAllActors(w http.ResponseWriter, r *http.Request) {
 	// Handle get request ==> return all actors
-	actors, err := h.actorUseCase.GetAllActors()
+	actors, err := h.actorUseCase.GetAllActors(r.Context())
 	if errors.Is(err, models.ErrNoRecord) {
 		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
 		return
 	} else if err != nil {
-		log.Println("Error getting actors", err)
+		slog.ErrorContext(r.Context(), "Error getting actors", "err", err)
 		utils.ErrorJSON(w, errors.New("Error getting actors"), http.StatusInternalServerError)
 		return
 	}
 
+	modified, err := h.actorUseCase.GetActorsModifiedAt(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting last change of actors", "err", err)
+	}
+	utils.SetLastModified(w, modified)
 	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Actors retrieved", Data: actors})
 }
 
@@ -123,13 +133,13 @@ func (h *ActorHandler) updateActor(w http.ResponseWriter, r *http.Request) {
 	actor := &models.Actor{}
 	err := utils.ReadJSON(r, w, &actor)
 	if err != nil {
-		log.Println("Error reading request", err)
+		slog.ErrorContext(r.Context(), "Error reading request", "err", err)
 		utils.ErrorJSON(w, errors.New("error reading request"), http.StatusBadRequest)
 		return
 	}
-	res, err := h.actorUseCase.UpdateActor(actor)
+	res, err := h.actorUseCase.UpdateActor(r.Context(), actor)
 	if err != nil {
-		log.Println("Error updating actor", err)
+		slog.ErrorContext(r.Context(), "Error updating actor", "err", err)
 		utils.ErrorJSON(w, errors.New("error updating actor"), http.StatusInternalServerError)
 		return
 	}
@@ -143,7 +153,7 @@ func (h *ActorHandler) deleteActor(w http.ResponseWriter, r *http.Request) {
 
 	for param := range r.URL.Query() {
 		if !expectedParams[param] {
-			log.Println("Invalid request parameter: ", param)
+			slog.InfoContext(r.Context(), "Invalid request parameter", "param", param)
 			utils.ErrorJSON(w, errors.New("invalid request parameter. "), http.StatusBadRequest)
 			return
 		}
@@ -152,16 +162,92 @@ func (h *ActorHandler) deleteActor(w http.ResponseWriter, r *http.Request) {
 	//actor := &models.Actor{}
 	actorID, err := strconv.Atoi(r.URL.Query().Get("id"))
 	if err != nil {
-		log.Println("Error deleting actor, invalid value", err)
+		slog.ErrorContext(r.Context(), "Error deleting actor, invalid value", "err", err)
 		utils.ErrorJSON(w, errors.New("invalid request"), http.StatusBadRequest)
 		return
 	}
-	err = h.actorUseCase.DeleteActor(actorID)
+	err = h.actorUseCase.DeleteActor(r.Context(), actorID)
 
-	if err != nil {
-		log.Println("Error deleting actor", err)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error deleting actor", "err", err)
 		utils.ErrorJSON(w, errors.New("error deleting actor"), http.StatusInternalServerError)
 		return
 	}
 	utils.WriteJSON(w, http.StatusCreated, utils.JsonResponse{Error: false, Message: "Actor successfully deleted"})
 }
+
+// Handlers for the /actors resource routes
+
+func (h *ActorHandler) ListActors(w http.ResponseWriter, r *http.Request) {
+	h.getAllActors(w, r)
+}
+
+func (h *ActorHandler) CreateActor(w http.ResponseWriter, r *http.Request) {
+	h.createActor(w, r)
+}
+
+func (h *ActorHandler) GetActor(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	actor, err := h.actorUseCase.GetActorByID(r.Context(), id)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting actor", "err", err)
+		utils.ErrorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+	utils.SetLastModified(w, actor.UpdatedAt)
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Actor retrieved", Data: actor})
+}
+
+func (h *ActorHandler) UpdateActor(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	actor := &models.Actor{}
+	err = utils.ReadJSON(r, w, &actor)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading request", "err", err)
+		utils.ErrorJSON(w, errors.New("error reading request"), http.StatusBadRequest)
+		return
+	}
+	actor.ActorID = id
+	res, err := h.actorUseCase.UpdateActor(r.Context(), actor)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error updating actor", "err", err)
+		utils.ErrorJSON(w, errors.New("error updating actor"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Actor updated", Data: res})
+}
+
+func (h *ActorHandler) DeleteActor(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	err = h.actorUseCase.DeleteActor(r.Context(), id)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error deleting actor", "err", err)
+		utils.ErrorJSON(w, errors.New("error deleting actor"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Actor successfully deleted"})
+}
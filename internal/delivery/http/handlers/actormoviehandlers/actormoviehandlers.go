@@ -1,13 +1,16 @@
 package actormoviehandlers
 
 import (
+	"context"
 	"errors"
 	"filmoteka/internal/domain/models"
 	"filmoteka/internal/utils"
 	"fmt"
 	"github.com/alexedwards/scs/v2"
-	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
 )
 
 type ActorMovieHandler struct {
@@ -22,13 +25,21 @@ func New(useCase actorMovieUseCase, manager *scs.SessionManager) *ActorMovieHand
 	}
 }
 
+// LinkRequest is the body of the legacy add/delete actor to movie requests.
+type LinkRequest struct {
+	ActorID int `json:"actorid"`
+	MovieID int `json:"movieid"`
+}
+
 type actorMovieUseCase interface {
-	GetActorsForMovie(movieid int) ([]*models.Actor, *models.Movie, error)
-	GetMoviesForActor(actorid int) ([]*models.Movie, *models.Actor, error)
-	GetActorsAndMoviesForMovie(movieid int) ([]*models.ActorMovies, *models.Movie, error)
-	GetMovieByActorName(firstname string, lastname string) ([]*models.MovieWithActor, error)
-	AddActorToMovie(actorid int, movieid int) (*models.Actor, *models.Movie, error)
-	DeleteActorFromMovie(actorid int, movieid int) (*models.Actor, *models.Movie, error)
+	GetActorsForMovie(ctx context.Context, movieid int) ([]*models.Actor, *models.Movie, error)
+	GetMoviesForActor(ctx context.Context, actorid int) ([]*models.Movie, *models.Actor, error)
+	GetActorsAndMoviesForMovie(ctx context.Context, movieid int, opts models.CastOptions) ([]*models.ActorMovies, *models.Movie, error)
+	GetMovieByActorName(ctx context.Context, firstname string, lastname string) ([]*models.MovieWithActor, error)
+	AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error)
+	DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error)
+	FindActorPath(ctx context.Context, from int, to int, opts models.PathOptions) (*models.ActorPath, error)
+	GetActorStats(ctx context.Context, actorid int) (*models.ActorStats, error)
 }
 
 func (h *ActorMovieHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -49,23 +60,18 @@ func (h *ActorMovieHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ActorMovieHandler) deleteActorFromMovie(w http.ResponseWriter, r *http.Request) {
-	type request struct {
-		ActorID int `json:"actorid"`
-		MovieID int `json:"movieid"`
-	}
-	req := &request{}
+	req := &LinkRequest{}
 	movie := &models.Movie{}
 	actor := &models.Actor{}
 	err := utils.ReadJSON(r, w, &req)
-	log.Println("Request: ", req)
 	if err != nil {
-		log.Println("Error reading request", err)
+		slog.ErrorContext(r.Context(), "Error reading request", "err", err)
 		utils.ErrorJSON(w, errors.New("error reading request"), http.StatusBadRequest)
 		return
 	}
-	actor, movie, err = h.useCase.DeleteActorFromMovie(req.ActorID, req.MovieID)
+	actor, movie, err = h.useCase.DeleteActorFromMovie(r.Context(), req.ActorID, req.MovieID)
 	if err != nil {
-		log.Println("Error deleting actor from movie", err)
+		slog.ErrorContext(r.Context(), "Error deleting actor from movie", "err", err)
 		utils.ErrorJSON(w, errors.New("error deleting actor from movie"), http.StatusInternalServerError)
 		return
 	}
@@ -83,10 +89,12 @@ func (h *ActorMovieHandler) getMovie(w http.ResponseWriter, r *http.Request) {
 		"movieid":   true,
 		"actorid":   true,
 		"action":    true,
+		"depth":     true,
+		"limit":     true,
 	}
 	for param := range r.URL.Query() {
 		if !expectedParams[param] {
-			log.Println("Invalid request parameter: ", param)
+			slog.InfoContext(r.Context(), "Invalid request parameter", "param", param)
 			utils.ErrorJSON(w, errors.New("invalid request parameter. "), http.StatusBadRequest)
 			return
 		}
@@ -107,12 +115,12 @@ func (h *ActorMovieHandler) getMovie(w http.ResponseWriter, r *http.Request) {
 		if action[0] == "getmovies" && actorOK && len(actorid) > 0 {
 			var id int
 			utils.StringToInt(w, &id, actorid[0])
-			movies, actor, err := h.useCase.GetMoviesForActor(id)
+			movies, actor, err := h.useCase.GetMoviesForActor(r.Context(), id)
 			if errors.Is(err, models.ErrNoRecord) {
 				utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
 				return
 			} else if err != nil {
-				log.Println("Error getting movies", err)
+				slog.ErrorContext(r.Context(), "Error getting movies", "err", err)
 				utils.ErrorJSON(w, errors.New("Error getting movies for actor"), http.StatusInternalServerError)
 				return
 			}
@@ -120,12 +128,12 @@ func (h *ActorMovieHandler) getMovie(w http.ResponseWriter, r *http.Request) {
 		} else if action[0] == "getactors" && idOk && len(movieid) > 0 {
 			var id int
 			utils.StringToInt(w, &id, movieid[0])
-			actors, movie, err := h.useCase.GetActorsForMovie(id)
+			actors, movie, err := h.useCase.GetActorsForMovie(r.Context(), id)
 			if errors.Is(err, models.ErrNoRecord) {
 				utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
 				return
 			} else if err != nil {
-				log.Println("Error getting actors", err)
+				slog.ErrorContext(r.Context(), "Error getting actors", "err", err)
 				utils.ErrorJSON(w, errors.New("Error getting actors for movie"), http.StatusInternalServerError)
 				return
 			}
@@ -135,16 +143,21 @@ func (h *ActorMovieHandler) getMovie(w http.ResponseWriter, r *http.Request) {
 			var id int
 			utils.StringToInt(w, &id, movieid[0])
 			//if err != nil {
-			//	log.Println("Error converting id to int", err)
+			//	slog.ErrorContext(r.Context(), "Error converting id to int", "err", err)
 			//	utils.ErrorJSON(w, errors.New("invalid id parameter"), http.StatusBadRequest)
 			//	return
 			//}
-			res, movie, err := h.useCase.GetActorsAndMoviesForMovie(id)
+			opts, err := castOptions(r.URL.Query())
+			if err != nil {
+				utils.ErrorJSON(w, err, http.StatusBadRequest)
+				return
+			}
+			res, movie, err := h.useCase.GetActorsAndMoviesForMovie(r.Context(), id, opts)
 			if errors.Is(err, models.ErrNoRecord) {
 				utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
 				return
 			} else if err != nil {
-				log.Println("Error getting result", err)
+				slog.ErrorContext(r.Context(), "Error getting result", "err", err)
 				utils.ErrorJSON(w, err, http.StatusInternalServerError)
 				return
 			}
@@ -158,12 +171,12 @@ func (h *ActorMovieHandler) getMovie(w http.ResponseWriter, r *http.Request) {
 			firstname := firstnameParam[0]
 			lastname := lastnameParam[0]
 
-			movies, err := h.useCase.GetMovieByActorName(firstname, lastname)
+			movies, err := h.useCase.GetMovieByActorName(r.Context(), firstname, lastname)
 			if errors.Is(err, models.ErrNoRecord) {
 				utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
 				return
 			} else if err != nil {
-				log.Println("Error getting movies", err)
+				slog.ErrorContext(r.Context(), "Error getting movies", "err", err)
 				utils.ErrorJSON(w, err, http.StatusInternalServerError)
 				return
 			}
@@ -174,12 +187,12 @@ func (h *ActorMovieHandler) getMovie(w http.ResponseWriter, r *http.Request) {
 			firstname := firstnameParam[0]
 			lastname := ""
 			//movie := &models.Movie{}
-			movies, err := h.useCase.GetMovieByActorName(firstname, lastname)
+			movies, err := h.useCase.GetMovieByActorName(r.Context(), firstname, lastname)
 			if errors.Is(err, models.ErrNoRecord) {
 				utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
 				return
 			} else if err != nil {
-				log.Println("Error getting movies", err)
+				slog.ErrorContext(r.Context(), "Error getting movies", "err", err)
 				utils.ErrorJSON(w, err, http.StatusInternalServerError)
 				return
 			}
@@ -189,13 +202,13 @@ func (h *ActorMovieHandler) getMovie(w http.ResponseWriter, r *http.Request) {
 			firstname := ""
 			lastname := lastnameParam[0]
 			//movie := &models.Movie{}
-			movies, err := h.useCase.GetMovieByActorName(firstname, lastname)
+			movies, err := h.useCase.GetMovieByActorName(r.Context(), firstname, lastname)
 			if errors.Is(err, models.ErrNoRecord) {
 				utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
 				return
 
 			} else if err != nil {
-				log.Println("Error getting movies", err)
+				slog.ErrorContext(r.Context(), "Error getting movies", "err", err)
 				utils.ErrorJSON(w, err, http.StatusInternalServerError)
 				return
 			}
@@ -207,26 +220,206 @@ func (h *ActorMovieHandler) getMovie(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ActorMovieHandler) addActorToMovie(w http.ResponseWriter, r *http.Request) {
-	type request struct {
-		ActorID int `json:"actorid"`
-		MovieID int `json:"movieid"`
-	}
 	movie := &models.Movie{}
 	actor := &models.Actor{}
-	req := &request{}
+	req := &LinkRequest{}
 	err := utils.ReadJSON(r, w, &req)
-	log.Println("Movie: ", req)
 	if err != nil {
-		log.Println("Error reading request", err)
+		slog.ErrorContext(r.Context(), "Error reading request", "err", err)
 		utils.ErrorJSON(w, errors.New("error reading request"), http.StatusBadRequest)
 		return
 	}
-	actor, movie, err = h.useCase.AddActorToMovie(req.ActorID, req.MovieID)
+	actor, movie, _, err = h.useCase.AddActorToMovie(r.Context(), req.ActorID, req.MovieID)
 	if err != nil {
-		log.Println("Error adding actor to movie", err)
+		slog.ErrorContext(r.Context(), "Error adding actor to movie", "err", err)
 		utils.ErrorJSON(w, errors.New("error adding actor to movie"), http.StatusInternalServerError)
 		return
 	}
 	utils.WriteJSON(w, http.StatusCreated, utils.JsonResponse{Error: false, Message: fmt.Sprintf("Actor  '%s'  succesfully added to the movie (%s) ", actor.Name, movie.Title), Data: req})
 
 }
+
+// castOptions reads the depth and limit (movies per actor) parameters of the
+// cast with filmographies lookups.
+func castOptions(query url.Values) (models.CastOptions, error) {
+	opts := models.CastOptions{Depth: 2}
+	if v := query.Get("depth"); v != "" {
+		depth, err := strconv.Atoi(v)
+		if err != nil || depth < 1 || depth > 2 {
+			return opts, errors.New("depth must be 1 or 2")
+		}
+		opts.Depth = depth
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return opts, errors.New("limit must be a positive integer")
+		}
+		opts.MoviesPerActor = limit
+	}
+	return opts, nil
+}
+
+// Handlers for the /movies/{id}/actors and /actors/{id}/movies resource routes
+
+func (h *ActorMovieHandler) GetActorsForMovie(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if r.URL.Query().Get("include") == "movies" {
+		opts, err := castOptions(r.URL.Query())
+		if err != nil {
+			utils.ErrorJSON(w, err, http.StatusBadRequest)
+			return
+		}
+		res, movie, err := h.useCase.GetActorsAndMoviesForMovie(r.Context(), id, opts)
+		if errors.Is(err, models.ErrNoRecord) {
+			utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+			return
+		} else if err != nil {
+			slog.ErrorContext(r.Context(), "Error getting result", "err", err)
+			utils.ErrorJSON(w, err, http.StatusInternalServerError)
+			return
+		}
+		utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: fmt.Sprint("Actors of the movie ", movie.Title, " and their movies: "), Data: res})
+		return
+	}
+
+	actors, movie, err := h.useCase.GetActorsForMovie(r.Context(), id)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting actors", "err", err)
+		utils.ErrorJSON(w, errors.New("Error getting actors for movie"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: fmt.Sprintf("Actors retrieved for movie `%s`  (%d)", movie.Title, movie.ReleaseDate.Time.Year()), Data: actors})
+}
+
+func (h *ActorMovieHandler) GetMoviesForActor(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	movies, actor, err := h.useCase.GetMoviesForActor(r.Context(), id)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting movies", "err", err)
+		utils.ErrorJSON(w, errors.New("Error getting movies for actor"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: fmt.Sprint("Movies retrieved for actor ", actor.Name), Data: movies})
+}
+
+func (h *ActorMovieHandler) GetActorStats(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	stats, err := h.useCase.GetActorStats(r.Context(), id)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting actor stats", "err", err)
+		utils.ErrorJSON(w, errors.New("error getting actor stats"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: fmt.Sprint("Stats retrieved for actor ", stats.Actor.Name), Data: stats})
+}
+
+func (h *ActorMovieHandler) AddActorToMovie(w http.ResponseWriter, r *http.Request) {
+	movieID, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	actorID, err := utils.PathID(r, "actorId")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	actor, movie, created, err := h.useCase.AddActorToMovie(r.Context(), actorID, movieID)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error adding actor to movie", "err", err)
+		utils.ErrorJSON(w, errors.New("error adding actor to movie"), http.StatusInternalServerError)
+		return
+	}
+	if !created {
+		utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: fmt.Sprintf("Actor '%s' already plays in the movie (%s)", actor.Name, movie.Title), Data: actor})
+		return
+	}
+	headers := http.Header{"Location": {fmt.Sprintf("/movies/%d/actors/%d", movieID, actorID)}}
+	utils.WriteJSON(w, http.StatusCreated, utils.JsonResponse{Error: false, Message: fmt.Sprintf("Actor  '%s'  succesfully added to the movie (%s) ", actor.Name, movie.Title), Data: actor}, headers)
+}
+
+func (h *ActorMovieHandler) DeleteActorFromMovie(w http.ResponseWriter, r *http.Request) {
+	movieID, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	actorID, err := utils.PathID(r, "actorId")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	actor, movie, err := h.useCase.DeleteActorFromMovie(r.Context(), actorID, movieID)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error deleting actor from movie", "err", err)
+		utils.ErrorJSON(w, errors.New("error deleting actor from movie"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: fmt.Sprintf("Actor  '%s'  succesfully deleted from the movie (%s) ", actor.Name, movie.Title)})
+}
+
+func (h *ActorMovieHandler) FindActorPath(w http.ResponseWriter, r *http.Request) {
+	from, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	to, err := utils.PathID(r, "otherId")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	opts := models.PathOptions{}
+	for param, value := range map[string]*int{"maxdepth": &opts.MaxDepth, "fromyear": &opts.FromYear, "toyear": &opts.ToYear} {
+		v := r.URL.Query().Get(param)
+		if v == "" {
+			continue
+		}
+		*value, err = strconv.Atoi(v)
+		if err != nil || *value < 1 {
+			utils.ErrorJSON(w, fmt.Errorf("invalid %s parameter", param), http.StatusBadRequest)
+			return
+		}
+	}
+
+	path, err := h.useCase.FindActorPath(r.Context(), from, to, opts)
+	if errors.Is(err, models.ErrNoRecord) || errors.Is(err, models.ErrNoPath) {
+		utils.ErrorJSON(w, err, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error finding path between actors", "err", err)
+		utils.ErrorJSON(w, errors.New("error finding path between actors"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: fmt.Sprintf("Actors are %d degrees apart", path.Degrees), Data: path})
+}
@@ -0,0 +1,34 @@
+package analyticshandlers
+
+import (
+	"context"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/utils"
+	"log/slog"
+	"net/http"
+)
+
+type AnalyticsHandler struct {
+	useCase analyticsUseCase
+}
+
+func New(useCase analyticsUseCase) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		useCase: useCase,
+	}
+}
+
+type analyticsUseCase interface {
+	GetCatalogStats(ctx context.Context) (*models.CatalogStats, error)
+}
+
+func (h *AnalyticsHandler) GetCatalogStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.useCase.GetCatalogStats(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting catalog stats", "err", err)
+		utils.ErrorJSON(w, errors.New("error getting catalog stats"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Catalog stats retrieved", Data: stats})
+}
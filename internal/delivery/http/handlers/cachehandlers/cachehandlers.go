@@ -0,0 +1,25 @@
+package cachehandlers
+
+import (
+	"filmoteka/internal/cache"
+	"filmoteka/internal/utils"
+	"net/http"
+)
+
+type CacheHandler struct {
+	cache statsSource
+}
+
+func New(c statsSource) *CacheHandler {
+	return &CacheHandler{
+		cache: c,
+	}
+}
+
+type statsSource interface {
+	Stats() cache.Stats
+}
+
+func (h *CacheHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Cache stats retrieved", Data: h.cache.Stats()})
+}
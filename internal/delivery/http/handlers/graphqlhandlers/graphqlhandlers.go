@@ -0,0 +1,110 @@
+package graphqlhandlers
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"filmoteka/internal/domain/models"
+	"fmt"
+	"github.com/alexedwards/scs/v2"
+	"github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"net/http"
+)
+
+//go:embed schema.graphql
+var schema string
+
+type movieUseCase interface {
+	GetAllMovies(ctx context.Context, param string) ([]*models.Movie, error)
+	CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	GetMovieByID(ctx context.Context, id int) (*models.Movie, error)
+	UpdateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	DeleteMovie(ctx context.Context, id int) error
+	GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error)
+}
+
+type actorUseCase interface {
+	GetAllActors(ctx context.Context) ([]*models.Actor, error)
+	CreateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	GetActorByID(ctx context.Context, id int) (*models.Actor, error)
+	UpdateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	DeleteActor(ctx context.Context, id int) error
+}
+
+type actorMovieUseCase interface {
+	GetMovieByActorName(ctx context.Context, firstname string, lastname string) ([]*models.MovieWithActor, error)
+	AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error)
+	DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error)
+	GetActorsForMovies(ctx context.Context, movieids []int) (map[int][]*models.Actor, error)
+	GetMoviesForActors(ctx context.Context, actorids []int) (map[int][]*models.Movie, error)
+}
+
+type Resolver struct {
+	movieUseCase      movieUseCase
+	actorUseCase      actorUseCase
+	actorMovieUseCase actorMovieUseCase
+	sessionManager    *scs.SessionManager
+}
+
+// Limits bound the work a single request can cause. MaxDepth is the deepest
+// nesting of selections, MaxParallelism the number of fields resolved at
+// once and MaxQueryLength the size of the query text in bytes.
+type Limits struct {
+	MaxDepth       int
+	MaxParallelism int
+	MaxQueryLength int
+}
+
+// maxBodyBytes caps the request body, query and variables together.
+const maxBodyBytes = 1 << 20
+
+type handler struct {
+	schema         *graphql.Schema
+	maxQueryLength int
+}
+
+func New(movies movieUseCase, actors actorUseCase, actorMovies actorMovieUseCase, manager *scs.SessionManager, limits Limits) http.Handler {
+	r := &Resolver{
+		movieUseCase:      movies,
+		actorUseCase:      actors,
+		actorMovieUseCase: actorMovies,
+		sessionManager:    manager,
+	}
+	return &handler{
+		schema:         graphql.MustParseSchema(schema, r, graphql.MaxDepth(limits.MaxDepth), graphql.MaxParallelism(limits.MaxParallelism)),
+		maxQueryLength: limits.MaxQueryLength,
+	}
+}
+
+// ServeHTTP runs a query posted as JSON like relay.Handler, but refuses
+// oversized requests before parsing them.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query         string         `json:"query"`
+		OperationName string         `json:"operationName"`
+		Variables     map[string]any `json:"variables"`
+	}
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(params.Query) > h.maxQueryLength {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(&graphql.Response{
+			Errors: []*gqlerrors.QueryError{gqlerrors.Errorf("query is longer than %d bytes", h.maxQueryLength)},
+		})
+		return
+	}
+
+	response := h.schema.Exec(r.Context(), params.Query, params.OperationName, params.Variables)
+	body, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error encoding response: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Write(body)
+}
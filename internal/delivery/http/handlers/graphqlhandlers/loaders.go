@@ -0,0 +1,111 @@
+package graphqlhandlers
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"log/slog"
+	"sync"
+)
+
+// The loaders batch the nested credit lookups of sibling resolvers: every
+// movie returned by one resolver shares a castLoader, so resolving the actors
+// of all of them costs a single query, and the actors found that way share a
+// filmographyLoader for the next level down.
+
+type castLoader struct {
+	r        *Resolver
+	movieids []int
+	once     sync.Once
+	actors   map[int][]*actorResolver
+	err      error
+}
+
+type filmographyLoader struct {
+	r        *Resolver
+	actorids []int
+	once     sync.Once
+	movies   map[int][]*movieResolver
+	err      error
+}
+
+func (r *Resolver) movieResolvers(movies []*models.Movie) []*movieResolver {
+	loader := &castLoader{r: r}
+	res := make([]*movieResolver, 0, len(movies))
+	for _, m := range movies {
+		loader.movieids = append(loader.movieids, m.MovieID)
+		res = append(res, &movieResolver{movie: m, cast: loader})
+	}
+	return res
+}
+
+func (r *Resolver) actorResolvers(actors []*models.Actor) []*actorResolver {
+	loader := &filmographyLoader{r: r}
+	res := make([]*actorResolver, 0, len(actors))
+	for _, a := range actors {
+		loader.actorids = append(loader.actorids, a.ActorID)
+		res = append(res, &actorResolver{actor: a, films: loader})
+	}
+	return res
+}
+
+func (l *castLoader) load(ctx context.Context, movieid int) ([]*actorResolver, error) {
+	l.once.Do(func() {
+		byMovie, err := l.r.actorMovieUseCase.GetActorsForMovies(ctx, l.movieids)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error getting actors for movies", "err", err)
+			l.err = err
+			return
+		}
+
+		var distinct []*models.Actor
+		index := make(map[int]int)
+		for _, actors := range byMovie {
+			for _, a := range actors {
+				if _, ok := index[a.ActorID]; !ok {
+					index[a.ActorID] = len(distinct)
+					distinct = append(distinct, a)
+				}
+			}
+		}
+		resolvers := l.r.actorResolvers(distinct)
+
+		l.actors = make(map[int][]*actorResolver, len(byMovie))
+		for id, actors := range byMovie {
+			for _, a := range actors {
+				l.actors[id] = append(l.actors[id], resolvers[index[a.ActorID]])
+			}
+		}
+	})
+	return l.actors[movieid], l.err
+}
+
+func (l *filmographyLoader) load(ctx context.Context, actorid int) ([]*movieResolver, error) {
+	l.once.Do(func() {
+		byActor, err := l.r.actorMovieUseCase.GetMoviesForActors(ctx, l.actorids)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error getting movies for actors", "err", err)
+			l.err = err
+			return
+		}
+
+		var distinct []*models.Movie
+		index := make(map[int]int)
+		for _, movies := range byActor {
+			for _, m := range movies {
+				if _, ok := index[m.MovieID]; !ok {
+					index[m.MovieID] = len(distinct)
+					distinct = append(distinct, m)
+				}
+			}
+		}
+		resolvers := l.r.movieResolvers(distinct)
+
+		l.movies = make(map[int][]*movieResolver, len(byActor))
+		for id, movies := range byActor {
+			for _, m := range movies {
+				l.movies[id] = append(l.movies[id], resolvers[index[m.MovieID]])
+			}
+		}
+	})
+	return l.movies[actorid], l.err
+}
@@ -0,0 +1,450 @@
+package graphqlhandlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"github.com/graph-gophers/graphql-go"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+var errUnauthorized = errors.New("unauthorized")
+
+type movieResolver struct {
+	movie *models.Movie
+	cast  *castLoader
+}
+
+type actorResolver struct {
+	actor *models.Actor
+	films *filmographyLoader
+}
+
+type creditResolver struct {
+	movie *movieResolver
+	actor *actorResolver
+}
+
+type movieArgs struct {
+	Sort   string
+	Search *string
+}
+
+type idArgs struct {
+	ID graphql.ID
+}
+
+type actorNameArgs struct {
+	Firstname string
+	Lastname  string
+}
+
+type movieInput struct {
+	Title       *string
+	Description *string
+	Rating      *float64
+	ReleaseDate *string
+}
+
+type actorInput struct {
+	Name        *string
+	Gender      *string
+	DateOfBirth *string
+}
+
+type creditArgs struct {
+	MovieID graphql.ID
+	ActorID graphql.ID
+}
+
+// Queries
+
+func (r *Resolver) Movies(ctx context.Context, args movieArgs) ([]*movieResolver, error) {
+	var movies []*models.Movie
+	var err error
+	if args.Search != nil {
+		movies, err = r.movieUseCase.GetMovieByMovieName(ctx, *args.Search)
+	} else {
+		movies, err = r.movieUseCase.GetAllMovies(ctx, strings.ToLower(args.Sort))
+	}
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		slog.ErrorContext(ctx, "Error getting movies", "err", err)
+		return nil, err
+	}
+	return r.movieResolvers(movies), nil
+}
+
+func (r *Resolver) Movie(ctx context.Context, args idArgs) (*movieResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	movie, err := r.movieUseCase.GetMovieByID(ctx, id)
+	if errors.Is(err, models.ErrNoRecord) {
+		return nil, nil
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error getting movie", "err", err)
+		return nil, err
+	}
+	return r.movieResolvers([]*models.Movie{movie})[0], nil
+}
+
+func (r *Resolver) MoviesByActorName(ctx context.Context, args actorNameArgs) ([]*movieResolver, error) {
+	found, err := r.actorMovieUseCase.GetMovieByActorName(ctx, args.Firstname, args.Lastname)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		slog.ErrorContext(ctx, "Error getting movies", "err", err)
+		return nil, err
+	}
+	// a movie is listed once per matching actor
+	seen := make(map[int]bool, len(found))
+	var movies []*models.Movie
+	for _, m := range found {
+		if seen[m.MovieID] {
+			continue
+		}
+		seen[m.MovieID] = true
+		movies = append(movies, &models.Movie{
+			MovieID:     m.MovieID,
+			Title:       m.Title,
+			Description: m.Description,
+			Rating:      m.Rating,
+			ReleaseDate: m.ReleaseDate,
+		})
+	}
+	return r.movieResolvers(movies), nil
+}
+
+func (r *Resolver) Actors(ctx context.Context) ([]*actorResolver, error) {
+	actors, err := r.actorUseCase.GetAllActors(ctx)
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		slog.ErrorContext(ctx, "Error getting actors", "err", err)
+		return nil, err
+	}
+	return r.actorResolvers(actors), nil
+}
+
+func (r *Resolver) Actor(ctx context.Context, args idArgs) (*actorResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := r.actorUseCase.GetActorByID(ctx, id)
+	if errors.Is(err, models.ErrNoRecord) {
+		return nil, nil
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error getting actor", "err", err)
+		return nil, err
+	}
+	return r.actorResolvers([]*models.Actor{actor})[0], nil
+}
+
+// Mutations, restricted to admins like the write endpoints of the REST API
+
+func (r *Resolver) CreateMovie(ctx context.Context, args struct{ Input movieInput }) (*movieResolver, error) {
+	if !r.isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+	movie, err := args.Input.toModel()
+	if err != nil {
+		return nil, err
+	}
+	movie, err = r.movieUseCase.CreateMovie(ctx, movie)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating movie", "err", err)
+		return nil, errors.New("error creating movie")
+	}
+	return r.movieResolvers([]*models.Movie{movie})[0], nil
+}
+
+func (r *Resolver) UpdateMovie(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input movieInput
+}) (*movieResolver, error) {
+	if !r.isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	movie, err := args.Input.toModel()
+	if err != nil {
+		return nil, err
+	}
+	movie.MovieID = id
+	movie, err = r.movieUseCase.UpdateMovie(ctx, movie)
+	if errors.Is(err, models.ErrNoRecord) {
+		return nil, err
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error updating movie", "err", err)
+		return nil, errors.New("error updating movie")
+	}
+	return r.movieResolvers([]*models.Movie{movie})[0], nil
+}
+
+func (r *Resolver) DeleteMovie(ctx context.Context, args idArgs) (graphql.ID, error) {
+	if !r.isAdmin(ctx) {
+		return "", errUnauthorized
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return "", err
+	}
+	err = r.movieUseCase.DeleteMovie(ctx, id)
+	if errors.Is(err, models.ErrNoRecord) {
+		return "", err
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error deleting movie", "err", err)
+		return "", errors.New("error deleting movie")
+	}
+	return args.ID, nil
+}
+
+func (r *Resolver) CreateActor(ctx context.Context, args struct{ Input actorInput }) (*actorResolver, error) {
+	if !r.isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+	actor, err := args.Input.toModel()
+	if err != nil {
+		return nil, err
+	}
+	actor, err = r.actorUseCase.CreateActor(ctx, actor)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error creating actor", "err", err)
+		return nil, errors.New("error creating actor")
+	}
+	return r.actorResolvers([]*models.Actor{actor})[0], nil
+}
+
+func (r *Resolver) UpdateActor(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input actorInput
+}) (*actorResolver, error) {
+	if !r.isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := args.Input.toModel()
+	if err != nil {
+		return nil, err
+	}
+	actor.ActorID = id
+	actor, err = r.actorUseCase.UpdateActor(ctx, actor)
+	if errors.Is(err, models.ErrNoRecord) {
+		return nil, err
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error updating actor", "err", err)
+		return nil, errors.New("error updating actor")
+	}
+	return r.actorResolvers([]*models.Actor{actor})[0], nil
+}
+
+func (r *Resolver) DeleteActor(ctx context.Context, args idArgs) (graphql.ID, error) {
+	if !r.isAdmin(ctx) {
+		return "", errUnauthorized
+	}
+	id, err := parseID(args.ID)
+	if err != nil {
+		return "", err
+	}
+	err = r.actorUseCase.DeleteActor(ctx, id)
+	if errors.Is(err, models.ErrNoRecord) {
+		return "", err
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error deleting actor", "err", err)
+		return "", errors.New("error deleting actor")
+	}
+	return args.ID, nil
+}
+
+func (r *Resolver) AddCredit(ctx context.Context, args creditArgs) (*creditResolver, error) {
+	if !r.isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+	movieid, actorid, err := args.parse()
+	if err != nil {
+		return nil, err
+	}
+	actor, movie, _, err := r.actorMovieUseCase.AddActorToMovie(ctx, actorid, movieid)
+	if errors.Is(err, models.ErrNoRecord) {
+		return nil, err
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error adding actor to movie", "err", err)
+		return nil, errors.New("error adding actor to movie")
+	}
+	return r.creditResolver(movie, actor), nil
+}
+
+func (r *Resolver) RemoveCredit(ctx context.Context, args creditArgs) (*creditResolver, error) {
+	if !r.isAdmin(ctx) {
+		return nil, errUnauthorized
+	}
+	movieid, actorid, err := args.parse()
+	if err != nil {
+		return nil, err
+	}
+	actor, movie, err := r.actorMovieUseCase.DeleteActorFromMovie(ctx, actorid, movieid)
+	if errors.Is(err, models.ErrNoRecord) {
+		return nil, err
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error deleting actor from movie", "err", err)
+		return nil, errors.New("error deleting actor from movie")
+	}
+	return r.creditResolver(movie, actor), nil
+}
+
+func (r *Resolver) isAdmin(ctx context.Context) bool {
+	return r.sessionManager.GetString(ctx, "role") == "admin"
+}
+
+func (r *Resolver) creditResolver(movie *models.Movie, actor *models.Actor) *creditResolver {
+	return &creditResolver{
+		movie: r.movieResolvers([]*models.Movie{movie})[0],
+		actor: r.actorResolvers([]*models.Actor{actor})[0],
+	}
+}
+
+// Movie fields
+
+func (m *movieResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(m.movie.MovieID))
+}
+
+func (m *movieResolver) Title() string {
+	return m.movie.Title
+}
+
+func (m *movieResolver) Description() string {
+	return m.movie.Description
+}
+
+func (m *movieResolver) Rating() float64 {
+	return m.movie.Rating
+}
+
+func (m *movieResolver) ReleaseDate() *string {
+	return formatDate(m.movie.ReleaseDate)
+}
+
+func (m *movieResolver) Actors(ctx context.Context) ([]*actorResolver, error) {
+	actors, err := m.cast.load(ctx, m.movie.MovieID)
+	if actors == nil {
+		actors = []*actorResolver{}
+	}
+	return actors, err
+}
+
+// Actor fields
+
+func (a *actorResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(a.actor.ActorID))
+}
+
+func (a *actorResolver) Name() string {
+	return a.actor.Name
+}
+
+func (a *actorResolver) Gender() string {
+	return a.actor.Gender
+}
+
+func (a *actorResolver) DateOfBirth() *string {
+	return formatDate(a.actor.DateOfBirth)
+}
+
+func (a *actorResolver) Movies(ctx context.Context) ([]*movieResolver, error) {
+	movies, err := a.films.load(ctx, a.actor.ActorID)
+	if movies == nil {
+		movies = []*movieResolver{}
+	}
+	return movies, err
+}
+
+// Credit fields
+
+func (c *creditResolver) Movie() *movieResolver {
+	return c.movie
+}
+
+func (c *creditResolver) Actor() *actorResolver {
+	return c.actor
+}
+
+// Helpers
+
+func parseID(id graphql.ID) (int, error) {
+	res, err := strconv.Atoi(string(id))
+	if err != nil || res < 1 {
+		return 0, errors.New("invalid id")
+	}
+	return res, nil
+}
+
+func (c creditArgs) parse() (movieid int, actorid int, err error) {
+	movieid, err = parseID(c.MovieID)
+	if err != nil {
+		return 0, 0, err
+	}
+	actorid, err = parseID(c.ActorID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return movieid, actorid, nil
+}
+
+func formatDate(d models.Date) *string {
+	if !d.Valid {
+		return nil
+	}
+	s := d.Time.Format(dateLayout)
+	return &s
+}
+
+func parseDate(s *string) (models.Date, error) {
+	if s == nil {
+		return models.Date{}, nil
+	}
+	t, err := time.Parse(dateLayout, *s)
+	if err != nil {
+		return models.Date{}, errors.New("dates must be formatted as YYYY-MM-DD")
+	}
+	return models.Date{NullTime: sql.NullTime{Time: t, Valid: true}}, nil
+}
+
+func (in movieInput) toModel() (*models.Movie, error) {
+	m := &models.Movie{}
+	if in.Title != nil {
+		m.Title = *in.Title
+	}
+	if in.Description != nil {
+		m.Description = *in.Description
+	}
+	if in.Rating != nil {
+		m.Rating = *in.Rating
+	}
+	var err error
+	m.ReleaseDate, err = parseDate(in.ReleaseDate)
+	return m, err
+}
+
+func (in actorInput) toModel() (*models.Actor, error) {
+	a := &models.Actor{}
+	if in.Name != nil {
+		a.Name = *in.Name
+	}
+	if in.Gender != nil {
+		a.Gender = *in.Gender
+	}
+	var err error
+	a.DateOfBirth, err = parseDate(in.DateOfBirth)
+	return a, err
+}
@@ -0,0 +1,55 @@
+package healthhandlers
+
+import (
+	"context"
+	"filmoteka/internal/health"
+	"filmoteka/internal/utils"
+	"github.com/alexedwards/scs/v2"
+	"net/http"
+)
+
+type HealthHandler struct {
+	checker        checker
+	sessionManager *scs.SessionManager
+}
+
+func New(c checker, manager *scs.SessionManager) *HealthHandler {
+	return &HealthHandler{
+		checker:        c,
+		sessionManager: manager,
+	}
+}
+
+type checker interface {
+	Live() *health.Report
+	Ready(ctx context.Context) *health.Report
+}
+
+// Healthz is the liveness probe.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	h.write(w, r, h.checker.Live())
+}
+
+// Readyz is the readiness probe, failing while a dependency is down or the
+// server is shutting down.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	h.write(w, r, h.checker.Ready(r.Context()))
+}
+
+// write answers probes with a plain status line and admins with the whole
+// report.
+func (h *HealthHandler) write(w http.ResponseWriter, r *http.Request, report *health.Report) {
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	if h.sessionManager.GetString(r.Context(), "role") == "admin" {
+		utils.WriteJSON(w, status, utils.JsonResponse{Error: !report.Ready, Message: report.Status, Data: report})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(report.Status + "\n"))
+}
@@ -1,12 +1,15 @@
 package moviehandlers
 
 import (
+	"context"
 	"errors"
 	"filmoteka/internal/domain/models"
 	"filmoteka/internal/utils"
+	"fmt"
 	"github.com/alexedwards/scs/v2"
-	"log"
+	"log/slog"
 	"net/http"
+	"time"
 )
 
 type MovieHandler struct {
@@ -22,12 +25,13 @@ func New(useCase movieUseCase, manager *scs.SessionManager) *MovieHandler {
 }
 
 type movieUseCase interface {
-	GetAllMovies(param string) ([]*models.Movie, error)
-	CreateMovie(m *models.Movie) (*models.Movie, error)
-	GetMovieByID(id int) (*models.Movie, error)
-	UpdateMovie(m *models.Movie) (*models.Movie, error)
-	DeleteMovie(id int) error
-	GetMovieByMovieName(moviename string) ([]*models.Movie, error)
+	GetAllMovies(ctx context.Context, param string) ([]*models.Movie, error)
+	CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	GetMovieByID(ctx context.Context, id int) (*models.Movie, error)
+	UpdateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	DeleteMovie(ctx context.Context, id int) error
+	GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error)
+	GetMoviesModifiedAt(ctx context.Context) (time.Time, error)
 }
 
 func (h *MovieHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -84,7 +88,7 @@ func (h *MovieHandler) getMovie(w http.ResponseWriter, r *http.Request) {
 
 	for param := range r.URL.Query() {
 		if !expectedParams[param] {
-			log.Println("Invalid request parameter: ", param)
+			slog.InfoContext(r.Context(), "Invalid request parameter", "param", param)
 			utils.ErrorJSON(w, errors.New("invalid request parameter. "), http.StatusBadRequest)
 			return
 		}
@@ -100,50 +104,54 @@ func (h *MovieHandler) getMovie(w http.ResponseWriter, r *http.Request) {
 		// Fetch movie by id
 		var id int
 		utils.StringToInt(w, &id, idParam[0])
-		movie, err := h.movieUseCase.GetMovieByID(id)
+		movie, err := h.movieUseCase.GetMovieByID(r.Context(), id)
 		if err != nil {
-			log.Println("Error getting movie", err)
+			slog.ErrorContext(r.Context(), "Error getting movie", "err", err)
 			utils.ErrorJSON(w, err, http.StatusInternalServerError)
 			return
 		}
+		utils.SetLastModified(w, movie.UpdatedAt)
 		utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Movie retrieved", Data: movie})
 
 	} else if sortOk {
 		// Fetch all movies and sort
-		movies, err := h.movieUseCase.GetAllMovies(sortParam[0])
+		movies, err := h.movieUseCase.GetAllMovies(r.Context(), sortParam[0])
 		if errors.Is(err, models.ErrNoRecord) {
 			utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
 			return
 		} else if err != nil {
-			log.Println("Error getting movies", err)
+			slog.ErrorContext(r.Context(), "Error getting movies", "err", err)
 			utils.ErrorJSON(w, err, http.StatusInternalServerError)
 			return
 		}
+		h.setMoviesModified(w, r)
 		utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Movies retrieved", Data: movies})
 
 	} else if nameOk && len(nameParam) > 0 {
 		// Fetch all movies and sort
-		movies, err := h.movieUseCase.GetMovieByMovieName(nameParam[0])
+		movies, err := h.movieUseCase.GetMovieByMovieName(r.Context(), nameParam[0])
 		if errors.Is(err, models.ErrNoRecord) {
 			utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
 			return
 		} else if err != nil {
-			log.Println("Error getting movies", err)
+			slog.ErrorContext(r.Context(), "Error getting movies", "err", err)
 			utils.ErrorJSON(w, err, http.StatusInternalServerError)
 			return
 		}
+		h.setMoviesModified(w, r)
 		utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Movie retrieved", Data: movies})
 	} else {
 		// Fetch all movies
-		movies, err := h.movieUseCase.GetAllMovies("")
+		movies, err := h.movieUseCase.GetAllMovies(r.Context(), "")
 		if errors.Is(err, models.ErrNoRecord) {
 			utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
 			return
 		} else if err != nil {
-			log.Println("Error getting movies", err)
+			slog.ErrorContext(r.Context(), "Error getting movies", "err", err)
 			utils.ErrorJSON(w, err, http.StatusInternalServerError)
 			return
 		}
+		h.setMoviesModified(w, r)
 		utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Movies retrieved", Data: movies})
 	}
 }
@@ -153,20 +161,20 @@ func (h *MovieHandler) createMovie(w http.ResponseWriter, r *http.Request) {
 	var movie *models.Movie
 
 	err := utils.ReadJSON(r, w, &movie)
-	log.Println("Movie: ", movie)
 	if err != nil {
-		log.Println("Error reading request", err)
+		slog.ErrorContext(r.Context(), "Error reading request", "err", err)
 		utils.ErrorJSON(w, errors.New("error reading request"), http.StatusBadRequest)
 		return
 	}
 
-	movie, err = h.movieUseCase.CreateMovie(movie)
+	movie, err = h.movieUseCase.CreateMovie(r.Context(), movie)
 	if err != nil {
-		log.Println("Error creating movie", err)
+		slog.ErrorContext(r.Context(), "Error creating movie", "err", err)
 		utils.ErrorJSON(w, errors.New("error creating movie"), http.StatusInternalServerError)
 		return
 	}
-	utils.WriteJSON(w, http.StatusCreated, utils.JsonResponse{Error: false, Message: "Movie created", Data: movie})
+	headers := http.Header{"Location": {fmt.Sprintf("/movies/%d", movie.MovieID)}}
+	utils.WriteJSON(w, http.StatusCreated, utils.JsonResponse{Error: false, Message: "Movie created", Data: movie}, headers)
 
 }
 
@@ -175,13 +183,13 @@ func (h *MovieHandler) updateMovie(w http.ResponseWriter, r *http.Request) {
 	movie := &models.Movie{}
 	err := utils.ReadJSON(r, w, &movie)
 	if err != nil {
-		log.Println("Error reading request", err)
+		slog.ErrorContext(r.Context(), "Error reading request", "err", err)
 		utils.ErrorJSON(w, errors.New("error reading request"), http.StatusBadRequest)
 		return
 	}
-	movie, err = h.movieUseCase.UpdateMovie(movie)
+	movie, err = h.movieUseCase.UpdateMovie(r.Context(), movie)
 	if err != nil {
-		log.Println("Error updating movie", err)
+		slog.ErrorContext(r.Context(), "Error updating movie", "err", err)
 		utils.ErrorJSON(w, errors.New("error updating movie"), http.StatusInternalServerError)
 		return
 	}
@@ -195,7 +203,7 @@ func (h *MovieHandler) deleteMovie(w http.ResponseWriter, r *http.Request) {
 
 	for param := range r.URL.Query() {
 		if !expectedParams[param] {
-			log.Println("Invalid request parameter: ", param)
+			slog.InfoContext(r.Context(), "Invalid request parameter", "param", param)
 			utils.ErrorJSON(w, errors.New("invalid request parameter. "), http.StatusBadRequest)
 			return
 		}
@@ -209,11 +217,130 @@ func (h *MovieHandler) deleteMovie(w http.ResponseWriter, r *http.Request) {
 	var err error
 	var id int
 	utils.StringToInt(w, &id, idParam[0])
-	err = h.movieUseCase.DeleteMovie(id)
-	if err != nil {
-		log.Println("Error deleting movie", err)
+	err = h.movieUseCase.DeleteMovie(r.Context(), id)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error deleting movie", "err", err)
 		utils.ErrorJSON(w, errors.New("error deleting movie"), http.StatusInternalServerError)
 		return
 	}
 	utils.WriteJSON(w, http.StatusCreated, utils.JsonResponse{Error: false, Message: "Movie successfully deleted"})
 }
+
+// Handlers for the /movies resource routes
+
+// ListMovies lists the movies, sorted by sort or searched by name. Single
+// movies are at /movies/{id}, unlike on the legacy /movie route there is no
+// id parameter.
+func (h *MovieHandler) ListMovies(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	for param := range query {
+		if param != "sort" && param != "name" {
+			slog.InfoContext(r.Context(), "Invalid request parameter", "param", param)
+			utils.ErrorJSON(w, fmt.Errorf("invalid request parameter %q", param), http.StatusBadRequest)
+			return
+		}
+	}
+	if query.Has("sort") && query.Has("name") {
+		utils.ErrorJSON(w, errors.New("sort and name cannot be combined"), http.StatusBadRequest)
+		return
+	}
+
+	var movies []*models.Movie
+	var err error
+	if query.Has("name") {
+		movies, err = h.movieUseCase.GetMovieByMovieName(r.Context(), query.Get("name"))
+	} else {
+		movies, err = h.movieUseCase.GetAllMovies(r.Context(), query.Get("sort"))
+	}
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting movies", "err", err)
+		utils.ErrorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+	h.setMoviesModified(w, r)
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Movies retrieved", Data: movies})
+}
+
+func (h *MovieHandler) CreateMovie(w http.ResponseWriter, r *http.Request) {
+	h.createMovie(w, r)
+}
+
+func (h *MovieHandler) GetMovie(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	movie, err := h.movieUseCase.GetMovieByID(r.Context(), id)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting movie", "err", err)
+		utils.ErrorJSON(w, err, http.StatusInternalServerError)
+		return
+	}
+	utils.SetLastModified(w, movie.UpdatedAt)
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Movie retrieved", Data: movie})
+}
+
+func (h *MovieHandler) UpdateMovie(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	movie := &models.Movie{}
+	err = utils.ReadJSON(r, w, &movie)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading request", "err", err)
+		utils.ErrorJSON(w, errors.New("error reading request"), http.StatusBadRequest)
+		return
+	}
+	movie.MovieID = id
+	movie, err = h.movieUseCase.UpdateMovie(r.Context(), movie)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error updating movie", "err", err)
+		utils.ErrorJSON(w, errors.New("error updating movie"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Movie updated", Data: movie})
+}
+
+func (h *MovieHandler) DeleteMovie(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+	err = h.movieUseCase.DeleteMovie(r.Context(), id)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, models.ErrNoRecord, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error deleting movie", "err", err)
+		utils.ErrorJSON(w, errors.New("error deleting movie"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Movie successfully deleted"})
+}
+
+// setMoviesModified sets Last-Modified of movie lists to the last change of
+// the movies table. The ETag still validates the response if that fails.
+func (h *MovieHandler) setMoviesModified(w http.ResponseWriter, r *http.Request) {
+	modified, err := h.movieUseCase.GetMoviesModifiedAt(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting last change of movies", "err", err)
+		return
+	}
+	utils.SetLastModified(w, modified)
+}
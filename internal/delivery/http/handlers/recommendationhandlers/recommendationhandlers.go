@@ -0,0 +1,56 @@
+package recommendationhandlers
+
+import (
+	"context"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/utils"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+const defaultLimit = 10
+
+type RecommendationHandler struct {
+	useCase recommendationUseCase
+}
+
+func New(useCase recommendationUseCase) *RecommendationHandler {
+	return &RecommendationHandler{
+		useCase: useCase,
+	}
+}
+
+type recommendationUseCase interface {
+	SimilarMovies(ctx context.Context, id int, limit int) ([]*models.SimilarMovie, error)
+}
+
+func (h *RecommendationHandler) SimilarMovies(w http.ResponseWriter, r *http.Request) {
+	id, err := utils.PathID(r, "id")
+	if err != nil {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			utils.ErrorJSON(w, errors.New("invalid limit parameter"), http.StatusBadRequest)
+			return
+		}
+	}
+
+	similar, err := h.useCase.SimilarMovies(r.Context(), id, limit)
+	if errors.Is(err, models.ErrNoRecord) {
+		utils.ErrorJSON(w, err, http.StatusNotFound)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error getting similar movies", "err", err)
+		utils.ErrorJSON(w, errors.New("error getting similar movies"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: fmt.Sprintf("Found %d similar movies", len(similar)), Data: similar})
+}
@@ -1,12 +1,17 @@
 package userhandlers
 
 import (
+	"context"
+	"errors"
+	"filmoteka/internal/delivery/http/middleware"
+	"filmoteka/internal/domain/models"
 	"filmoteka/internal/utils"
 	"fmt"
 	"github.com/alexedwards/scs/v2"
-	"golang.org/x/crypto/bcrypt"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 )
 
 type UserHandler struct {
@@ -21,9 +26,35 @@ func New(useCase userUseCase, manager *scs.SessionManager) *UserHandler {
 	}
 }
 
+// LoginRequest is the body of a login request.
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// UnlockRequest names the account, the client address or both whose failed
+// logins should be forgotten.
+type UnlockRequest struct {
+	Email string `json:"email,omitempty"`
+	IP    string `json:"ip,omitempty"`
+}
+
+// PasswordResetRequest asks for a reset token to be mailed to Email.
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+// PasswordResetConfirm sets a new password with a mailed reset token.
+type PasswordResetConfirm struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
 type userUseCase interface {
-	//auth(u *models.User)
-	GetUserByEmail(email string) (string, string, error)
+	Login(ctx context.Context, email string, password string, ip string) (*models.User, error)
+	Unlock(ctx context.Context, email string, ip string) error
+	RequestPasswordReset(ctx context.Context, email string)
+	ResetPassword(ctx context.Context, token string, password string) (*models.User, error)
 }
 
 func (h *UserHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -37,35 +68,115 @@ func (h *UserHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) login(w http.ResponseWriter, r *http.Request) {
-	type request struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
-	}
-	req := &request{}
+	req := &LoginRequest{}
 	err := utils.ReadJSON(r, w, &req)
-	log.Println(req)
 	if err != nil {
-		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		utils.ErrorJSON(w, errors.New("error reading request"), http.StatusBadRequest)
 		return
 	}
-	pwd, role, err := h.userUseCase.GetUserByEmail(req.Email)
-	if err != nil {
-		utils.ErrorJSON(w, err, http.StatusInternalServerError)
+
+	user, err := h.userUseCase.Login(r.Context(), req.Email, req.Password, utils.ClientIP(r))
+	var throttled *models.LoginThrottledError
+	if errors.As(err, &throttled) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		utils.ErrorJSON(w, throttled, http.StatusTooManyRequests)
 		return
-	}
-	err = bcrypt.CompareHashAndPassword([]byte(pwd), []byte(req.Password))
-	if err != nil {
+	} else if errors.Is(err, models.ErrInvalidCredentials) {
 		utils.ErrorJSON(w, err, http.StatusUnauthorized)
 		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error logging in", "err", err)
+		utils.ErrorJSON(w, errors.New("error logging in"), http.StatusInternalServerError)
+		return
 	}
 
 	// create session
 	err = h.sessionManager.RenewToken(r.Context())
 	if err != nil {
-		utils.ErrorJSON(w, err, http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Error renewing session token", "err", err)
+		utils.ErrorJSON(w, errors.New("error logging in"), http.StatusInternalServerError)
+		return
+	}
+	h.sessionManager.Put(r.Context(), "role", user.Role)
+	h.sessionManager.Put(r.Context(), "email", user.Email)
+	csrfToken, err := middleware.RenewCSRFToken(h.sessionManager, r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating CSRF token", "err", err)
+		utils.ErrorJSON(w, errors.New("error logging in"), http.StatusInternalServerError)
 		return
 	}
-	h.sessionManager.Put(r.Context(), "role", role)
 
-	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Logged in", Data: nil})
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Logged in", Data: middleware.CSRFTokenResponse{CSRFToken: csrfToken}})
+}
+
+func (h *UserHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	req := &UnlockRequest{}
+	err := utils.ReadJSON(r, w, &req)
+	if err != nil || (req.Email == "" && req.IP == "") {
+		utils.ErrorJSON(w, errors.New("email or ip is required"), http.StatusBadRequest)
+		return
+	}
+	err = h.userUseCase.Unlock(r.Context(), req.Email, req.IP)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error unlocking login", "err", err)
+		utils.ErrorJSON(w, errors.New("error unlocking login"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Login unlocked"})
+}
+
+func (h *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	req := &PasswordResetRequest{}
+	err := utils.ReadJSON(r, w, &req)
+	if err != nil || req.Email == "" {
+		utils.ErrorJSON(w, errors.New("email is required"), http.StatusBadRequest)
+		return
+	}
+	h.userUseCase.RequestPasswordReset(r.Context(), req.Email)
+	utils.WriteJSON(w, http.StatusAccepted, utils.JsonResponse{Error: false, Message: "If the account exists, a reset mail has been sent"})
+}
+
+func (h *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	req := &PasswordResetConfirm{}
+	err := utils.ReadJSON(r, w, &req)
+	if err != nil || req.Token == "" || req.Password == "" {
+		utils.ErrorJSON(w, errors.New("token and password are required"), http.StatusBadRequest)
+		return
+	}
+	user, err := h.userUseCase.ResetPassword(r.Context(), req.Token, req.Password)
+	var weak *models.PasswordPolicyError
+	if errors.Is(err, models.ErrInvalidResetToken) || errors.As(err, &weak) {
+		utils.ErrorJSON(w, err, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		slog.ErrorContext(r.Context(), "Error resetting password", "err", err)
+		utils.ErrorJSON(w, errors.New("error resetting password"), http.StatusInternalServerError)
+		return
+	}
+
+	err = h.destroySessions(r.Context(), user.Email)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error destroying sessions after password reset", "err", err)
+		utils.ErrorJSON(w, errors.New("error resetting password"), http.StatusInternalServerError)
+		return
+	}
+	utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "Password reset"})
+}
+
+// destroySessions logs the user with email out everywhere, including the
+// session of the current request if it belongs to them.
+func (h *UserHandler) destroySessions(ctx context.Context, email string) error {
+	err := h.sessionManager.Iterate(ctx, func(ctx context.Context) error {
+		if h.sessionManager.GetString(ctx, "email") != email {
+			return nil
+		}
+		return h.sessionManager.Destroy(ctx)
+	})
+	if err != nil {
+		return err
+	}
+	if h.sessionManager.GetString(ctx, "email") == email {
+		return h.sessionManager.Destroy(ctx)
+	}
+	return nil
 }
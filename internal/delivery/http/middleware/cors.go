@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures cross-origin access. AllowedOrigins are exact
+// origins such as "https://app.example.com", "https://*.example.com" for
+// any subdomain, or "*" for any origin; without any CORS is disabled.
+type CORSOptions struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+type CORS struct {
+	opts     CORSOptions
+	any      bool
+	exact    map[string]bool
+	suffixes []string
+
+	methods string
+	headers map[string]bool
+	exposed string
+	maxAge  string
+}
+
+func NewCORS(opts CORSOptions) (*CORS, error) {
+	c := &CORS{
+		opts:    opts,
+		exact:   make(map[string]bool),
+		methods: strings.Join(opts.AllowedMethods, ", "),
+		headers: make(map[string]bool),
+		exposed: strings.Join(opts.ExposedHeaders, ", "),
+		maxAge:  strconv.Itoa(int(opts.MaxAge.Seconds())),
+	}
+	for _, origin := range opts.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		switch {
+		case origin == "*":
+			c.any = true
+		case strings.Contains(origin, "://*."):
+			scheme, host, _ := strings.Cut(origin, "://*")
+			c.suffixes = append(c.suffixes, scheme+"://|"+host)
+		default:
+			c.exact[origin] = true
+		}
+	}
+	if c.any && opts.AllowCredentials {
+		return nil, errors.New("CORS credentials cannot be allowed for any origin")
+	}
+	for _, h := range opts.AllowedHeaders {
+		c.headers[strings.ToLower(h)] = true
+	}
+	return c, nil
+}
+
+// Handler answers preflight requests from allowed origins itself and adds
+// the CORS headers to their other requests. Responses vary by Origin either
+// way, so that caches keep them apart.
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		if preflight {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+		}
+		if origin == "" || !c.allowed(origin) {
+			if preflight {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if c.any {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if c.opts.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if c.exposed != "" {
+				h.Set("Access-Control-Expose-Headers", c.exposed)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !c.allowedHeaders(r.Header.Get("Access-Control-Request-Headers")) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		h.Set("Access-Control-Allow-Methods", c.methods)
+		if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			h.Set("Access-Control-Allow-Headers", requested)
+		}
+		h.Set("Access-Control-Max-Age", c.maxAge)
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (c *CORS) allowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	if c.any || c.exact[origin] {
+		return true
+	}
+	for _, s := range c.suffixes {
+		scheme, host, _ := strings.Cut(s, "|")
+		rest, ok := strings.CutPrefix(origin, scheme)
+		if ok && strings.HasSuffix(rest, host) && len(rest) > len(host) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowedHeaders reports whether every header of an
+// Access-Control-Request-Headers list is allowed.
+func (c *CORS) allowedHeaders(requested string) bool {
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h != "" && !c.headers[h] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestCORS(t *testing.T, origins []string, credentials bool) http.Handler {
+	t.Helper()
+	cors, err := NewCORS(CORSOptions{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type", "X-CSRF-Token"},
+		ExposedHeaders:   []string{"ETag"},
+		AllowCredentials: credentials,
+		MaxAge:           10 * time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cors.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+}
+
+func corsRequest(handler http.Handler, method string, origin string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/movies", nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	for name, values := range header {
+		for _, value := range values {
+			r.Header.Add(name, value)
+		}
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestCORSOrigins(t *testing.T) {
+	handler := newTestCORS(t, []string{"https://app.example.org/", "https://*.example.com"}, true)
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.org", true},
+		{"HTTPS://APP.EXAMPLE.ORG", true},
+		{"http://app.example.org", false},
+		{"https://app.example.org.evil.com", false},
+		{"https://www.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://example.com", false},
+		{"https://evilexample.com", false},
+		{"https://.example.com", false},
+		{"http://www.example.com", false},
+		{"https://www.example.com:8443", false},
+		{"https://example.com.evil.com", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			w := corsRequest(handler, http.MethodGet, tt.origin, nil)
+			if w.Code != http.StatusTeapot {
+				t.Errorf("got status %d, want the handler's %d", w.Code, http.StatusTeapot)
+			}
+			got := w.Header().Get("Access-Control-Allow-Origin")
+			if tt.want && got != tt.origin {
+				t.Errorf("got Access-Control-Allow-Origin %q, want %q", got, tt.origin)
+			} else if !tt.want && got != "" {
+				t.Errorf("got Access-Control-Allow-Origin %q, want none", got)
+			}
+			if credentials := w.Header().Get("Access-Control-Allow-Credentials"); (credentials == "true") != tt.want {
+				t.Errorf("got Access-Control-Allow-Credentials %q", credentials)
+			}
+			if exposed := w.Header().Get("Access-Control-Expose-Headers"); (exposed == "ETag") != tt.want {
+				t.Errorf("got Access-Control-Expose-Headers %q", exposed)
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	handler := newTestCORS(t, []string{"https://app.example.org"}, true)
+
+	tests := []struct {
+		name    string
+		origin  string
+		headers string
+		allowed bool
+	}{
+		{"allowed", "https://app.example.org", "content-type, X-CSRF-Token", true},
+		{"without headers", "https://app.example.org", "", true},
+		{"disallowed origin", "https://evil.example.org", "content-type", false},
+		{"disallowed header", "https://app.example.org", "content-type, x-evil", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{"Access-Control-Request-Method": {"POST"}}
+			if tt.headers != "" {
+				header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			w := corsRequest(handler, http.MethodOptions, tt.origin, header)
+			if w.Code != http.StatusNoContent {
+				t.Errorf("got status %d, want %d without calling the handler", w.Code, http.StatusNoContent)
+			}
+			methods := w.Header().Get("Access-Control-Allow-Methods")
+			if tt.allowed && methods != "GET, POST" {
+				t.Errorf("got Access-Control-Allow-Methods %q, want %q", methods, "GET, POST")
+			} else if !tt.allowed && methods != "" {
+				t.Errorf("got Access-Control-Allow-Methods %q, want none", methods)
+			}
+			wantHeaders := ""
+			if tt.allowed {
+				wantHeaders = tt.headers
+			}
+			if headers := w.Header().Get("Access-Control-Allow-Headers"); headers != wantHeaders {
+				t.Errorf("got Access-Control-Allow-Headers %q, want %q", headers, wantHeaders)
+			}
+			if maxAge := w.Header().Get("Access-Control-Max-Age"); tt.allowed && maxAge != "600" {
+				t.Errorf("got Access-Control-Max-Age %q, want 600", maxAge)
+			}
+		})
+	}
+}
+
+func TestCORSVary(t *testing.T) {
+	handler := newTestCORS(t, []string{"https://app.example.org"}, false)
+	preflight := http.Header{"Access-Control-Request-Method": {"POST"}}
+
+	tests := []struct {
+		name   string
+		method string
+		origin string
+		header http.Header
+		want   []string
+	}{
+		{"without origin", http.MethodGet, "", nil, []string{"Origin"}},
+		{"allowed origin", http.MethodGet, "https://app.example.org", nil, []string{"Origin"}},
+		{"disallowed origin", http.MethodGet, "https://evil.example.org", nil, []string{"Origin"}},
+		{"preflight", http.MethodOptions, "https://app.example.org", preflight, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}},
+		{"disallowed preflight", http.MethodOptions, "https://evil.example.org", preflight, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := corsRequest(handler, tt.method, tt.origin, tt.header)
+			if got := w.Header().Values("Vary"); !slices.Equal(got, tt.want) {
+				t.Errorf("got Vary %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	_, err := NewCORS(CORSOptions{AllowedOrigins: []string{"https://app.example.org", "*"}, AllowCredentials: true})
+	if err == nil {
+		t.Error("credentials for any origin: got no error")
+	}
+
+	handler := newTestCORS(t, []string{"*"}, false)
+	w := corsRequest(handler, http.MethodGet, "https://anywhere.example.net", nil)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("got Access-Control-Allow-Origin %q, want *", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Errorf("got Access-Control-Allow-Credentials %q, want none", got)
+	}
+}
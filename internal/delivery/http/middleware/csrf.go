@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"filmoteka/internal/utils"
+	"github.com/alexedwards/scs/v2"
+	"log/slog"
+	"mime"
+	"net/http"
+)
+
+// CSRFHeader carries the token of the session on state-changing requests.
+const CSRFHeader = "X-CSRF-Token"
+
+const csrfKey = "csrf_token"
+
+// CSRF rejects state-changing requests of cookie sessions that do not carry
+// the session's token in the X-CSRF-Token header. Bearer token clients are
+// exempt: a browser never attaches the header on its own. Sessions that are
+// not logged in, as on POST /login, may send a JSON body instead, which
+// another site can only send after a CORS preflight. Otherwise a page could
+// log its visitors into an account of the attacker.
+func CSRF(manager *scs.SessionManager, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if IsBearer(r) || (manager.GetString(r.Context(), "role") == "" && isJSON(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		want := manager.GetString(r.Context(), csrfKey)
+		got := r.Header.Get(CSRFHeader)
+		if want == "" || subtle.ConstantTimeCompare([]byte(want), []byte(got)) != 1 {
+			utils.ErrorJSON(w, errors.New("missing or invalid CSRF token"), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isJSON reports whether r has a JSON body, a content type no form can send.
+func isJSON(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// CSRFToken returns the CSRF token of the session in ctx, creating one if
+// the session has none yet.
+func CSRFToken(manager *scs.SessionManager, ctx context.Context) (string, error) {
+	if token := manager.GetString(ctx, csrfKey); token != "" {
+		return token, nil
+	}
+	return RenewCSRFToken(manager, ctx)
+}
+
+// RenewCSRFToken replaces the CSRF token of the session in ctx, as on login
+// together with the session token.
+func RenewCSRFToken(manager *scs.SessionManager, ctx context.Context) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	manager.Put(ctx, csrfKey, token)
+	return token, nil
+}
+
+// CSRFTokenResponse is the data of a response handing out a CSRF token.
+type CSRFTokenResponse struct {
+	CSRFToken string `json:"csrf_token"`
+}
+
+// CSRFTokenHandler returns the CSRF token of the caller's session.
+func CSRFTokenHandler(manager *scs.SessionManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, err := CSRFToken(manager, r.Context())
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error creating CSRF token", "err", err)
+			utils.ErrorJSON(w, errors.New("error creating CSRF token"), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		utils.WriteJSON(w, http.StatusOK, utils.JsonResponse{Error: false, Message: "CSRF token", Data: CSRFTokenResponse{CSRFToken: token}})
+	}
+}
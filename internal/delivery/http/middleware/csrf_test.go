@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"context"
+	"github.com/alexedwards/scs/v2"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// csrfServer serves POST /login, which logs in like the user handler does
+// and answers with the CSRF token, GET /csrf and a POST /movies to protect.
+func csrfServer(manager *scs.SessionManager) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		if err := manager.RenewToken(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		manager.Put(r.Context(), "role", "admin")
+		token, err := RenewCSRFToken(manager, r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(token))
+	})
+	mux.HandleFunc("GET /csrf", func(w http.ResponseWriter, r *http.Request) {
+		token, err := CSRFToken(manager, r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(token))
+	})
+	mux.HandleFunc("/movies", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return Sessions(manager, CSRF(manager, mux))
+}
+
+// client keeps the session cookie between requests.
+type client struct {
+	t       *testing.T
+	handler http.Handler
+	cookie  *http.Cookie
+}
+
+func (c *client) do(method string, path string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(`{}`))
+	for name, values := range header {
+		for _, value := range values {
+			r.Header.Add(name, value)
+		}
+	}
+	if c.cookie != nil {
+		r.AddCookie(c.cookie)
+	}
+	w := httptest.NewRecorder()
+	c.handler.ServeHTTP(w, r)
+	for _, cookie := range w.Result().Cookies() {
+		c.cookie = cookie
+	}
+	return w
+}
+
+// login logs in, sending token if the session is logged in already.
+func (c *client) login(token string) string {
+	w := c.do(http.MethodPost, "/login", http.Header{"Content-Type": {"application/json"}, CSRFHeader: {token}})
+	if w.Code != http.StatusOK {
+		c.t.Fatalf("login: got status %d: %s", w.Code, w.Body)
+	}
+	return w.Body.String()
+}
+
+func TestCSRFLoggedInCookieSession(t *testing.T) {
+	c := &client{t: t, handler: csrfServer(scs.New())}
+	token := c.login("")
+
+	for _, tt := range []struct {
+		name   string
+		method string
+		header http.Header
+		want   int
+	}{
+		{"without token", http.MethodPost, http.Header{"Content-Type": {"application/json"}}, http.StatusForbidden},
+		{"wrong token", http.MethodPost, http.Header{CSRFHeader: {token + "x"}}, http.StatusForbidden},
+		{"token", http.MethodPost, http.Header{CSRFHeader: {token}}, http.StatusNoContent},
+		{"delete without token", http.MethodDelete, nil, http.StatusForbidden},
+		{"GET", http.MethodGet, nil, http.StatusNoContent},
+		{"HEAD", http.MethodHead, nil, http.StatusNoContent},
+		{"OPTIONS", http.MethodOptions, nil, http.StatusNoContent},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := c.do(tt.method, "/movies", tt.header); w.Code != tt.want {
+				t.Errorf("got status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSRFTokenRotatesOnLogin(t *testing.T) {
+	c := &client{t: t, handler: csrfServer(scs.New())}
+	first := c.login("")
+	second := c.login(first)
+	if first == second {
+		t.Fatal("second login kept the CSRF token")
+	}
+	if w := c.do(http.MethodPost, "/movies", http.Header{CSRFHeader: {first}}); w.Code != http.StatusForbidden {
+		t.Errorf("token of the first login: got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if w := c.do(http.MethodPost, "/movies", http.Header{CSRFHeader: {second}}); w.Code != http.StatusNoContent {
+		t.Errorf("token of the second login: got status %d, want %d", w.Code, http.StatusNoContent)
+	}
+}
+
+func TestCSRFBearerSession(t *testing.T) {
+	manager := scs.New()
+	ctx, err := manager.Load(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager.Put(ctx, "role", "admin")
+	token, _, err := manager.Commit(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &client{t: t, handler: csrfServer(manager)}
+	if w := c.do(http.MethodPost, "/movies", http.Header{"Authorization": {"Bearer " + token}}); w.Code != http.StatusNoContent {
+		t.Errorf("got status %d, want %d", w.Code, http.StatusNoContent)
+	}
+}
+
+func TestCSRFAnonymousCookieSession(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		contentType string
+		want        int
+	}{
+		{"form", "application/x-www-form-urlencoded", http.StatusForbidden},
+		{"text", "text/plain", http.StatusForbidden},
+		{"no content type", "", http.StatusForbidden},
+		{"JSON", "application/json; charset=utf-8", http.StatusOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &client{t: t, handler: csrfServer(scs.New())}
+			if w := c.do(http.MethodPost, "/login", http.Header{"Content-Type": {tt.contentType}}); w.Code != tt.want {
+				t.Errorf("got status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+
+	t.Run("form with token", func(t *testing.T) {
+		c := &client{t: t, handler: csrfServer(scs.New())}
+		token := c.do(http.MethodGet, "/csrf", nil).Body.String()
+		header := http.Header{"Content-Type": {"application/x-www-form-urlencoded"}, CSRFHeader: {token}}
+		if w := c.do(http.MethodPost, "/login", header); w.Code != http.StatusOK {
+			t.Errorf("got status %d, want %d", w.Code, http.StatusOK)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"filmoteka/internal/metrics"
+	"net/http"
+	"time"
+)
+
+type routeKey struct{}
+
+// Metrics records the count and latency of every request under the pattern
+// of the route that served it, "unmatched" if none did.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		route := "unmatched"
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), routeKey{}, &route)))
+		metrics.ObserveRequest(r.Method, route, rec.status, time.Since(start))
+	})
+}
+
+// Route tells Metrics and Tracing the pattern that next is registered under.
+func Route(pattern string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nameSpan(r, pattern)
+		if route, ok := r.Context().Value(routeKey{}).(*string); ok {
+			*route = pattern
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status, rec.wroteHeader = status, true
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
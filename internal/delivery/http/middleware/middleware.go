@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"errors"
+	"filmoteka/internal/utils"
+	"github.com/alexedwards/scs/v2"
+	"net/http"
+)
+
+// RequireAdmin rejects requests whose session does not carry the admin role.
+func RequireAdmin(manager *scs.SessionManager, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if manager.GetString(r.Context(), "role") != "admin" {
+			utils.ErrorJSON(w, errors.New("unauthorized"), http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	})
+}
+
+// Deprecated marks responses of a legacy query-string endpoint with the
+// Deprecation header and a link to the resource replacing it, the URL of
+// which successor builds from the request.
+func Deprecated(successor func(r *http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor(r)+">; rel=\"successor-version\"")
+		next.ServeHTTP(w, r)
+	})
+}
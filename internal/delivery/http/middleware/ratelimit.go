@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"errors"
+	"filmoteka/internal/ratelimit"
+	"filmoteka/internal/utils"
+	"github.com/alexedwards/scs/v2"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// RateLimiter applies ratelimit policies to routes. Logged-in clients are
+// limited per session, everyone else per IP address.
+type RateLimiter struct {
+	store   ratelimit.Store
+	manager *scs.SessionManager
+}
+
+func NewRateLimiter(store ratelimit.Store, manager *scs.SessionManager) *RateLimiter {
+	return &RateLimiter{
+		store:   store,
+		manager: manager,
+	}
+}
+
+// Limit rejects requests exceeding the policy with 429 Too Many Requests.
+// Every response carries the RateLimit headers of the policy's bucket. If the
+// store fails the request is let through.
+func (l *RateLimiter) Limit(p ratelimit.Policy, next http.Handler) http.Handler {
+	policy := strconv.Itoa(p.Burst) + ";w=" + strconv.FormatFloat(float64(p.Burst)/p.Rate, 'f', 0, 64)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res, err := l.store.Take(r.Context(), p.Name+":"+l.clientKey(r, p), p)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error checking rate limit", "err", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Policy", policy)
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(p.Burst))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(int(res.Reset.Seconds()+0.5)))
+		if !res.Allowed {
+			retry := int(res.RetryAfter.Seconds())
+			if float64(retry) < res.RetryAfter.Seconds() {
+				retry++
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(retry))
+			utils.ErrorJSON(w, errors.New("too many requests"), http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (l *RateLimiter) clientKey(r *http.Request, p ratelimit.Policy) string {
+	if !p.PerIP && l.manager.GetString(r.Context(), "role") != "" {
+		return "session:" + l.manager.Token(r.Context())
+	}
+	return "ip:" + utils.ClientIP(r)
+}
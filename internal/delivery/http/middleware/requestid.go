@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"filmoteka/internal/logging"
+	"filmoteka/internal/utils"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID tags the context of every request with the ID sent by the client
+// or a new one, so that everything logged for the request carries it, and
+// echoes it in the response. Every request is logged once it is served.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !logging.ValidRequestID(id) {
+			id = logging.NewRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		ctx := logging.WithRequestID(r.Context(), id)
+
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		slog.InfoContext(ctx, "Request served",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration", time.Since(start),
+			"ip", utils.ClientIP(r),
+		)
+	})
+}
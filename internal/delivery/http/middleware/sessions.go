@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"filmoteka/internal/utils"
+	"github.com/alexedwards/scs/v2"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type bearerKey struct{}
+
+// Sessions loads the session of a request. Clients sending an
+// Authorization: Bearer header, such as the token returned by the gRPC
+// Login, use that session and their cookies are ignored. Everyone else gets
+// the cookie session of manager.LoadAndSave.
+func Sessions(manager *scs.SessionManager, next http.Handler) http.Handler {
+	cookies := manager.LoadAndSave(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			cookies.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, err := manager.Load(r.Context(), token)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Error loading session", "err", err)
+			utils.ErrorJSON(w, errors.New("error loading session"), http.StatusInternalServerError)
+			return
+		}
+		ctx = context.WithValue(ctx, bearerKey{}, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
+
+		if manager.Status(ctx) == scs.Modified {
+			if _, _, err = manager.Commit(ctx); err != nil {
+				slog.ErrorContext(ctx, "Error saving session", "err", err)
+			}
+		}
+	})
+}
+
+// IsBearer reports whether the session of r was named by a bearer token
+// rather than a cookie.
+func IsBearer(r *http.Request) bool {
+	bearer, _ := r.Context().Value(bearerKey{}).(bool)
+	return bearer
+}
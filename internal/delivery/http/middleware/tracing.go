@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"filmoteka/internal/tracing"
+	"filmoteka/internal/utils"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strings"
+)
+
+// Tracing starts a server span for every request, continuing the trace of
+// the client if the request carries a traceparent header. Route renames the
+// span after the pattern that serves the request.
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, r.Method,
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+			attribute.String("client.address", utils.ClientIP(r)),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			tracing.Fail(span, fmt.Errorf("%d %s", rec.status, http.StatusText(rec.status)))
+		}
+	})
+}
+
+// nameSpan names the span of the request after the method and the path of
+// the route pattern.
+func nameSpan(r *http.Request, pattern string) {
+	path := pattern
+	if _, p, ok := strings.Cut(pattern, " "); ok {
+		path = p
+	}
+	span := trace.SpanFromContext(r.Context())
+	span.SetName(r.Method + " " + path)
+	span.SetAttributes(attribute.String("http.route", path))
+}
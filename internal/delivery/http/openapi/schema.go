@@ -0,0 +1,98 @@
+package openapi
+
+import (
+	"filmoteka/internal/domain/models"
+	"reflect"
+	"strings"
+	"time"
+)
+
+type Schema struct {
+	Ref        string             `json:"$ref,omitempty"`
+	Type       string             `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Enum       []string           `json:"enum,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	AllOf      []*Schema          `json:"allOf,omitempty"`
+	OneOf      []*Schema          `json:"oneOf,omitempty"`
+	Nullable   bool               `json:"nullable,omitempty"`
+}
+
+var (
+	dateType = reflect.TypeOf(models.Date{})
+	timeType = reflect.TypeOf(time.Time{})
+)
+
+// schemaOf derives a schema from the json tags of a Go value, registering
+// named structs as components so the spec follows the handler and model
+// types instead of being maintained by hand.
+func (d *Document) schemaOf(v any) *Schema {
+	return d.schemaFor(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaFor(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case dateType:
+		return &Schema{Type: "string", Format: "date"}
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		return d.component(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (d *Document) component(t reflect.Type) *Schema {
+	ref := &Schema{Ref: "#/components/schemas/" + t.Name()}
+	if t.Name() == "" {
+		return d.structSchema(t)
+	}
+	if _, ok := d.Components.Schemas[t.Name()]; ok {
+		return ref
+	}
+	// reserve the name first so recursive types terminate
+	d.Components.Schemas[t.Name()] = &Schema{}
+	d.Components.Schemas[t.Name()] = d.structSchema(t)
+	return ref
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.Properties[name] = d.schemaFor(f.Type)
+	}
+	return s
+}
@@ -0,0 +1,533 @@
+package openapi
+
+import (
+	"filmoteka/internal/cache"
+	"filmoteka/internal/delivery/http/handlers/actormoviehandlers"
+	"filmoteka/internal/delivery/http/handlers/userhandlers"
+	"filmoteka/internal/delivery/http/middleware"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/health"
+	"filmoteka/internal/utils"
+	"net/http"
+	"strconv"
+)
+
+var adminOnly = []map[string][]string{{"sessionCookie": {}}, {"bearerToken": {}}}
+
+// New describes every route registered by routes.Routes.
+func New() *Document {
+	d := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       "Filmoteka API",
+			Version:     "1.0.0",
+			Description: "Catalog of movies, actors and the credits linking them. Reads are public, writes require an admin session obtained from /login.",
+		},
+		Paths: map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{
+				"sessionCookie": {Type: "apiKey", In: "cookie", Name: "session", Description: "Session cookie set by POST /login, state-changing requests also need the X-CSRF-Token header"},
+				"bearerToken":   {Type: "http", Scheme: "bearer", Description: "Session token returned by the gRPC AuthService.Login"},
+			},
+		},
+	}
+	d.schemaOf(utils.JsonResponse{})
+
+	movie := d.schemaOf(models.Movie{})
+	movies := d.schemaOf([]*models.Movie{})
+	actor := d.schemaOf(models.Actor{})
+	actors := d.schemaOf([]*models.Actor{})
+	actorMovies := d.schemaOf([]*models.ActorMovies{})
+	moviesWithActor := d.schemaOf([]*models.MovieWithActor{})
+
+	movieID := pathParam("id", "Movie ID")
+	actorID := pathParam("id", "Actor ID")
+	sortParam := queryParam("sort", "Sort order of the list", &Schema{Type: "string", Enum: []string{"rating", "date", "title"}})
+	depthParam := queryParam("depth", "With filmographies: 1 returns the cast only, 2 (default) adds the movies of every cast member", &Schema{Type: "integer"})
+	limitParam := queryParam("limit", "With filmographies: maximum number of movies per cast member, newest first", &Schema{Type: "integer"})
+	nameParam := queryParam("name", "Case-insensitive search in title and description", &Schema{Type: "string"})
+
+	d.Paths["/login"] = &PathItem{
+		Post: &Operation{
+			Summary:     "Log in and start a session, repeated failures are delayed and then locked out with 429",
+			Tags:        []string{"auth"},
+			RequestBody: d.jsonBody(userhandlers.LoginRequest{}),
+			Responses:   responses(http.StatusOK, d.envelope("Logged in", d.schemaOf(middleware.CSRFTokenResponse{})), http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError),
+		},
+	}
+
+	d.Paths["/csrf"] = &PathItem{
+		Get: &Operation{
+			Summary:   "CSRF token of the session, to be sent in X-CSRF-Token on state-changing requests",
+			Tags:      []string{"auth"},
+			Responses: responses(http.StatusOK, d.envelope("CSRF token", d.schemaOf(middleware.CSRFTokenResponse{})), http.StatusInternalServerError),
+		},
+	}
+
+	d.Paths["/password-reset"] = &PathItem{
+		Post: &Operation{
+			Summary:     "Mail a single-use password reset token, answers the same whether or not the account exists",
+			Tags:        []string{"auth"},
+			RequestBody: d.jsonBody(userhandlers.PasswordResetRequest{}),
+			Responses:   responses(http.StatusAccepted, d.envelope("If the account exists, a reset mail has been sent", nil), http.StatusBadRequest),
+		},
+	}
+
+	d.Paths["/password-reset/confirm"] = &PathItem{
+		Post: &Operation{
+			Summary:     "Set a new password with a reset token and log the user out of all sessions",
+			Tags:        []string{"auth"},
+			RequestBody: d.jsonBody(userhandlers.PasswordResetConfirm{}),
+			Responses:   responses(http.StatusOK, d.envelope("Password reset", nil), http.StatusBadRequest, http.StatusInternalServerError),
+		},
+	}
+
+	d.Paths["/movies"] = &PathItem{
+		Get: &Operation{
+			Summary:    "List movies, sorted or filtered by name",
+			Tags:       []string{"movies"},
+			Parameters: []*Parameter{sortParam, nameParam},
+			Responses:  responses(http.StatusOK, d.envelope("Movies retrieved", movies), http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+		Post: &Operation{
+			Summary:     "Create a movie",
+			Tags:        []string{"movies"},
+			RequestBody: d.jsonBody(models.Movie{}),
+			Responses:   responses(http.StatusCreated, d.created("Movie created", movie), http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError),
+			Security:    adminOnly,
+		},
+	}
+	d.Paths["/movies/{id}"] = &PathItem{
+		Get: &Operation{
+			Summary:    "Get a movie",
+			Tags:       []string{"movies"},
+			Parameters: []*Parameter{movieID},
+			Responses:  responses(http.StatusOK, d.envelope("Movie retrieved", movie), http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+		Patch: &Operation{
+			Summary:     "Update a movie, empty fields are left unchanged",
+			Tags:        []string{"movies"},
+			Parameters:  []*Parameter{movieID},
+			RequestBody: d.jsonBody(models.Movie{}),
+			Responses:   responses(http.StatusOK, d.envelope("Movie updated", movie), http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError),
+			Security:    adminOnly,
+		},
+		Delete: &Operation{
+			Summary:    "Delete a movie",
+			Tags:       []string{"movies"},
+			Parameters: []*Parameter{movieID},
+			Responses:  responses(http.StatusOK, d.envelope("Movie deleted", nil), http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError),
+			Security:   adminOnly,
+		},
+	}
+	d.Paths["/movies/{id}/actors"] = &PathItem{
+		Get: &Operation{
+			Summary: "List the cast of a movie",
+			Tags:    []string{"credits"},
+			Parameters: []*Parameter{movieID, queryParam("include", "Set to movies to also return the filmography of every cast member",
+				&Schema{Type: "string", Enum: []string{"movies"}}), depthParam, limitParam},
+			Responses: responses(http.StatusOK, d.envelope("Cast retrieved", &Schema{OneOf: []*Schema{actors, actorMovies}}),
+				http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+	}
+	d.Paths["/movies/{id}/similar"] = &PathItem{
+		Get: &Operation{
+			Summary: "Recommend movies sharing cast, era and description terms, best match first",
+			Tags:    []string{"movies"},
+			Parameters: []*Parameter{
+				movieID,
+				queryParam("limit", "Maximum number of recommendations, 10 by default", &Schema{Type: "integer"}),
+			},
+			Responses: responses(http.StatusOK, d.envelope("Similar movies retrieved", d.schemaOf([]*models.SimilarMovie{})), http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+	}
+	addCredit := &Operation{
+		Summary:    "Add an actor to the cast of a movie, 200 if the actor is in the cast already",
+		Tags:       []string{"credits"},
+		Parameters: []*Parameter{movieID, pathParam("actorId", "Actor ID")},
+		Responses:  responses(http.StatusCreated, d.created("Actor added to the movie", actor), http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError),
+		Security:   adminOnly,
+	}
+	addCredit.Responses["200"] = d.envelope("Actor already in the cast", actor)
+	d.Paths["/movies/{id}/actors/{actorId}"] = &PathItem{
+		Put: addCredit,
+		Delete: &Operation{
+			Summary:    "Remove an actor from the cast of a movie",
+			Tags:       []string{"credits"},
+			Parameters: []*Parameter{movieID, pathParam("actorId", "Actor ID")},
+			Responses:  responses(http.StatusOK, d.envelope("Actor removed from the movie", nil), http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError),
+			Security:   adminOnly,
+		},
+	}
+
+	d.Paths["/actors"] = &PathItem{
+		Get: &Operation{
+			Summary:   "List actors",
+			Tags:      []string{"actors"},
+			Responses: responses(http.StatusOK, d.envelope("Actors retrieved", actors), http.StatusNotFound, http.StatusInternalServerError),
+		},
+		Post: &Operation{
+			Summary:     "Create an actor",
+			Tags:        []string{"actors"},
+			RequestBody: d.jsonBody(models.Actor{}),
+			Responses:   responses(http.StatusCreated, d.created("Actor created", actor), http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError),
+			Security:    adminOnly,
+		},
+	}
+	d.Paths["/actors/{id}"] = &PathItem{
+		Get: &Operation{
+			Summary:    "Get an actor",
+			Tags:       []string{"actors"},
+			Parameters: []*Parameter{actorID},
+			Responses:  responses(http.StatusOK, d.envelope("Actor retrieved", actor), http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+		Patch: &Operation{
+			Summary:     "Update an actor, empty fields are left unchanged",
+			Tags:        []string{"actors"},
+			Parameters:  []*Parameter{actorID},
+			RequestBody: d.jsonBody(models.Actor{}),
+			Responses:   responses(http.StatusOK, d.envelope("Actor updated", actor), http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError),
+			Security:    adminOnly,
+		},
+		Delete: &Operation{
+			Summary:    "Delete an actor",
+			Tags:       []string{"actors"},
+			Parameters: []*Parameter{actorID},
+			Responses:  responses(http.StatusOK, d.envelope("Actor deleted", nil), http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError),
+			Security:   adminOnly,
+		},
+	}
+	d.Paths["/actors/{id}/movies"] = &PathItem{
+		Get: &Operation{
+			Summary:    "List the movies of an actor",
+			Tags:       []string{"credits"},
+			Parameters: []*Parameter{actorID},
+			Responses:  responses(http.StatusOK, d.envelope("Movies retrieved", movies), http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+	}
+
+	d.Paths["/actors/{id}/stats"] = &PathItem{
+		Get: &Operation{
+			Summary:    "Career statistics of an actor: yearly releases, ratings, age at release and frequent co-stars",
+			Tags:       []string{"credits"},
+			Parameters: []*Parameter{actorID},
+			Responses:  responses(http.StatusOK, d.envelope("Stats retrieved", d.schemaOf(models.ActorStats{})), http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+	}
+	d.Paths["/actors/{id}/path/{otherId}"] = &PathItem{
+		Get: &Operation{
+			Summary: "Find the shortest co-star chain between two actors",
+			Tags:    []string{"credits"},
+			Parameters: []*Parameter{
+				actorID,
+				pathParam("otherId", "Actor ID at the other end of the chain"),
+				queryParam("maxdepth", "Maximum number of movies in the chain, 6 by default and at most 12", &Schema{Type: "integer"}),
+				queryParam("fromyear", "Only follow movies released in or after this year", &Schema{Type: "integer"}),
+				queryParam("toyear", "Only follow movies released in or before this year", &Schema{Type: "integer"}),
+			},
+			Responses: responses(http.StatusOK, d.envelope("Path found", d.schemaOf(models.ActorPath{})), http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+	}
+
+	d.Paths["/admin/analytics"] = &PathItem{
+		Get: &Operation{
+			Summary:   "Catalog overview: releases per year and decade, rating histogram, credit coverage and growth",
+			Tags:      []string{"admin"},
+			Responses: responses(http.StatusOK, d.envelope("Catalog stats retrieved", d.schemaOf(models.CatalogStats{})), http.StatusUnauthorized, http.StatusInternalServerError),
+			Security:  adminOnly,
+		},
+	}
+
+	d.Paths["/admin/unlock"] = &PathItem{
+		Post: &Operation{
+			Summary:     "Forget the failed logins of an account, a client address or both",
+			Tags:        []string{"admin"},
+			RequestBody: d.jsonBody(userhandlers.UnlockRequest{}),
+			Responses:   responses(http.StatusOK, d.envelope("Login unlocked", nil), http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError),
+			Security:    adminOnly,
+		},
+	}
+
+	d.Paths["/admin/cache"] = &PathItem{
+		Get: &Operation{
+			Summary:   "Hit, miss and eviction counters of the storage read cache",
+			Tags:      []string{"admin"},
+			Responses: responses(http.StatusOK, d.envelope("Cache stats retrieved", d.schemaOf(cache.Stats{})), http.StatusUnauthorized),
+			Security:  adminOnly,
+		},
+	}
+
+	d.Paths["/graphql"] = &PathItem{
+		Post: &Operation{
+			Summary: "GraphQL endpoint over movies, actors and credits, mutations require an admin session",
+			Tags:    []string{"graphql"},
+			RequestBody: &RequestBody{Required: true, Content: map[string]*MediaType{"application/json": {Schema: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"query":         {Type: "string"},
+					"operationName": {Type: "string"},
+					"variables":     {Type: "object"},
+				},
+			}}}},
+			Responses: map[string]*Response{"200": {Description: "GraphQL response with data and errors", Content: map[string]*MediaType{"application/json": {Schema: &Schema{
+				Type: "object",
+				Properties: map[string]*Schema{
+					"data":   {Type: "object"},
+					"errors": {Type: "array", Items: &Schema{Type: "object"}},
+				},
+			}}}}},
+		},
+	}
+
+	// deprecated query-string endpoints
+	d.Paths["/movie"] = &PathItem{
+		Get: &Operation{
+			Summary:    "Get a movie by id, or list movies sorted or filtered by name",
+			Tags:       []string{"deprecated"},
+			Deprecated: true,
+			Parameters: []*Parameter{queryParam("id", "Movie ID", &Schema{Type: "integer"}), sortParam, nameParam},
+			Responses:  responses(http.StatusOK, d.envelope("Movies retrieved", &Schema{OneOf: []*Schema{movie, movies}}), http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+		Post: &Operation{
+			Summary:     "Create a movie",
+			Tags:        []string{"deprecated"},
+			Deprecated:  true,
+			RequestBody: d.jsonBody(models.Movie{}),
+			Responses:   responses(http.StatusCreated, d.created("Movie created", movie), http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError),
+			Security:    adminOnly,
+		},
+		Patch: &Operation{
+			Summary:     "Update the movie identified by movieid in the body",
+			Tags:        []string{"deprecated"},
+			Deprecated:  true,
+			RequestBody: d.jsonBody(models.Movie{}),
+			Responses:   responses(http.StatusOK, d.envelope("Movie updated", movie), http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError),
+			Security:    adminOnly,
+		},
+		Delete: &Operation{
+			Summary:    "Delete a movie",
+			Tags:       []string{"deprecated"},
+			Deprecated: true,
+			Parameters: []*Parameter{requiredQueryParam("id", "Movie ID", &Schema{Type: "integer"})},
+			Responses:  responses(http.StatusCreated, d.envelope("Movie deleted", nil), http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError),
+			Security:   adminOnly,
+		},
+	}
+	d.Paths["/actor"] = &PathItem{
+		Get: &Operation{
+			Summary:    "Get an actor by id, or list all actors",
+			Tags:       []string{"deprecated"},
+			Deprecated: true,
+			Parameters: []*Parameter{queryParam("id", "Actor ID", &Schema{Type: "integer"})},
+			Responses:  responses(http.StatusOK, d.envelope("Actors retrieved", &Schema{OneOf: []*Schema{actor, actors}}), http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+		Post: &Operation{
+			Summary:     "Create an actor",
+			Tags:        []string{"deprecated"},
+			Deprecated:  true,
+			RequestBody: d.jsonBody(models.Actor{}),
+			Responses:   responses(http.StatusCreated, d.created("Actor created", actor), http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError),
+			Security:    adminOnly,
+		},
+		Patch: &Operation{
+			Summary:     "Update the actor identified by actorid in the body",
+			Tags:        []string{"deprecated"},
+			Deprecated:  true,
+			RequestBody: d.jsonBody(models.Actor{}),
+			Responses:   responses(http.StatusOK, d.envelope("Actor updated", actor), http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError),
+			Security:    adminOnly,
+		},
+		Delete: &Operation{
+			Summary:    "Delete an actor",
+			Tags:       []string{"deprecated"},
+			Deprecated: true,
+			Parameters: []*Parameter{requiredQueryParam("id", "Actor ID", &Schema{Type: "integer"})},
+			Responses:  responses(http.StatusCreated, d.envelope("Actor deleted", nil), http.StatusBadRequest, http.StatusUnauthorized, http.StatusNotFound, http.StatusInternalServerError),
+			Security:   adminOnly,
+		},
+	}
+	d.Paths["/movie/actormovie"] = &PathItem{
+		Get: &Operation{
+			Summary:    "Credits lookups selected by action, or movies found by actor name",
+			Tags:       []string{"deprecated"},
+			Deprecated: true,
+			Parameters: []*Parameter{
+				queryParam("action", "getmovies needs actorid, getactors and getactorandmovie need movieid", &Schema{Type: "string", Enum: []string{"getmovies", "getactors", "getactorandmovie"}}),
+				queryParam("actorid", "Actor ID", &Schema{Type: "integer"}),
+				queryParam("movieid", "Movie ID", &Schema{Type: "integer"}),
+				queryParam("firstname", "Part of the actor name, used without action", &Schema{Type: "string"}),
+				queryParam("lastname", "Part of the actor name, used without action", &Schema{Type: "string"}),
+				depthParam,
+				limitParam,
+			},
+			Responses: responses(http.StatusOK, d.envelope("Credits retrieved", &Schema{OneOf: []*Schema{movies, actors, actorMovies, moviesWithActor}}),
+				http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError),
+		},
+		Post: &Operation{
+			Summary:     "Add an actor to the cast of a movie",
+			Tags:        []string{"deprecated"},
+			Deprecated:  true,
+			RequestBody: d.jsonBody(actormoviehandlers.LinkRequest{}),
+			Responses:   responses(http.StatusCreated, d.created("Actor added to the movie", d.schemaOf(actormoviehandlers.LinkRequest{})), http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError),
+			Security:    adminOnly,
+		},
+		Delete: &Operation{
+			Summary:     "Remove an actor from the cast of a movie",
+			Tags:        []string{"deprecated"},
+			Deprecated:  true,
+			RequestBody: d.jsonBody(actormoviehandlers.LinkRequest{}),
+			Responses:   responses(http.StatusOK, d.envelope("Actor removed from the movie", d.schemaOf(actormoviehandlers.LinkRequest{})), http.StatusBadRequest, http.StatusUnauthorized, http.StatusInternalServerError),
+			Security:    adminOnly,
+		},
+	}
+
+	probe := func(summary string, codes ...int) *PathItem {
+		res := map[string]*Response{}
+		for _, code := range codes {
+			res[strconv.Itoa(code)] = &Response{Description: http.StatusText(code) + ", a plain status line or for admins the whole report", Content: map[string]*MediaType{
+				"text/plain":       {Schema: &Schema{Type: "string"}},
+				"application/json": d.envelope("", d.schemaOf(health.Report{})).Content["application/json"],
+			}}
+		}
+		return &PathItem{Get: &Operation{Summary: summary, Tags: []string{"health"}, Responses: res}}
+	}
+	d.Paths["/healthz"] = probe("Liveness probe", http.StatusOK)
+	d.Paths["/readyz"] = probe("Readiness probe: database, schema version and session store, failing while shutting down", http.StatusOK, http.StatusServiceUnavailable)
+
+	d.Paths["/metrics"] = &PathItem{
+		Get: &Operation{
+			Summary:   "Prometheus metrics: request counts and latencies by route, storage call durations, connection pool, sessions and logins",
+			Tags:      []string{"health"},
+			Responses: map[string]*Response{"200": {Description: "Prometheus text exposition format", Content: map[string]*MediaType{"text/plain": {Schema: &Schema{Type: "string"}}}}},
+		},
+	}
+
+	d.Paths["/openapi.json"] = &PathItem{
+		Get: &Operation{
+			Summary:   "This document",
+			Tags:      []string{"docs"},
+			Responses: map[string]*Response{"200": {Description: "OpenAPI document", Content: map[string]*MediaType{"application/json": {Schema: &Schema{Type: "object"}}}}},
+		},
+	}
+	d.Paths["/docs"] = &PathItem{
+		Get: &Operation{
+			Summary:   "Human readable API documentation",
+			Tags:      []string{"docs"},
+			Responses: map[string]*Response{"200": {Description: "HTML page", Content: map[string]*MediaType{"text/html": {Schema: &Schema{Type: "string"}}}}},
+		},
+	}
+
+	d.conditionalGET()
+	d.rateLimited()
+	d.csrfProtected()
+	return d
+}
+
+func pathParam(name, description string) *Parameter {
+	return &Parameter{Name: name, In: "path", Description: description, Required: true, Schema: &Schema{Type: "integer"}}
+}
+
+func queryParam(name, description string, schema *Schema) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func requiredQueryParam(name, description string, schema *Schema) *Parameter {
+	p := queryParam(name, description, schema)
+	p.Required = true
+	return p
+}
+
+func (d *Document) jsonBody(v any) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]*MediaType{"application/json": {Schema: d.schemaOf(v)}}}
+}
+
+// envelope describes a utils.JsonResponse whose data field holds the given schema.
+func (d *Document) envelope(description string, data *Schema) *Response {
+	schema := &Schema{Ref: "#/components/schemas/JsonResponse"}
+	if data != nil {
+		schema = &Schema{AllOf: []*Schema{schema, {Type: "object", Properties: map[string]*Schema{"data": data}}}}
+	}
+	return &Response{Description: description, Content: map[string]*MediaType{"application/json": {Schema: schema}}}
+}
+
+func (d *Document) created(description string, data *Schema) *Response {
+	res := d.envelope(description, data)
+	res.Headers = map[string]*Header{"Location": {Description: "URL of the created resource", Schema: &Schema{Type: "string"}}}
+	return res
+}
+
+// conditionalGET documents the validators middleware.ConditionalGET adds to
+// every successful GET.
+func (d *Document) conditionalGET() {
+	for _, item := range d.Paths {
+		op := item.Get
+		if op == nil {
+			continue
+		}
+		op.Parameters = append(op.Parameters,
+			&Parameter{Name: "If-None-Match", In: "header", Description: "ETag of the cached copy, answered with 304 when it is current", Schema: &Schema{Type: "string"}},
+			&Parameter{Name: "If-Modified-Since", In: "header", Description: "Ignored when If-None-Match is present", Schema: &Schema{Type: "string"}},
+		)
+		if ok := op.Responses["200"]; ok != nil {
+			if ok.Headers == nil {
+				ok.Headers = map[string]*Header{}
+			}
+			ok.Headers["ETag"] = &Header{Description: "Strong validator computed from the response body", Schema: &Schema{Type: "string"}}
+			ok.Headers["Last-Modified"] = &Header{Description: "Last change of the entity or collection, where known", Schema: &Schema{Type: "string"}}
+		}
+		op.Responses["304"] = &Response{Description: "The cached copy is current"}
+	}
+}
+
+// rateLimited documents the 429 response every route can return once the
+// client exhausts its budget.
+func (d *Document) rateLimited() {
+	for _, item := range d.Paths {
+		for _, op := range []*Operation{item.Get, item.Post, item.Put, item.Patch, item.Delete} {
+			if op == nil {
+				continue
+			}
+			op.Responses["429"] = &Response{
+				Description: http.StatusText(http.StatusTooManyRequests),
+				Headers: map[string]*Header{
+					"Retry-After":         {Description: "Seconds until the next request is allowed", Schema: &Schema{Type: "integer"}},
+					"RateLimit-Limit":     {Description: "Size of the request budget", Schema: &Schema{Type: "integer"}},
+					"RateLimit-Remaining": {Description: "Requests left in the budget", Schema: &Schema{Type: "integer"}},
+					"RateLimit-Reset":     {Description: "Seconds until the budget is full again", Schema: &Schema{Type: "integer"}},
+				},
+				Content: map[string]*MediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/JsonResponse"}}},
+			}
+		}
+	}
+}
+
+// responses combines the success response with the error envelopes written by utils.ErrorJSON.
+func responses(status int, success *Response, errorCodes ...int) map[string]*Response {
+	res := map[string]*Response{strconv.Itoa(status): success}
+	for _, code := range errorCodes {
+		res[strconv.Itoa(code)] = &Response{
+			Description: http.StatusText(code),
+			Content:     map[string]*MediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/JsonResponse"}}},
+		}
+	}
+	return res
+}
+
+// csrfProtected documents the CSRF header every state-changing request of a
+// cookie session has to send.
+func (d *Document) csrfProtected() {
+	header := &Parameter{Name: middleware.CSRFHeader, In: "header", Description: "CSRF token from POST /login or GET /csrf, required for cookie sessions unless they are not logged in and send a JSON body", Schema: &Schema{Type: "string"}}
+	for _, item := range d.Paths {
+		for _, op := range []*Operation{item.Post, item.Put, item.Patch, item.Delete} {
+			if op == nil {
+				continue
+			}
+			op.Parameters = append(op.Parameters, header)
+			op.Responses["403"] = &Response{
+				Description: "Missing or invalid CSRF token",
+				Content:     map[string]*MediaType{"application/json": {Schema: &Schema{Ref: "#/components/schemas/JsonResponse"}}},
+			}
+		}
+	}
+}
@@ -1,28 +1,141 @@
 package routes
 
 import (
+	"filmoteka/internal/cache"
 	"filmoteka/internal/delivery/http/handlers/actorhandlers"
 	"filmoteka/internal/delivery/http/handlers/actormoviehandlers"
+	"filmoteka/internal/delivery/http/handlers/analyticshandlers"
+	"filmoteka/internal/delivery/http/handlers/cachehandlers"
+	"filmoteka/internal/delivery/http/handlers/graphqlhandlers"
+	"filmoteka/internal/delivery/http/handlers/healthhandlers"
 	"filmoteka/internal/delivery/http/handlers/moviehandlers"
+	"filmoteka/internal/delivery/http/handlers/recommendationhandlers"
 	"filmoteka/internal/delivery/http/handlers/userhandlers"
+	"filmoteka/internal/delivery/http/middleware"
+	"filmoteka/internal/delivery/http/openapi"
 	"filmoteka/internal/domain/usecase"
+	"filmoteka/internal/health"
+	"filmoteka/internal/metrics"
+	"filmoteka/internal/ratelimit"
 	"github.com/alexedwards/scs/v2"
 	"net/http"
+	"net/url"
 )
 
-func Routes(useCase *usecase.UseCase, manager *scs.SessionManager) http.Handler {
-	mux := http.NewServeMux()
+// router records the registered patterns so they can be checked against the
+// OpenAPI document, and labels the metrics of each request with its pattern.
+type router struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func (r *router) Handle(pattern string, handler http.Handler) {
+	r.patterns = append(r.patterns, pattern)
+	r.ServeMux.Handle(pattern, middleware.Route(pattern, handler))
+}
+
+func (r *router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	r.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// successor links a legacy request to the resource under collection named
+// by its param query parameter, followed by sub, or to the collection
+// itself if the parameter is missing.
+func successor(collection string, param string, sub string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		id := r.URL.Query().Get(param)
+		if id == "" {
+			return collection
+		}
+		return collection + "/" + url.PathEscape(id) + sub
+	}
+}
+
+// creditSuccessor links /movie/actormovie to the cast of the movie or the
+// filmography of the actor it asks for.
+func creditSuccessor(r *http.Request) string {
+	if r.URL.Query().Has("actorid") {
+		return successor("/actors", "actorid", "/movies")(r)
+	}
+	return successor("/movies", "movieid", "/actors")(r)
+}
+
+// Options are the tunable limits of the routes.
+type Options struct {
+	GraphQL    graphqlhandlers.Limits
+	RateLimits RateLimits
+}
+
+// RateLimits are the request budgets. Default applies to every request, the
+// others are taken in addition on the routes they guard.
+type RateLimits struct {
+	Default   ratelimit.Policy
+	Login     ratelimit.Policy
+	Expensive ratelimit.Policy
+}
+
+func Routes(useCase *usecase.UseCase, manager *scs.SessionManager, storageCache cache.Cache, limits ratelimit.Store, cors *middleware.CORS, checker *health.Checker, opts Options) http.Handler {
+	limiter := middleware.NewRateLimiter(limits, manager)
+	mux := newRouter(useCase, manager, storageCache, limiter, checker, opts)
+	return middleware.Tracing(middleware.RequestID(cors.Handler(middleware.Metrics(middleware.Sessions(manager, limiter.Limit(opts.RateLimits.Default, middleware.CSRF(manager, mux)))))))
+}
+
+// newRouter registers the handlers of every route.
+func newRouter(useCase *usecase.UseCase, manager *scs.SessionManager, storageCache cache.Cache, limiter *middleware.RateLimiter, checker *health.Checker, opts Options) *router {
+	mux := &router{ServeMux: http.NewServeMux()}
+
 	userHandler := userhandlers.New(useCase.UserUseCase, manager)
-	mux.Handle("/login", userHandler)
+	mux.Handle("/login", limiter.Limit(opts.RateLimits.Login, userHandler))
+	mux.Handle("POST /admin/unlock", middleware.RequireAdmin(manager, userHandler.Unlock))
+	mux.Handle("POST /password-reset", limiter.Limit(opts.RateLimits.Login, http.HandlerFunc(userHandler.RequestPasswordReset)))
+	mux.HandleFunc("GET /csrf", middleware.CSRFTokenHandler(manager))
+	mux.Handle("POST /password-reset/confirm", limiter.Limit(opts.RateLimits.Login, http.HandlerFunc(userHandler.ResetPassword)))
 
 	actormovieHandler := actormoviehandlers.New(useCase.ActorMovieUseCase, manager)
-	mux.Handle("/movie/actormovie", actormovieHandler)
-
 	actorHandler := actorhandlers.New(useCase.ActorUseCase, manager)
-	mux.Handle("/actor", actorHandler)
-
 	movieHandler := moviehandlers.New(useCase.MovieUseCase, manager)
-	mux.Handle("/movie", movieHandler)
+	recommendationHandler := recommendationhandlers.New(useCase.RecommendationUseCase)
+
+	// resource routes; only JSON resources answer conditional requests
+	mux.Handle("GET /movies", middleware.ConditionalGET(http.HandlerFunc(movieHandler.ListMovies)))
+	mux.Handle("POST /movies", middleware.RequireAdmin(manager, movieHandler.CreateMovie))
+	mux.Handle("GET /movies/{id}", middleware.ConditionalGET(http.HandlerFunc(movieHandler.GetMovie)))
+	mux.Handle("PATCH /movies/{id}", middleware.RequireAdmin(manager, movieHandler.UpdateMovie))
+	mux.Handle("DELETE /movies/{id}", middleware.RequireAdmin(manager, movieHandler.DeleteMovie))
+	mux.Handle("GET /movies/{id}/similar", middleware.ConditionalGET(http.HandlerFunc(recommendationHandler.SimilarMovies)))
+	mux.Handle("GET /movies/{id}/actors", limiter.Limit(opts.RateLimits.Expensive, middleware.ConditionalGET(http.HandlerFunc(actormovieHandler.GetActorsForMovie))))
+	mux.Handle("PUT /movies/{id}/actors/{actorId}", middleware.RequireAdmin(manager, actormovieHandler.AddActorToMovie))
+	mux.Handle("DELETE /movies/{id}/actors/{actorId}", middleware.RequireAdmin(manager, actormovieHandler.DeleteActorFromMovie))
+
+	mux.Handle("GET /actors", middleware.ConditionalGET(http.HandlerFunc(actorHandler.ListActors)))
+	mux.Handle("POST /actors", middleware.RequireAdmin(manager, actorHandler.CreateActor))
+	mux.Handle("GET /actors/{id}", middleware.ConditionalGET(http.HandlerFunc(actorHandler.GetActor)))
+	mux.Handle("PATCH /actors/{id}", middleware.RequireAdmin(manager, actorHandler.UpdateActor))
+	mux.Handle("DELETE /actors/{id}", middleware.RequireAdmin(manager, actorHandler.DeleteActor))
+	mux.Handle("GET /actors/{id}/movies", middleware.ConditionalGET(http.HandlerFunc(actormovieHandler.GetMoviesForActor)))
+	mux.Handle("GET /actors/{id}/stats", middleware.ConditionalGET(http.HandlerFunc(actormovieHandler.GetActorStats)))
+	mux.Handle("GET /actors/{id}/path/{otherId}", limiter.Limit(opts.RateLimits.Expensive, middleware.ConditionalGET(http.HandlerFunc(actormovieHandler.FindActorPath))))
+
+	analyticsHandler := analyticshandlers.New(useCase.AnalyticsUseCase)
+	mux.Handle("GET /admin/analytics", middleware.RequireAdmin(manager, analyticsHandler.GetCatalogStats))
+	cacheHandler := cachehandlers.New(storageCache)
+	mux.Handle("GET /admin/cache", middleware.RequireAdmin(manager, cacheHandler.Stats))
+
+	graphqlHandler := graphqlhandlers.New(useCase.MovieUseCase, useCase.ActorUseCase, useCase.ActorMovieUseCase, manager, opts.GraphQL)
+	mux.Handle("POST /graphql", limiter.Limit(opts.RateLimits.Expensive, graphqlHandler))
+
+	// deprecated query-string endpoints
+	mux.Handle("/movie/actormovie", middleware.Deprecated(creditSuccessor, limiter.Limit(opts.RateLimits.Expensive, middleware.ConditionalGET(actormovieHandler))))
+	mux.Handle("/actor", middleware.Deprecated(successor("/actors", "id", ""), middleware.ConditionalGET(actorHandler)))
+	mux.Handle("/movie", middleware.Deprecated(successor("/movies", "id", ""), middleware.ConditionalGET(movieHandler)))
+
+	healthHandler := healthhandlers.New(checker, manager)
+	mux.HandleFunc("GET /healthz", healthHandler.Healthz)
+	mux.HandleFunc("GET /readyz", healthHandler.Readyz)
+	mux.Handle("GET /metrics", metrics.Handler())
 
-	return manager.LoadAndSave(mux)
+	doc := openapi.New()
+	mux.HandleFunc("GET /openapi.json", openapi.Handler(doc))
+	mux.HandleFunc("GET /docs", openapi.Docs)
+	return mux
 }
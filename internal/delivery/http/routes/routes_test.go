@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"filmoteka/internal/cache"
+	"filmoteka/internal/delivery/http/middleware"
+	"filmoteka/internal/delivery/http/openapi"
+	"filmoteka/internal/domain/usecase"
+	"filmoteka/internal/health"
+	"filmoteka/internal/ratelimit"
+	"github.com/alexedwards/scs/v2"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testOptions = Options{RateLimits: RateLimits{
+	Default:   ratelimit.Policy{Name: "default", Rate: 100, Burst: 100},
+	Login:     ratelimit.Policy{Name: "login", Rate: 100, Burst: 100, PerIP: true},
+	Expensive: ratelimit.Policy{Name: "expensive", Rate: 100, Burst: 100},
+}}
+
+func testRouter() *router {
+	manager := scs.New()
+	limiter := middleware.NewRateLimiter(ratelimit.NewMemoryStore(), manager)
+	return newRouter(&usecase.UseCase{}, manager, cache.NewLRU(1, time.Minute), limiter, health.New(time.Second), testOptions)
+}
+
+func TestRoutesDocumented(t *testing.T) {
+	mux := testRouter()
+
+	if err := openapi.Check(openapi.New(), mux.patterns); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConditionalOnlyOnResources(t *testing.T) {
+	cors, err := middleware.NewCORS(middleware.CORSOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := Routes(&usecase.UseCase{}, scs.New(), cache.NewLRU(1, time.Minute), ratelimit.NewMemoryStore(), cors, health.New(time.Second), testOptions)
+	for _, path := range []string{"/metrics", "/docs", "/openapi.json"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s: status %d", path, rec.Code)
+		}
+		if etag := rec.Header().Get("ETag"); etag != "" {
+			t.Errorf("GET %s: got ETag %s, want none", path, etag)
+		}
+	}
+}
+
+func TestConditionalDoesNotFlush(t *testing.T) {
+	handler := middleware.ConditionalGET(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		if err := http.NewResponseController(w).Flush(); err == nil {
+			t.Error("flushed a buffered response")
+		}
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/movies", nil))
+	if rec.Flushed {
+		t.Error("recorder was flushed")
+	}
+	if rec.Header().Get("ETag") == "" {
+		t.Error("missing ETag")
+	}
+}
@@ -0,0 +1,25 @@
+package events
+
+import "sync"
+
+// Notifier lets usecases announce that the data they manage changed, so that
+// components holding derived data can drop it.
+type Notifier struct {
+	mu        sync.Mutex
+	listeners []func()
+}
+
+func (n *Notifier) Subscribe(fn func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.listeners = append(n.listeners, fn)
+}
+
+func (n *Notifier) Notify() {
+	n.mu.Lock()
+	listeners := n.listeners
+	n.mu.Unlock()
+	for _, fn := range listeners {
+		fn()
+	}
+}
@@ -7,11 +7,34 @@ import (
 	"errors"
 	_ "github.com/jackc/pgx/v5"
 	"golang.org/x/crypto/bcrypt"
-	"log"
+	"log/slog"
 	"time"
 )
 
 var ErrNoRecord = errors.New("no entries found")
+var ErrNoPath = errors.New("no path found within the maximum depth")
+var ErrInvalidCredentials = errors.New("invalid credentials")
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// LoginThrottledError rejects a login attempt made before the delay imposed
+// by earlier failures has passed.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return "too many failed login attempts"
+}
+
+// PasswordPolicyError rejects a new password that breaks the password
+// policy.
+type PasswordPolicyError struct {
+	Reason string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return e.Reason
+}
 
 type Date struct {
 	sql.NullTime
@@ -22,14 +45,14 @@ func (dt *Date) MarshalJSON() ([]byte, error) {
 }
 
 func (dt *Date) UnmarshalJSON(b []byte) (err error) {
+	if string(b) == "null" {
+		dt.Valid = false
+		return
+	}
 	var s string
 	if err = json.Unmarshal(b, &s); err != nil {
 		return err
 	}
-	if s == "null" {
-		dt.Valid = false
-		return
-	}
 	dt.Valid = true
 	dt.Time, err = time.Parse("2006-01-02", s)
 	return
@@ -43,16 +66,26 @@ type Models struct {
 }
 
 type User struct {
+	UserID   int    `json:"-"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
 	Role     string `json:"role"`
 }
 
+// LoginFailures counts the failed logins recorded under a key since the
+// counter was last reset.
+type LoginFailures struct {
+	Failures    int
+	LastFailure time.Time
+}
+
 type Actor struct {
 	ActorID     int    `json:"actorid,omitempty"`
 	Name        string `json:"name,omitempty"`
 	Gender      string `json:"gender,omitempty"`
 	DateOfBirth Date   `json:"dateofbirth,omitempty"`
+
+	UpdatedAt time.Time `json:"-"`
 }
 
 type MovieWithActor struct {
@@ -70,12 +103,149 @@ type ActorMovies struct {
 	Movies  []*Movie
 }
 
+// CastOptions shapes the result of GetActorsAndMoviesForMovie. Depth 1 returns
+// the cast only, depth 2 (the default) adds the movies of every cast member,
+// newest first and at most MoviesPerActor of them when it is positive.
+type CastOptions struct {
+	Depth          int
+	MoviesPerActor int
+}
+
+// PathOptions limits the co-star path search between two actors. Years are
+// inclusive bounds on the release date of the connecting movies, zero means
+// unbounded.
+type PathOptions struct {
+	MaxDepth int
+	FromYear int
+	ToYear   int
+}
+
+// CoStarLink records that Actor and CoStar both appear in Movie.
+type CoStarLink struct {
+	ActorID  int
+	MovieID  int
+	CoStarID int
+}
+
+// PathNode is one element of the alternating actor, movie, actor chain of an
+// ActorPath.
+type PathNode struct {
+	Kind  string `json:"kind"`
+	Actor *Actor `json:"actor,omitempty"`
+	Movie *Movie `json:"movie,omitempty"`
+}
+
+type ActorPath struct {
+	Degrees int         `json:"degrees"`
+	Chain   []*PathNode `json:"chain"`
+}
+
+// ActorStats summarises the career of an actor. MoviesPerYear covers every
+// year from FirstYear to LastYear, including years without releases, and
+// Timeline is ordered by release date, so both can be plotted directly.
+type ActorStats struct {
+	Actor         *Actor         `json:"actor"`
+	MovieCount    int            `json:"moviecount"`
+	FirstYear     int            `json:"firstyear,omitempty"`
+	LastYear      int            `json:"lastyear,omitempty"`
+	AverageRating float64        `json:"averagerating"`
+	BestRated     *Movie         `json:"bestrated,omitempty"`
+	MoviesPerYear []*YearCount   `json:"moviesperyear"`
+	Timeline      []*CareerPoint `json:"timeline"`
+	TopCoStars    []*CoStar      `json:"topcostars"`
+}
+
+type YearCount struct {
+	Year  int `json:"year"`
+	Count int `json:"count"`
+}
+
+// CareerPoint is one release of an actor. Age is omitted when the actor's
+// date of birth is unknown.
+type CareerPoint struct {
+	MovieID     int     `json:"movieid"`
+	Title       string  `json:"Title"`
+	ReleaseDate Date    `json:"releasedate"`
+	Rating      float64 `json:"rating"`
+	Age         *int    `json:"age,omitempty"`
+}
+
+type CoStar struct {
+	ActorID int    `json:"actorid"`
+	Name    string `json:"name"`
+	Movies  int    `json:"movies"`
+}
+
+// CatalogStats is the admin overview of the library. The movie and actor
+// lists are capped, Totals holds the full counts.
+type CatalogStats struct {
+	GeneratedAt         time.Time        `json:"generatedat"`
+	Totals              *CatalogTotals   `json:"totals"`
+	MoviesPerYear       []*YearCount     `json:"moviesperyear"`
+	MoviesPerDecade     []*DecadeCount   `json:"moviesperdecade"`
+	RatingHistogram     []*RatingBucket  `json:"ratinghistogram"`
+	MostCredited        []*CreditedActor `json:"mostcredited"`
+	MoviesWithoutCast   []*Movie         `json:"movieswithoutcast"`
+	ActorsWithoutMovies []*Actor         `json:"actorswithoutmovies"`
+	Growth              []*GrowthPoint   `json:"growth"`
+}
+
+type CatalogTotals struct {
+	Movies              int `json:"movies"`
+	Actors              int `json:"actors"`
+	Credits             int `json:"credits"`
+	MoviesWithoutCast   int `json:"movieswithoutcast"`
+	ActorsWithoutMovies int `json:"actorswithoutmovies"`
+}
+
+type DecadeCount struct {
+	Decade int `json:"decade"`
+	Count  int `json:"count"`
+}
+
+// RatingBucket counts movies rated in [Min, Max), the last bucket includes
+// its upper bound.
+type RatingBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+type CreditedActor struct {
+	ActorID int    `json:"actorid"`
+	Name    string `json:"name"`
+	Movies  int    `json:"movies"`
+}
+
+// GrowthPoint holds the movies and actors added in a month and the size of
+// the catalog at its end.
+type GrowthPoint struct {
+	Month       string `json:"month"`
+	Movies      int    `json:"movies"`
+	Actors      int    `json:"actors"`
+	TotalMovies int    `json:"totalmovies"`
+	TotalActors int    `json:"totalactors"`
+}
+
+// SimilarMovie is a recommendation for another movie. Score is the weighted
+// sum of the cast, era and text components, each in the range [0, 1].
+type SimilarMovie struct {
+	Movie        *Movie  `json:"movie"`
+	Score        float64 `json:"score"`
+	CastScore    float64 `json:"castscore"`
+	EraScore     float64 `json:"erascore"`
+	TextScore    float64 `json:"textscore"`
+	SharedActors int     `json:"sharedactors"`
+}
+
 type Movie struct {
 	MovieID     int     `json:"movieid,omitempty"`
 	Title       string  `json:"Title"`
 	Description string  `json:"description"`
 	Rating      float64 `json:"rating"`
 	ReleaseDate Date    `json:"releasedate"`
+
+	UpdatedAt time.Time `json:"-"`
 }
 
 var db *sql.DB
@@ -104,13 +274,13 @@ func (u *User) GetByEmail() (password string, role string, err error) {
 	query := `SELECT password, role FROM users WHERE email = $1`
 	_, err = db.QueryContext(ctx, query, u.Email)
 	if err != nil {
-		log.Println("Error getting user by email from the table", err)
+		slog.Error("Error getting user by email from the table", "err", err)
 		return "", "", err
 	}
 
 	err = db.QueryRowContext(ctx, query, u.Email).Scan(&password, &role)
 	if err != nil {
-		log.Println("Error getting user by email from the table", err)
+		slog.Error("Error getting user by email from the table", "err", err)
 		return "", "", err
 	}
 
@@ -128,13 +298,13 @@ func (a *Actor) GetAll() ([]*Actor, error) {
 
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
-		log.Println("Error getting all actors from the table", err)
+		slog.Error("Error getting all actors from the table", "err", err)
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.Error("Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -149,14 +319,14 @@ func (a *Actor) GetAll() ([]*Actor, error) {
 			&actor.DateOfBirth,
 		)
 		if err != nil {
-			log.Println("Error scanning actor rows", err)
+			slog.Error("Error scanning actor rows", "err", err)
 			return nil, err
 		}
 
 		actors = append(actors, &actor)
 	}
 	if len(actors) < 1 {
-		log.Println("No actors found in the table")
+		slog.Debug("No actors found in the table")
 		return nil, ErrNoRecord
 	}
 
@@ -172,9 +342,7 @@ func (a *Actor) Create() (*Actor, error) {
 	values ($1, $2, $3)`
 	_, err := db.ExecContext(ctx, query, a.Name, a.Gender, a.DateOfBirth)
 	if err != nil {
-		log.Println("Error inserting actor into a table", err)
-		encoder, _ := json.Marshal(a)
-		log.Println("Actor: ", string(encoder))
+		slog.Error("Error inserting actor into a table", "err", err)
 		return nil, err
 	}
 	return a, nil
@@ -186,14 +354,14 @@ func (a *Actor) GetByID() (*Actor, error) {
 	query := `SELECT actorid, name, gender, dateofbirth FROM actors WHERE actorid = $1`
 	rows, err := db.QueryContext(ctx, query, a.ActorID)
 	if err != nil {
-		log.Println("Error getting actor by id from the table", err)
+		slog.Error("Error getting actor by id from the table", "err", err)
 		return nil, err
 	}
 
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.Error("Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -206,7 +374,7 @@ func (a *Actor) GetByID() (*Actor, error) {
 			&actor.DateOfBirth,
 		)
 		if err != nil {
-			log.Println("Error scanning actor rows", err)
+			slog.Error("Error scanning actor rows", "err", err)
 			return nil, err
 		}
 	}
@@ -215,28 +383,22 @@ func (a *Actor) GetByID() (*Actor, error) {
 	if !actor.DateOfBirth.Valid {
 		return nil, errors.New("no actor found")
 	}
-	log.Println("Actor: ", actor.ActorID, actor.DateOfBirth.Time, actor.DateOfBirth.Valid)
 	return actor, nil
 }
 
 func (a *Actor) Update() (*Actor, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
-	log.Println(a.DateOfBirth)
 	formattedDOB, _ := json.Marshal(a.DateOfBirth.Time)
-	log.Println("Formatted DOB: ", string(formattedDOB))
 	query := `UPDATE actors SET name = CASE WHEN $1 = '' THEN name ELSE COALESCE($1) END, gender = CASE WHEN $2 = '' THEN gender ELSE COALESCE($2) END, dateofbirth = CASE WHEN $3 < '1000-1-1' THEN dateofbirth ELSE CAST(COALESCE($3) AS DATE) END WHERE actorid = ($4)`
 	_, err := db.ExecContext(ctx, query, a.Name, a.Gender, formattedDOB, a.ActorID)
 	if err != nil {
-		log.Println("Error updating actor in the table", err)
-		encoder, _ := json.Marshal(a)
-		log.Println("Actor: ", string(encoder))
+		slog.Error("Error updating actor in the table", "err", err)
 		return nil, err
 	}
 	res, err := a.GetByID()
-	log.Println("Updated actor: ", res)
 	if err != nil {
-		log.Println("Error error returning updated actor from the table", err)
+		slog.Error("Error error returning updated actor from the table", "err", err)
 		return nil, err
 	}
 	return res, nil
@@ -248,7 +410,7 @@ func (a *Actor) Delete() error {
 	query := `DELETE FROM actors WHERE actorid = $1`
 	_, err := db.ExecContext(ctx, query, a.ActorID)
 	if err != nil {
-		log.Println("Error deleting actor from the table", err)
+		slog.Error("Error deleting actor from the table", "err", err)
 		return err
 	}
 	return nil
@@ -271,18 +433,18 @@ func (m *Movie) GetAll(sortParam string) ([]*Movie, error) {
 	case "":
 		query = `SELECT movieid, title, description, rating, releasedate FROM movies ORDER BY rating DESC`
 	default:
-		log.Println("Invalid sort parameter")
+		slog.Info("Invalid sort parameter", "sort", sortParam)
 		return nil, errors.New("invalid sort parameter")
 	}
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
-		log.Println("Error getting all movies from the table", err)
+		slog.Error("Error getting all movies from the table", "err", err)
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.Error("Error closing rows", "err", err)
 		}
 	}(rows)
 	var movies []*Movie
@@ -297,14 +459,14 @@ func (m *Movie) GetAll(sortParam string) ([]*Movie, error) {
 			&movie.ReleaseDate,
 		)
 		if err != nil {
-			log.Println("Error scanning movie rows", err)
+			slog.Error("Error scanning movie rows", "err", err)
 			return nil, err
 		}
 
 		movies = append(movies, &movie)
 	}
 	if len(movies) < 1 {
-		log.Println("No movies found in the table")
+		slog.Debug("No movies found in the table")
 		return nil, ErrNoRecord
 	}
 	return movies, nil
@@ -316,14 +478,14 @@ func (m *Movie) GetByID() (*Movie, error) {
 	query := `SELECT movieid, title, description, rating, releasedate FROM movies WHERE movieid = $1`
 	rows, err := db.QueryContext(ctx, query, m.MovieID)
 	if err != nil {
-		log.Println("Error getting movie by id from the table", err)
+		slog.Error("Error getting movie by id from the table", "err", err)
 		return nil, err
 	}
 
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.Error("Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -337,7 +499,7 @@ func (m *Movie) GetByID() (*Movie, error) {
 			&movie.ReleaseDate,
 		)
 		if err != nil {
-			log.Println("Error scanning movie rows", err)
+			slog.Error("Error scanning movie rows", "err", err)
 			return nil, err
 		}
 	}
@@ -345,7 +507,6 @@ func (m *Movie) GetByID() (*Movie, error) {
 	if !movie.ReleaseDate.Valid {
 		return nil, errors.New("no movie found")
 	}
-	log.Println("Movie: ", movie)
 	return movie, nil
 }
 
@@ -357,9 +518,7 @@ func (m *Movie) Create() (*Movie, error) {
 	values ($1, $2, $3, $4)`
 	_, err := db.ExecContext(ctx, query, m.Title, m.Description, m.Rating, m.ReleaseDate)
 	if err != nil {
-		log.Println("Error inserting movie into a table", err)
-		encoder, _ := json.Marshal(m)
-		log.Println("Movie: ", string(encoder))
+		slog.Error("Error inserting movie into a table", "err", err)
 		return nil, err
 	}
 	return m, nil
@@ -369,19 +528,15 @@ func (m *Movie) Update() (*Movie, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
 	defer cancel()
 	formattedRD, _ := json.Marshal(m.ReleaseDate.Time)
-	log.Println("Formatted DOB: ", string(formattedRD))
 	query := `UPDATE movies SET title = CASE WHEN $1 = '' THEN title ELSE COALESCE($1) END, description = CASE WHEN $2 = '' THEN description ELSE COALESCE($2) END, rating = CASE WHEN $3 = 0 THEN rating ELSE COALESCE($3) END, releasedate = CASE WHEN $4 < '1000-1-1' THEN releasedate ELSE CAST(COALESCE($4) AS DATE) END WHERE movieid = ($5)`
 	_, err := db.ExecContext(ctx, query, m.Title, m.Description, m.Rating, formattedRD, m.MovieID)
 	if err != nil {
-		log.Println("Error updating movie in the table", err)
-		encoder, _ := json.Marshal(m)
-		log.Println("Movie: ", string(encoder))
+		slog.Error("Error updating movie in the table", "err", err)
 		return nil, err
 	}
 	res, err := m.GetByID()
-	log.Println("Updated movie: ", res)
 	if err != nil {
-		log.Println("Error returning updated movie from the table ", err)
+		slog.Error("Error returning updated movie from the table", "err", err)
 		return nil, err
 	}
 	return res, nil
@@ -393,7 +548,7 @@ func (m *Movie) Delete() error {
 	query := `DELETE FROM movies WHERE movieid = $1`
 	_, err := db.ExecContext(ctx, query, m.MovieID)
 	if err != nil {
-		log.Println("Error deleting movie from the table", err)
+		slog.Error("Error deleting movie from the table", "err", err)
 		return err
 	}
 	return nil
@@ -411,13 +566,13 @@ WHERE a.name ILIKE  $1 OR a.name ILIKE $2;`
 
 	rows, err := db.QueryContext(ctx, query, "%"+name+"%", "%"+surname+"%")
 	if err != nil {
-		log.Println("Error getting movies by actor name from the table", err)
+		slog.Error("Error getting movies by actor name from the table", "err", err)
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.Error("Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -434,14 +589,14 @@ WHERE a.name ILIKE  $1 OR a.name ILIKE $2;`
 			&movie.ActorName,
 		)
 		if err != nil {
-			log.Println("Error scanning actor rows", err)
+			slog.Error("Error scanning actor rows", "err", err)
 			return nil, err
 		}
 
 		movies = append(movies, &movie)
 	}
 	if len(movies) < 1 {
-		log.Println("No movies found in the table")
+		slog.Debug("No movies found in the table")
 		return nil, ErrNoRecord
 	}
 
@@ -456,13 +611,13 @@ func (m *Movie) GetByMovieName(moviename string) ([]*Movie, error) {
 
 	rows, err := db.QueryContext(ctx, query, "%"+moviename+"%", "%"+moviename+"%")
 	if err != nil {
-		log.Println("Error getting movies by movie name from the table", err)
+		slog.Error("Error getting movies by movie name from the table", "err", err)
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.Error("Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -478,7 +633,7 @@ func (m *Movie) GetByMovieName(moviename string) ([]*Movie, error) {
 			&movie.ReleaseDate,
 		)
 		if err != nil {
-			log.Println("Error scanning actor rows", err)
+			slog.Error("Error scanning actor rows", "err", err)
 			return nil, err
 		}
 
@@ -486,7 +641,7 @@ func (m *Movie) GetByMovieName(moviename string) ([]*Movie, error) {
 	}
 
 	if len(movies) < 1 {
-		log.Println("No movies found in the table")
+		slog.Debug("No movies found in the table")
 		return nil, ErrNoRecord
 	}
 	return movies, nil
@@ -500,13 +655,13 @@ func (m *Movie) GetActorsForMovie() ([]*Actor, error) {
 
 	rows, err := db.QueryContext(ctx, query, m.MovieID)
 	if err != nil {
-		log.Println("Error getting actors for movie from the table", err)
+		slog.Error("Error getting actors for movie from the table", "err", err)
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.Error("Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -518,7 +673,7 @@ func (m *Movie) GetActorsForMovie() ([]*Actor, error) {
 			&actor.Name,
 		)
 		if err != nil {
-			log.Println("Error scanning actor rows", err)
+			slog.Error("Error scanning actor rows", "err", err)
 			return nil, err
 		}
 
@@ -536,13 +691,13 @@ func (m *Movie) GetMoviesForActor(actorid int) ([]*Movie, error) {
 
 	rows, err := db.QueryContext(ctx, query, actorid)
 	if err != nil {
-		log.Println("Error getting movies for actor from the table", err)
+		slog.Error("Error getting movies for actor from the table", "err", err)
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.Error("Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -557,7 +712,7 @@ func (m *Movie) GetMoviesForActor(actorid int) ([]*Movie, error) {
 			&movie.ReleaseDate,
 		)
 		if err != nil {
-			log.Println("Error scanning movie rows", err)
+			slog.Error("Error scanning movie rows", "err", err)
 			return nil, err
 		}
 
@@ -571,14 +726,14 @@ func (m *Movie) GetMoviesForActor(actorid int) ([]*Movie, error) {
 func (m *Movie) GetActorsAndMoviesForMovie() ([]*ActorMovies, error) {
 	actors, err := m.GetActorsForMovie()
 	if err != nil {
-		log.Println("Error getting actors for movie", err)
+		slog.Error("Error getting actors for movie", "err", err)
 		return nil, err
 	}
 	var result []*ActorMovies
 	for _, actor := range actors {
 		movies, err := m.GetMoviesForActor(actor.ActorID)
 		if err != nil {
-			log.Println("Error getting movies for actor", err)
+			slog.Error("Error getting movies for actor", "err", err)
 			return nil, err
 		}
 		result = append(result, &ActorMovies{
@@ -597,18 +752,18 @@ func (m *Movie) AddActorToMovie(actorid int, movieid int) error {
 	m.MovieID = movieid
 	_, err := a.GetByID()
 	if err != nil {
-		log.Println("Error getting actor by id from the table")
+		slog.Error("Error getting actor by id from the table", "err", err)
 		return err
 	}
 	_, err = m.GetByID()
 	if err != nil {
-		log.Println("Error getting movie by id from the table")
+		slog.Error("Error getting movie by id from the table", "err", err)
 		return err
 	}
 	query := `INSERT INTO actormovie (actorid, movieid) VALUES ($1, $2)`
 	_, err = db.ExecContext(ctx, query, actorid, movieid)
 	if err != nil {
-		log.Println("Error adding actor to movie in the table", err)
+		slog.Error("Error adding actor to movie in the table", "err", err)
 		return err
 	}
 	return nil
@@ -621,18 +776,18 @@ func (m *Movie) DeleteActorFromMovie(actorid int, movieid int) error {
 	m.MovieID = movieid
 	_, err := a.GetByID()
 	if err != nil {
-		log.Println("Error getting actor by id from the table")
+		slog.Error("Error getting actor by id from the table", "err", err)
 		return err
 	}
 	_, err = m.GetByID()
 	if err != nil {
-		log.Println("Error getting movie by id from the table")
+		slog.Error("Error getting movie by id from the table", "err", err)
 		return err
 	}
 	query := `DELETE FROM actormovie WHERE actorid = $1 AND movieid = $2`
 	_, err = db.ExecContext(ctx, query, actorid, movieid)
 	if err != nil {
-		log.Println("Error deleting actor from movie in the table", err)
+		slog.Error("Error deleting actor from movie in the table", "err", err)
 		return err
 	}
 	return nil
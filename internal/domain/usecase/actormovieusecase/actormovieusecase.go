@@ -1,20 +1,30 @@
 package actormovieusecase
 
 import (
+	"context"
+	"filmoteka/internal/domain/events"
 	"filmoteka/internal/domain/models"
+	"filmoteka/internal/tracing"
 )
 
 type ActorMovieUseCase struct {
 	storage ActorMovieStorage
+	changes events.Notifier
 }
 
 type ActorMovieStorage interface {
-	GetActorsForMovie(id int) ([]*models.Actor, *models.Movie, error)
-	GetMoviesForActor(actorid int) ([]*models.Movie, *models.Actor, error)
-	GetActorsAndMoviesForMovie(id int) ([]*models.ActorMovies, *models.Movie, error)
-	AddActorToMovie(actorid int, movieid int) (*models.Actor, *models.Movie, error)
-	DeleteActorFromMovie(actorid int, movieid int) (*models.Actor, *models.Movie, error)
-	GetMovieByActorName(name string, surname string) ([]*models.MovieWithActor, error)
+	GetActorsForMovie(ctx context.Context, id int) ([]*models.Actor, *models.Movie, error)
+	GetMoviesForActor(ctx context.Context, actorid int) ([]*models.Movie, *models.Actor, error)
+	GetActorsAndMoviesForMovie(ctx context.Context, id int, opts models.CastOptions) ([]*models.ActorMovies, *models.Movie, error)
+	AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error)
+	DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error)
+	GetMovieByActorName(ctx context.Context, name string, surname string) ([]*models.MovieWithActor, error)
+	GetActorsForMovies(ctx context.Context, movieids []int) (map[int][]*models.Actor, error)
+	GetMoviesForActors(ctx context.Context, actorids []int) (map[int][]*models.Movie, error)
+	GetCoStars(ctx context.Context, actorids []int, opts models.PathOptions) ([]*models.CoStarLink, error)
+	GetTopCoStars(ctx context.Context, actorid int, limit int) ([]*models.CoStar, error)
+	GetActorByID(ctx context.Context, id int) (*models.Actor, error)
+	GetMovieByID(ctx context.Context, id int) (*models.Movie, error)
 }
 
 func New(storage ActorMovieStorage) *ActorMovieUseCase {
@@ -23,26 +33,73 @@ func New(storage ActorMovieStorage) *ActorMovieUseCase {
 	}
 }
 
-func (uc *ActorMovieUseCase) GetActorsForMovie(id int) ([]*models.Actor, *models.Movie, error) {
-	return uc.storage.GetActorsForMovie(id)
+func (uc *ActorMovieUseCase) GetActorsForMovie(ctx context.Context, id int) ([]*models.Actor, *models.Movie, error) {
+	ctx, span := tracing.Start(ctx, "ActorMovieUseCase.GetActorsForMovie")
+	defer span.End()
+
+	return uc.storage.GetActorsForMovie(ctx, id)
+}
+
+func (uc *ActorMovieUseCase) GetMoviesForActor(ctx context.Context, actorid int) ([]*models.Movie, *models.Actor, error) {
+	ctx, span := tracing.Start(ctx, "ActorMovieUseCase.GetMoviesForActor")
+	defer span.End()
+
+	return uc.storage.GetMoviesForActor(ctx, actorid)
+}
+
+func (uc *ActorMovieUseCase) GetActorsAndMoviesForMovie(ctx context.Context, id int, opts models.CastOptions) ([]*models.ActorMovies, *models.Movie, error) {
+	ctx, span := tracing.Start(ctx, "ActorMovieUseCase.GetActorsAndMoviesForMovie")
+	defer span.End()
+
+	return uc.storage.GetActorsAndMoviesForMovie(ctx, id, opts)
+}
+
+// AddActorToMovie credits the actor in the movie, reporting whether the
+// credit is new.
+func (uc *ActorMovieUseCase) AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error) {
+	ctx, span := tracing.Start(ctx, "ActorMovieUseCase.AddActorToMovie")
+	defer span.End()
+
+	actor, movie, created, err := uc.storage.AddActorToMovie(ctx, actorid, movieid)
+	if created {
+		uc.changes.Notify()
+	}
+	return actor, movie, created, err
 }
 
-func (uc *ActorMovieUseCase) GetMoviesForActor(actorid int) ([]*models.Movie, *models.Actor, error) {
-	return uc.storage.GetMoviesForActor(actorid)
+func (uc *ActorMovieUseCase) DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error) {
+	ctx, span := tracing.Start(ctx, "ActorMovieUseCase.DeleteActorFromMovie")
+	defer span.End()
+
+	actor, movie, err := uc.storage.DeleteActorFromMovie(ctx, actorid, movieid)
+	if err == nil {
+		uc.changes.Notify()
+	}
+	return actor, movie, err
 }
 
-func (uc *ActorMovieUseCase) GetActorsAndMoviesForMovie(id int) ([]*models.ActorMovies, *models.Movie, error) {
-	return uc.storage.GetActorsAndMoviesForMovie(id)
+func (uc *ActorMovieUseCase) GetMovieByActorName(ctx context.Context, name string, surname string) ([]*models.MovieWithActor, error) {
+	ctx, span := tracing.Start(ctx, "ActorMovieUseCase.GetMovieByActorName")
+	defer span.End()
+
+	return uc.storage.GetMovieByActorName(ctx, name, surname)
 }
 
-func (uc *ActorMovieUseCase) AddActorToMovie(actorid int, movieid int) (*models.Actor, *models.Movie, error) {
-	return uc.storage.AddActorToMovie(actorid, movieid)
+func (uc *ActorMovieUseCase) GetActorsForMovies(ctx context.Context, movieids []int) (map[int][]*models.Actor, error) {
+	ctx, span := tracing.Start(ctx, "ActorMovieUseCase.GetActorsForMovies")
+	defer span.End()
+
+	return uc.storage.GetActorsForMovies(ctx, movieids)
 }
 
-func (uc *ActorMovieUseCase) DeleteActorFromMovie(actorid int, movieid int) (*models.Actor, *models.Movie, error) {
-	return uc.storage.DeleteActorFromMovie(actorid, movieid)
+func (uc *ActorMovieUseCase) GetMoviesForActors(ctx context.Context, actorids []int) (map[int][]*models.Movie, error) {
+	ctx, span := tracing.Start(ctx, "ActorMovieUseCase.GetMoviesForActors")
+	defer span.End()
+
+	return uc.storage.GetMoviesForActors(ctx, actorids)
 }
 
-func (uc *ActorMovieUseCase) GetMovieByActorName(name string, surname string) ([]*models.MovieWithActor, error) {
-	return uc.storage.GetMovieByActorName(name, surname)
+// OnChange registers fn to be called after every successful write.
+func (uc *ActorMovieUseCase) OnChange(fn func()) {
+	uc.changes.Subscribe(fn)
 }
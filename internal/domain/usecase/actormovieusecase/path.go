@@ -0,0 +1,131 @@
+package actormovieusecase
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/tracing"
+	"sort"
+)
+
+const (
+	DefaultPathDepth = 6
+	MaxPathDepth     = 12
+)
+
+// hop is how an actor was reached during the path search: through movie,
+// from the neighbouring actor on the side the search started from, dist
+// hops away from that side's end.
+type hop struct {
+	actorid int
+	movieid int
+	dist    int
+}
+
+// FindActorPath returns a shortest co-star chain between two actors. The
+// search runs breadth-first from both ends, always expanding the smaller
+// frontier, with one storage query per expanded level.
+func (uc *ActorMovieUseCase) FindActorPath(ctx context.Context, from int, to int, opts models.PathOptions) (*models.ActorPath, error) {
+	ctx, span := tracing.Start(ctx, "ActorMovieUseCase.FindActorPath")
+	defer span.End()
+
+	if opts.MaxDepth < 1 {
+		opts.MaxDepth = DefaultPathDepth
+	}
+	if opts.MaxDepth > MaxPathDepth {
+		opts.MaxDepth = MaxPathDepth
+	}
+
+	start, err := uc.storage.GetActorByID(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = uc.storage.GetActorByID(ctx, to); err != nil {
+		return nil, err
+	}
+	if from == to {
+		return &models.ActorPath{Chain: []*models.PathNode{{Kind: "actor", Actor: start}}}, nil
+	}
+
+	// reached[i] maps every visited actor to the hop it was reached by, the
+	// start actors are reached by a zero hop
+	reached := [2]map[int]hop{{from: {}}, {to: {}}}
+	frontier := [2][]int{{from}, {to}}
+
+	for depth := 0; depth < opts.MaxDepth && len(frontier[0]) > 0 && len(frontier[1]) > 0; depth++ {
+		side := 0
+		if len(frontier[1]) < len(frontier[0]) {
+			side = 1
+		}
+		other := 1 - side
+
+		links, err := uc.storage.GetCoStars(ctx, frontier[side], opts)
+		if err != nil {
+			return nil, err
+		}
+
+		var next, meetings []int
+		for _, link := range links {
+			if _, ok := reached[side][link.CoStarID]; ok {
+				continue
+			}
+			reached[side][link.CoStarID] = hop{actorid: link.ActorID, movieid: link.MovieID, dist: reached[side][link.ActorID].dist + 1}
+			next = append(next, link.CoStarID)
+			if _, ok := reached[other][link.CoStarID]; ok {
+				meetings = append(meetings, link.CoStarID)
+			}
+		}
+
+		if len(meetings) > 0 {
+			// the meetings of one level can still differ by a hop on the
+			// other side, keep the shortest and then the lowest actor id
+			sort.Slice(meetings, func(i, j int) bool {
+				di := reached[0][meetings[i]].dist + reached[1][meetings[i]].dist
+				dj := reached[0][meetings[j]].dist + reached[1][meetings[j]].dist
+				if di != dj {
+					return di < dj
+				}
+				return meetings[i] < meetings[j]
+			})
+			return uc.buildPath(ctx, meetings[0], from, to, reached)
+		}
+		frontier[side] = next
+	}
+
+	return nil, models.ErrNoPath
+}
+
+// buildPath walks back from the meeting actor to both ends of the search and
+// loads the actors and movies of the chain.
+func (uc *ActorMovieUseCase) buildPath(ctx context.Context, meeting int, from int, to int, reached [2]map[int]hop) (*models.ActorPath, error) {
+	// actor ids at even positions, movie ids at odd positions
+	var ids []int
+	for actor := meeting; actor != from; {
+		h := reached[0][actor]
+		ids = append([]int{h.actorid, h.movieid}, ids...)
+		actor = h.actorid
+	}
+	ids = append(ids, meeting)
+	for actor := meeting; actor != to; {
+		h := reached[1][actor]
+		ids = append(ids, h.movieid, h.actorid)
+		actor = h.actorid
+	}
+
+	path := &models.ActorPath{Degrees: len(ids) / 2}
+	for i, id := range ids {
+		if i%2 == 0 {
+			actor, err := uc.storage.GetActorByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			path.Chain = append(path.Chain, &models.PathNode{Kind: "actor", Actor: actor})
+		} else {
+			movie, err := uc.storage.GetMovieByID(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			path.Chain = append(path.Chain, &models.PathNode{Kind: "movie", Movie: movie})
+		}
+	}
+	return path, nil
+}
@@ -0,0 +1,112 @@
+package actormovieusecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/storage/memorystorage"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// pathCatalog links actor 1 to actor 4 directly through movie 8 of 2015,
+// through actors 2 and 3 in the movies 1 to 3 of the 1990s, and through
+// actors 5, 6 and 7 in the movies 4 to 7 of the 2000s. Actor 9 played
+// alone.
+func pathCatalog(t *testing.T) *ActorMovieUseCase {
+	years := map[int]int{1: 1990, 2: 1993, 3: 1995, 4: 2001, 5: 2002, 6: 2003, 7: 2004, 8: 2015, 9: 2000}
+	casts := map[int][]int{1: {1, 2}, 2: {2, 3}, 3: {3, 4}, 4: {1, 5}, 5: {5, 6}, 6: {6, 7}, 7: {7, 4}, 8: {1, 4}, 9: {9}}
+
+	f := &memorystorage.Fixtures{}
+	for id := 1; id <= 9; id++ {
+		f.Movies = append(f.Movies, models.Movie{MovieID: id, Title: fmt.Sprintf("movie %d", id), Rating: 5, ReleaseDate: date(years[id])})
+		f.Actors = append(f.Actors, models.Actor{ActorID: id, Name: fmt.Sprintf("actor %d", id), Gender: "female", DateOfBirth: date(1960)})
+		for _, actor := range casts[id] {
+			f.Credits = append(f.Credits, memorystorage.FixtureCredit{ActorID: actor, MovieID: id})
+		}
+	}
+	s := memorystorage.New()
+	if err := s.Seed(f); err != nil {
+		t.Fatal(err)
+	}
+	return New(s)
+}
+
+func date(year int) models.Date {
+	return models.Date{NullTime: sql.NullTime{Time: time.Date(year, time.June, 1, 0, 0, 0, 0, time.UTC), Valid: true}}
+}
+
+// chain lists the path as "a1 m8 a4".
+func chain(path *models.ActorPath) []string {
+	var list []string
+	for _, node := range path.Chain {
+		if node.Kind == "actor" {
+			list = append(list, fmt.Sprintf("a%d", node.Actor.ActorID))
+		} else {
+			list = append(list, fmt.Sprintf("m%d", node.Movie.MovieID))
+		}
+	}
+	return list
+}
+
+func TestFindActorPath(t *testing.T) {
+	uc := pathCatalog(t)
+
+	tests := []struct {
+		name     string
+		from, to int
+		opts     models.PathOptions
+		want     []string
+	}{
+		{"shortest", 1, 4, models.PathOptions{}, []string{"a1", "m8", "a4"}},
+		{"reversed", 4, 1, models.PathOptions{}, []string{"a4", "m8", "a1"}},
+		{"before the direct movie", 1, 4, models.PathOptions{ToYear: 2010}, []string{"a1", "m1", "a2", "m2", "a3", "m3", "a4"}},
+		{"in the 2000s", 1, 4, models.PathOptions{FromYear: 1996, ToYear: 2010}, []string{"a1", "m4", "a5", "m5", "a6", "m6", "a7", "m7", "a4"}},
+		{"across both chains", 2, 6, models.PathOptions{}, []string{"a2", "m1", "a1", "m4", "a5", "m5", "a6"}},
+		{"between years", 2, 6, models.PathOptions{FromYear: 1993, ToYear: 2004}, []string{"a2", "m2", "a3", "m3", "a4", "m7", "a7", "m6", "a6"}},
+		{"within the depth", 1, 4, models.PathOptions{FromYear: 1996, ToYear: 2010, MaxDepth: 4}, []string{"a1", "m4", "a5", "m5", "a6", "m6", "a7", "m7", "a4"}},
+		{"same actor", 3, 3, models.PathOptions{}, []string{"a3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := uc.FindActorPath(context.Background(), tt.from, tt.to, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := chain(path); !slices.Equal(got, tt.want) {
+				t.Errorf("got chain %v, want %v", got, tt.want)
+			}
+			if path.Degrees != len(tt.want)/2 {
+				t.Errorf("got %d degrees, want %d", path.Degrees, len(tt.want)/2)
+			}
+		})
+	}
+}
+
+func TestFindActorPathErrors(t *testing.T) {
+	uc := pathCatalog(t)
+
+	tests := []struct {
+		name     string
+		from, to int
+		opts     models.PathOptions
+		want     error
+	}{
+		{"beyond the depth", 1, 4, models.PathOptions{FromYear: 1996, ToYear: 2010, MaxDepth: 3}, models.ErrNoPath},
+		{"filtered out", 1, 4, models.PathOptions{FromYear: 2005, ToYear: 2010}, models.ErrNoPath},
+		{"unconnected", 1, 9, models.PathOptions{}, models.ErrNoPath},
+		{"missing source", 99, 1, models.PathOptions{}, models.ErrNoRecord},
+		{"missing target", 1, 99, models.PathOptions{}, models.ErrNoRecord},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.FindActorPath(context.Background(), tt.from, tt.to, tt.opts)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package actormovieusecase
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/tracing"
+	"math"
+	"sort"
+	"time"
+)
+
+// TopCoStars is the number of co-stars reported in actor statistics.
+const TopCoStars = 10
+
+// GetActorStats builds the career summary of an actor from their filmography.
+// Movies without a release date count towards the totals and the rating but
+// are left out of the yearly figures.
+func (uc *ActorMovieUseCase) GetActorStats(ctx context.Context, actorid int) (*models.ActorStats, error) {
+	ctx, span := tracing.Start(ctx, "ActorMovieUseCase.GetActorStats")
+	defer span.End()
+
+	movies, actor, err := uc.storage.GetMoviesForActor(ctx, actorid)
+	if err != nil {
+		return nil, err
+	}
+	costars, err := uc.storage.GetTopCoStars(ctx, actorid, TopCoStars)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(movies, func(i, j int) bool {
+		return movies[i].ReleaseDate.Time.Before(movies[j].ReleaseDate.Time)
+	})
+
+	stats := &models.ActorStats{
+		Actor:         actor,
+		MovieCount:    len(movies),
+		MoviesPerYear: []*models.YearCount{},
+		Timeline:      make([]*models.CareerPoint, 0, len(movies)),
+		TopCoStars:    costars,
+	}
+	if stats.TopCoStars == nil {
+		stats.TopCoStars = []*models.CoStar{}
+	}
+
+	perYear := make(map[int]int)
+	var total float64
+	for _, m := range movies {
+		total += m.Rating
+		if stats.BestRated == nil || m.Rating > stats.BestRated.Rating {
+			stats.BestRated = m
+		}
+		if !m.ReleaseDate.Valid {
+			continue
+		}
+
+		year := m.ReleaseDate.Time.Year()
+		if stats.FirstYear == 0 || year < stats.FirstYear {
+			stats.FirstYear = year
+		}
+		if year > stats.LastYear {
+			stats.LastYear = year
+		}
+		perYear[year]++
+
+		point := &models.CareerPoint{
+			MovieID:     m.MovieID,
+			Title:       m.Title,
+			ReleaseDate: m.ReleaseDate,
+			Rating:      m.Rating,
+		}
+		if actor.DateOfBirth.Valid {
+			age := ageAt(actor.DateOfBirth.Time, m.ReleaseDate.Time)
+			point.Age = &age
+		}
+		stats.Timeline = append(stats.Timeline, point)
+	}
+	if len(movies) > 0 {
+		stats.AverageRating = math.Round(total/float64(len(movies))*100) / 100
+	}
+	if stats.FirstYear != 0 {
+		for year := stats.FirstYear; year <= stats.LastYear; year++ {
+			stats.MoviesPerYear = append(stats.MoviesPerYear, &models.YearCount{Year: year, Count: perYear[year]})
+		}
+	}
+	return stats, nil
+}
+
+// ageAt returns the age in whole years of someone born at birth on the day t.
+func ageAt(birth time.Time, t time.Time) int {
+	age := t.Year() - birth.Year()
+	if t.Month() < birth.Month() || (t.Month() == birth.Month() && t.Day() < birth.Day()) {
+		age--
+	}
+	return age
+}
@@ -1,11 +1,16 @@
 package actorusecase
 
 import (
+	"context"
+	"filmoteka/internal/domain/events"
 	"filmoteka/internal/domain/models"
+	"filmoteka/internal/tracing"
+	"time"
 )
 
 type ActorUseCase struct {
 	storage ActorStorage
+	changes events.Notifier
 }
 
 func New(storage ActorStorage) *ActorUseCase {
@@ -15,29 +20,61 @@ func New(storage ActorStorage) *ActorUseCase {
 }
 
 type ActorStorage interface {
-	GetAllActors() ([]*models.Actor, error)
-	CreateActor(a *models.Actor) (*models.Actor, error)
-	GetActorByID(id int) (*models.Actor, error)
-	UpdateActor(a *models.Actor) (*models.Actor, error)
-	DeleteActor(id int) error
+	GetAllActors(ctx context.Context) ([]*models.Actor, error)
+	CreateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	GetActorByID(ctx context.Context, id int) (*models.Actor, error)
+	UpdateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	DeleteActor(ctx context.Context, id int) error
+	GetActorsModifiedAt(ctx context.Context) (time.Time, error)
 }
 
-func (uc *ActorUseCase) GetAllActors() ([]*models.Actor, error) {
-	return uc.storage.GetAllActors()
+func (uc *ActorUseCase) GetAllActors(ctx context.Context) ([]*models.Actor, error) {
+	ctx, span := tracing.Start(ctx, "ActorUseCase.GetAllActors")
+	defer span.End()
+
+	return uc.storage.GetAllActors(ctx)
+}
+
+func (uc *ActorUseCase) CreateActor(ctx context.Context, a *models.Actor) (*models.Actor, error) {
+	ctx, span := tracing.Start(ctx, "ActorUseCase.CreateActor")
+	defer span.End()
+
+	return uc.storage.CreateActor(ctx, a)
 }
 
-func (uc *ActorUseCase) CreateActor(a *models.Actor) (*models.Actor, error) {
-	return uc.storage.CreateActor(a)
+func (uc *ActorUseCase) GetActorByID(ctx context.Context, id int) (*models.Actor, error) {
+	ctx, span := tracing.Start(ctx, "ActorUseCase.GetActorByID")
+	defer span.End()
+
+	return uc.storage.GetActorByID(ctx, id)
 }
 
-func (uc *ActorUseCase) GetActorByID(id int) (*models.Actor, error) {
-	return uc.storage.GetActorByID(id)
+func (uc *ActorUseCase) UpdateActor(ctx context.Context, a *models.Actor) (*models.Actor, error) {
+	ctx, span := tracing.Start(ctx, "ActorUseCase.UpdateActor")
+	defer span.End()
+
+	return uc.storage.UpdateActor(ctx, a)
 }
 
-func (uc *ActorUseCase) UpdateActor(a *models.Actor) (*models.Actor, error) {
-	return uc.storage.UpdateActor(a)
+func (uc *ActorUseCase) DeleteActor(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "ActorUseCase.DeleteActor")
+	defer span.End()
+
+	err := uc.storage.DeleteActor(ctx, id)
+	if err == nil {
+		uc.changes.Notify()
+	}
+	return err
+}
+
+func (uc *ActorUseCase) GetActorsModifiedAt(ctx context.Context) (time.Time, error) {
+	ctx, span := tracing.Start(ctx, "ActorUseCase.GetActorsModifiedAt")
+	defer span.End()
+
+	return uc.storage.GetActorsModifiedAt(ctx)
 }
 
-func (uc *ActorUseCase) DeleteActor(id int) error {
-	return uc.storage.DeleteActor(id)
+// OnChange registers fn to be called after every successful write.
+func (uc *ActorUseCase) OnChange(fn func()) {
+	uc.changes.Subscribe(fn)
 }
@@ -0,0 +1,123 @@
+package analyticsusecase
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/tracing"
+	"sync"
+	"time"
+)
+
+const (
+	// ListLimit caps the actor and movie lists of the report.
+	ListLimit = 100
+
+	ratingBuckets = 10
+)
+
+type AnalyticsUseCase struct {
+	storage analyticsStorage
+	ttl     time.Duration
+
+	mu     sync.Mutex
+	report *models.CatalogStats
+}
+
+type analyticsStorage interface {
+	GetTotals(ctx context.Context) (*models.CatalogTotals, error)
+	GetMoviesPerYear(ctx context.Context) ([]*models.YearCount, error)
+	GetRatingCounts(ctx context.Context) (map[int]int, error)
+	GetMostCreditedActors(ctx context.Context, limit int) ([]*models.CreditedActor, error)
+	GetMoviesWithoutCast(ctx context.Context, limit int) ([]*models.Movie, error)
+	GetActorsWithoutMovies(ctx context.Context, limit int) ([]*models.Actor, error)
+	GetMonthlyGrowth(ctx context.Context) ([]*models.GrowthPoint, error)
+}
+
+// New returns a usecase that reuses a computed report for ttl. A zero ttl
+// computes the report on every call.
+func New(storage analyticsStorage, ttl time.Duration) *AnalyticsUseCase {
+	return &AnalyticsUseCase{
+		storage: storage,
+		ttl:     ttl,
+	}
+}
+
+// GetCatalogStats returns the cached report while it is younger than the TTL.
+// Concurrent callers wait for a single computation.
+func (uc *AnalyticsUseCase) GetCatalogStats(ctx context.Context) (*models.CatalogStats, error) {
+	ctx, span := tracing.Start(ctx, "AnalyticsUseCase.GetCatalogStats")
+	defer span.End()
+
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+
+	if uc.report != nil && time.Since(uc.report.GeneratedAt) < uc.ttl {
+		return uc.report, nil
+	}
+	report, err := uc.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	uc.report = report
+	return report, nil
+}
+
+func (uc *AnalyticsUseCase) compute(ctx context.Context) (*models.CatalogStats, error) {
+	report := &models.CatalogStats{GeneratedAt: time.Now()}
+
+	var err error
+	if report.Totals, err = uc.storage.GetTotals(ctx); err != nil {
+		return nil, err
+	}
+	if report.MoviesPerYear, err = uc.storage.GetMoviesPerYear(ctx); err != nil {
+		return nil, err
+	}
+	if report.MostCredited, err = uc.storage.GetMostCreditedActors(ctx, ListLimit); err != nil {
+		return nil, err
+	}
+	if report.MoviesWithoutCast, err = uc.storage.GetMoviesWithoutCast(ctx, ListLimit); err != nil {
+		return nil, err
+	}
+	if report.ActorsWithoutMovies, err = uc.storage.GetActorsWithoutMovies(ctx, ListLimit); err != nil {
+		return nil, err
+	}
+	if report.Growth, err = uc.storage.GetMonthlyGrowth(ctx); err != nil {
+		return nil, err
+	}
+	ratings, err := uc.storage.GetRatingCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report.MoviesPerDecade = []*models.DecadeCount{}
+	for _, year := range report.MoviesPerYear {
+		decade := year.Year - year.Year%10
+		if n := len(report.MoviesPerDecade); n > 0 && report.MoviesPerDecade[n-1].Decade == decade {
+			report.MoviesPerDecade[n-1].Count += year.Count
+			continue
+		}
+		report.MoviesPerDecade = append(report.MoviesPerDecade, &models.DecadeCount{Decade: decade, Count: year.Count})
+	}
+
+	report.RatingHistogram = make([]*models.RatingBucket, ratingBuckets)
+	for i := range report.RatingHistogram {
+		report.RatingHistogram[i] = &models.RatingBucket{Min: float64(i), Max: float64(i + 1), Count: ratings[i]}
+	}
+
+	if report.MoviesPerYear == nil {
+		report.MoviesPerYear = []*models.YearCount{}
+	}
+	if report.MostCredited == nil {
+		report.MostCredited = []*models.CreditedActor{}
+	}
+	if report.MoviesWithoutCast == nil {
+		report.MoviesWithoutCast = []*models.Movie{}
+	}
+	if report.ActorsWithoutMovies == nil {
+		report.ActorsWithoutMovies = []*models.Actor{}
+	}
+	if report.Growth == nil {
+		report.Growth = []*models.GrowthPoint{}
+	}
+	return report, nil
+}
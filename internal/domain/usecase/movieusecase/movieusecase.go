@@ -1,11 +1,16 @@
 package movieusecase
 
 import (
+	"context"
+	"filmoteka/internal/domain/events"
 	"filmoteka/internal/domain/models"
+	"filmoteka/internal/tracing"
+	"time"
 )
 
 type MovieUseCase struct {
 	storage movieStorage
+	changes events.Notifier
 }
 
 func New(movieStorage movieStorage) *MovieUseCase {
@@ -15,35 +20,78 @@ func New(movieStorage movieStorage) *MovieUseCase {
 }
 
 type movieStorage interface {
-	GetAllMovies(param string) ([]*models.Movie, error)
-	CreateMovie(m *models.Movie) (*models.Movie, error)
-	GetMovieByID(id int) (*models.Movie, error)
-	UpdateMovie(m *models.Movie) (*models.Movie, error)
-	DeleteMovie(id int) error
+	GetAllMovies(ctx context.Context, param string) ([]*models.Movie, error)
+	CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	GetMovieByID(ctx context.Context, id int) (*models.Movie, error)
+	UpdateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	DeleteMovie(ctx context.Context, id int) error
 
-	GetMovieByMovieName(moviename string) ([]*models.Movie, error)
+	GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error)
+	GetMoviesModifiedAt(ctx context.Context) (time.Time, error)
 }
 
-func (uc *MovieUseCase) GetAllMovies(param string) ([]*models.Movie, error) {
-	return uc.storage.GetAllMovies(param)
+func (uc *MovieUseCase) GetAllMovies(ctx context.Context, param string) ([]*models.Movie, error) {
+	ctx, span := tracing.Start(ctx, "MovieUseCase.GetAllMovies")
+	defer span.End()
+
+	return uc.storage.GetAllMovies(ctx, param)
+}
+
+func (uc *MovieUseCase) CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error) {
+	ctx, span := tracing.Start(ctx, "MovieUseCase.CreateMovie")
+	defer span.End()
+
+	movie, err := uc.storage.CreateMovie(ctx, m)
+	if err == nil {
+		uc.changes.Notify()
+	}
+	return movie, err
+}
+
+func (uc *MovieUseCase) GetMovieByID(ctx context.Context, id int) (*models.Movie, error) {
+	ctx, span := tracing.Start(ctx, "MovieUseCase.GetMovieByID")
+	defer span.End()
+
+	return uc.storage.GetMovieByID(ctx, id)
 }
 
-func (uc *MovieUseCase) CreateMovie(m *models.Movie) (*models.Movie, error) {
-	return uc.storage.CreateMovie(m)
+func (uc *MovieUseCase) UpdateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error) {
+	ctx, span := tracing.Start(ctx, "MovieUseCase.UpdateMovie")
+	defer span.End()
+
+	movie, err := uc.storage.UpdateMovie(ctx, m)
+	if err == nil {
+		uc.changes.Notify()
+	}
+	return movie, err
 }
 
-func (uc *MovieUseCase) GetMovieByID(id int) (*models.Movie, error) {
-	return uc.storage.GetMovieByID(id)
+func (uc *MovieUseCase) DeleteMovie(ctx context.Context, id int) error {
+	ctx, span := tracing.Start(ctx, "MovieUseCase.DeleteMovie")
+	defer span.End()
+
+	err := uc.storage.DeleteMovie(ctx, id)
+	if err == nil {
+		uc.changes.Notify()
+	}
+	return err
 }
 
-func (uc *MovieUseCase) UpdateMovie(m *models.Movie) (*models.Movie, error) {
-	return uc.storage.UpdateMovie(m)
+func (uc *MovieUseCase) GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error) {
+	ctx, span := tracing.Start(ctx, "MovieUseCase.GetMovieByMovieName")
+	defer span.End()
+
+	return uc.storage.GetMovieByMovieName(ctx, moviename)
 }
 
-func (uc *MovieUseCase) DeleteMovie(id int) error {
-	return uc.storage.DeleteMovie(id)
+func (uc *MovieUseCase) GetMoviesModifiedAt(ctx context.Context) (time.Time, error) {
+	ctx, span := tracing.Start(ctx, "MovieUseCase.GetMoviesModifiedAt")
+	defer span.End()
+
+	return uc.storage.GetMoviesModifiedAt(ctx)
 }
 
-func (uc *MovieUseCase) GetMovieByMovieName(moviename string) ([]*models.Movie, error) {
-	return uc.storage.GetMovieByMovieName(moviename)
+// OnChange registers fn to be called after every successful write.
+func (uc *MovieUseCase) OnChange(fn func()) {
+	uc.changes.Subscribe(fn)
 }
@@ -0,0 +1,243 @@
+package recommendationusecase
+
+import (
+	"context"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/tracing"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	castWeight = 0.5
+	eraWeight  = 0.2
+	textWeight = 0.3
+
+	// eraScale is the distance in years at which the era score drops to 1/e.
+	eraScale = 10.0
+
+	// maxResults bounds the number of recommendations kept per movie.
+	maxResults = 50
+)
+
+type RecommendationUseCase struct {
+	movies   movieStorage
+	credits  creditStorage
+	indexTTL time.Duration
+
+	mu      sync.Mutex
+	index   *index
+	results map[int][]*models.SimilarMovie
+	// building is closed when the rebuild in flight, if any, ends
+	building chan struct{}
+	// generation counts invalidations, an index built across one is stale
+	generation int
+}
+
+type movieStorage interface {
+	GetAllMovies(ctx context.Context, param string) ([]*models.Movie, error)
+}
+
+type creditStorage interface {
+	GetAllCredits(ctx context.Context) (map[int][]int, error)
+}
+
+// New recommends from a snapshot of the catalog trusted for indexTTL. Writes
+// made through this process invalidate it immediately, the TTL only catches
+// changes made elsewhere.
+func New(movies movieStorage, credits creditStorage, indexTTL time.Duration) *RecommendationUseCase {
+	return &RecommendationUseCase{
+		movies:   movies,
+		credits:  credits,
+		indexTTL: indexTTL,
+	}
+}
+
+// index is a snapshot of everything the scoring needs.
+type index struct {
+	built  time.Time
+	movies []*models.Movie
+	byID   map[int]*models.Movie
+	cast   map[int]map[int]bool
+	terms  map[int]vector
+}
+
+// Invalidate drops the catalog snapshot and all computed recommendations.
+func (uc *RecommendationUseCase) Invalidate() {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	uc.index = nil
+	uc.results = nil
+	uc.generation++
+}
+
+// SimilarMovies returns up to limit movies ranked by similarity to the movie
+// with the given id.
+func (uc *RecommendationUseCase) SimilarMovies(ctx context.Context, id int, limit int) ([]*models.SimilarMovie, error) {
+	ctx, span := tracing.Start(ctx, "RecommendationUseCase.SimilarMovies")
+	defer span.End()
+
+	idx, err := uc.currentIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := idx.byID[id]; !ok {
+		return nil, models.ErrNoRecord
+	}
+
+	// results belong to the current index, which may have changed since
+	uc.mu.Lock()
+	similar, ok := uc.results[id]
+	ok = ok && uc.index == idx
+	uc.mu.Unlock()
+	if !ok {
+		similar = idx.rank(id)
+		uc.mu.Lock()
+		if uc.index == idx {
+			uc.results[id] = similar
+		}
+		uc.mu.Unlock()
+	}
+
+	if limit > 0 && limit < len(similar) {
+		similar = similar[:limit]
+	}
+	return similar, nil
+}
+
+// currentIndex returns the catalog snapshot, rebuilding it if it is missing
+// or expired. The rebuild runs without holding the lock, concurrent callers
+// wait for it instead of starting their own.
+func (uc *RecommendationUseCase) currentIndex(ctx context.Context) (*index, error) {
+	for {
+		uc.mu.Lock()
+		if uc.index != nil && time.Since(uc.index.built) <= uc.indexTTL {
+			idx := uc.index
+			uc.mu.Unlock()
+			return idx, nil
+		}
+		if building := uc.building; building != nil {
+			uc.mu.Unlock()
+			select {
+			case <-building:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		building := make(chan struct{})
+		uc.building = building
+		generation := uc.generation
+		uc.mu.Unlock()
+
+		idx, err := uc.buildIndex(ctx)
+
+		uc.mu.Lock()
+		uc.building = nil
+		close(building)
+		// an index built across an invalidation still answers this call,
+		// but is not kept
+		if err == nil && generation == uc.generation {
+			uc.index = idx
+			uc.results = make(map[int][]*models.SimilarMovie)
+		}
+		uc.mu.Unlock()
+		return idx, err
+	}
+}
+
+func (uc *RecommendationUseCase) buildIndex(ctx context.Context) (*index, error) {
+	movies, err := uc.movies.GetAllMovies(ctx, "")
+	if err != nil && !errors.Is(err, models.ErrNoRecord) {
+		return nil, err
+	}
+	credits, err := uc.credits.GetAllCredits(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := &index{
+		built:  time.Now(),
+		movies: movies,
+		byID:   make(map[int]*models.Movie, len(movies)),
+		cast:   make(map[int]map[int]bool, len(credits)),
+	}
+	docs := make(map[int][]string, len(movies))
+	for _, m := range movies {
+		idx.byID[m.MovieID] = m
+		docs[m.MovieID] = tokenize(m.Title + " " + m.Description)
+	}
+	for movieid, actorids := range credits {
+		set := make(map[int]bool, len(actorids))
+		for _, actorid := range actorids {
+			set[actorid] = true
+		}
+		idx.cast[movieid] = set
+	}
+	idx.terms = tfidf(docs)
+	return idx, nil
+}
+
+func (idx *index) rank(id int) []*models.SimilarMovie {
+	target := idx.byID[id]
+
+	var similar []*models.SimilarMovie
+	for _, m := range idx.movies {
+		if m.MovieID == id {
+			continue
+		}
+		shared, cast := jaccard(idx.cast[id], idx.cast[m.MovieID])
+		era := eraSimilarity(target.ReleaseDate, m.ReleaseDate)
+		text := idx.terms[id].cosine(idx.terms[m.MovieID])
+		if shared == 0 && text == 0 {
+			continue
+		}
+		similar = append(similar, &models.SimilarMovie{
+			Movie:        m,
+			Score:        round(castWeight*cast + eraWeight*era + textWeight*text),
+			CastScore:    round(cast),
+			EraScore:     round(era),
+			TextScore:    round(text),
+			SharedActors: shared,
+		})
+	}
+
+	sort.SliceStable(similar, func(i, j int) bool {
+		if similar[i].Score != similar[j].Score {
+			return similar[i].Score > similar[j].Score
+		}
+		return similar[i].Movie.MovieID < similar[j].Movie.MovieID
+	})
+	if len(similar) > maxResults {
+		similar = similar[:maxResults]
+	}
+	return similar
+}
+
+func jaccard(a, b map[int]bool) (int, float64) {
+	if len(a) == 0 || len(b) == 0 {
+		return 0, 0
+	}
+	shared := 0
+	for actorid := range a {
+		if b[actorid] {
+			shared++
+		}
+	}
+	return shared, float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+func eraSimilarity(a, b models.Date) float64 {
+	if !a.Valid || !b.Valid {
+		return 0
+	}
+	years := math.Abs(float64(a.Time.Year() - b.Time.Year()))
+	return math.Exp(-years / eraScale)
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
@@ -0,0 +1,66 @@
+package recommendationusecase
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// catalog serves two movies sharing an actor. Loads block until release is
+// closed.
+type catalog struct {
+	loads   atomic.Int32
+	release chan struct{}
+}
+
+func (c *catalog) GetAllMovies(ctx context.Context, param string) ([]*models.Movie, error) {
+	c.loads.Add(1)
+	<-c.release
+	return []*models.Movie{
+		{MovieID: 1, Title: "Solaris", Description: "ocean planet"},
+		{MovieID: 2, Title: "Stalker", Description: "the zone"},
+	}, nil
+}
+
+func (c *catalog) GetAllCredits(ctx context.Context) (map[int][]int, error) {
+	return map[int][]int{1: {7}, 2: {7}}, nil
+}
+
+func TestSimilarMoviesSharesRebuild(t *testing.T) {
+	c := &catalog{release: make(chan struct{})}
+	uc := New(c, c, time.Hour)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			similar, err := uc.SimilarMovies(context.Background(), 1, 0)
+			if err != nil || len(similar) != 1 || similar[0].Movie.MovieID != 2 {
+				t.Errorf("got %v, %v, want movie 2", similar, err)
+			}
+		}()
+	}
+	for c.loads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	// the other calls are waiting for the rebuild, not holding the lock
+	uc.Invalidate()
+	close(c.release)
+	wg.Wait()
+
+	// the index built across the invalidation is not kept, one waiting
+	// call rebuilds it for the others
+	if n := c.loads.Load(); n != 2 {
+		t.Errorf("catalog loaded %d times, want 2", n)
+	}
+	if _, err := uc.SimilarMovies(context.Background(), 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := c.loads.Load(); n != 2 {
+		t.Errorf("catalog loaded %d times after the rebuild, want 2", n)
+	}
+}
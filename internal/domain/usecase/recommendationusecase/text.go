@@ -0,0 +1,85 @@
+package recommendationusecase
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// vector is an L2-normalised sparse TF-IDF vector.
+type vector map[string]float64
+
+func (v vector) cosine(o vector) float64 {
+	if len(o) < len(v) {
+		v, o = o, v
+	}
+	var dot float64
+	for term, w := range v {
+		dot += w * o[term]
+	}
+	return dot
+}
+
+var stopwords = map[string]bool{
+	"and": true, "are": true, "but": true, "for": true, "from": true,
+	"has": true, "have": true, "her": true, "his": true, "into": true,
+	"its": true, "not": true, "one": true, "that": true, "the": true,
+	"their": true, "them": true, "they": true, "this": true, "was": true,
+	"were": true, "when": true, "where": true, "which": true, "who": true,
+	"will": true, "with": true, "after": true, "about": true, "while": true,
+}
+
+func tokenize(s string) []string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := words[:0]
+	for _, w := range words {
+		if len([]rune(w)) < 3 || stopwords[w] {
+			continue
+		}
+		terms = append(terms, w)
+	}
+	return terms
+}
+
+// tfidf weighs every document's terms by their frequency in the document and
+// their rarity across the catalog. Terms found in every document carry no
+// weight.
+func tfidf(docs map[int][]string) map[int]vector {
+	df := make(map[string]int)
+	for _, terms := range docs {
+		seen := make(map[string]bool, len(terms))
+		for _, term := range terms {
+			if !seen[term] {
+				seen[term] = true
+				df[term]++
+			}
+		}
+	}
+
+	n := float64(len(docs))
+	vectors := make(map[int]vector, len(docs))
+	for id, terms := range docs {
+		v := make(vector, len(terms))
+		for _, term := range terms {
+			v[term]++
+		}
+		var norm float64
+		for term, tf := range v {
+			w := tf * math.Log(n/float64(df[term]))
+			if w == 0 {
+				delete(v, term)
+				continue
+			}
+			v[term] = w
+			norm += w * w
+		}
+		norm = math.Sqrt(norm)
+		for term := range v {
+			v[term] /= norm
+		}
+		vectors[id] = v
+	}
+	return vectors
+}
@@ -3,7 +3,9 @@ package usecase
 import (
 	"filmoteka/internal/domain/usecase/actormovieusecase"
 	"filmoteka/internal/domain/usecase/actorusecase"
+	"filmoteka/internal/domain/usecase/analyticsusecase"
 	"filmoteka/internal/domain/usecase/movieusecase"
+	"filmoteka/internal/domain/usecase/recommendationusecase"
 	"filmoteka/internal/domain/usecase/userusecase"
 )
 
@@ -12,6 +14,9 @@ type UseCase struct {
 	MovieUseCase      *movieusecase.MovieUseCase
 	ActorMovieUseCase *actormovieusecase.ActorMovieUseCase
 	ActorUseCase      *actorusecase.ActorUseCase
+
+	RecommendationUseCase *recommendationusecase.RecommendationUseCase
+	AnalyticsUseCase      *analyticsusecase.AnalyticsUseCase
 }
 
 //func New(storage storage) *UseCase {
@@ -0,0 +1,46 @@
+package userusecase
+
+import (
+	"filmoteka/internal/domain/models"
+	"time"
+)
+
+// LockoutPolicy throttles failed logins. After Free failures every further
+// attempt has to wait BaseDelay, doubling with each failure up to MaxDelay.
+// After LockAfter failures the key is locked for LockFor. Counters reset
+// after ResetAfter without failures, on successful login (accounts only) or
+// by an admin.
+type LockoutPolicy struct {
+	Account    Limits
+	IP         Limits
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	LockFor    time.Duration
+	ResetAfter time.Duration
+}
+
+type Limits struct {
+	Free      int
+	LockAfter int
+}
+
+// wait returns how long the key with failures f has to wait before the next
+// attempt.
+func (p LockoutPolicy) wait(f *models.LoginFailures, limits Limits, now time.Time) time.Duration {
+	if f.Failures <= limits.Free {
+		return 0
+	}
+	since := now.Sub(f.LastFailure)
+	if f.Failures >= limits.LockAfter {
+		return p.LockFor - since
+	}
+	if since >= p.ResetAfter {
+		return 0
+	}
+
+	delay := p.MaxDelay
+	if n := f.Failures - limits.Free - 1; n < 16 {
+		delay = min(p.BaseDelay<<n, p.MaxDelay)
+	}
+	return delay - since
+}
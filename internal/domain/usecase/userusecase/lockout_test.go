@@ -0,0 +1,52 @@
+package userusecase
+
+import (
+	"filmoteka/internal/domain/models"
+	"testing"
+	"time"
+)
+
+func TestLockoutWait(t *testing.T) {
+	policy := LockoutPolicy{
+		BaseDelay:  time.Second,
+		MaxDelay:   time.Minute,
+		LockFor:    15 * time.Minute,
+		ResetAfter: 10 * time.Minute,
+	}
+	account := Limits{Free: 3, LockAfter: 10}
+	ip := Limits{Free: 10, LockAfter: 50}
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		limits   Limits
+		failures int
+		ago      time.Duration
+		want     time.Duration
+	}{
+		{"no failures", account, 0, 0, 0},
+		{"free failures", account, 3, 0, 0},
+		{"first delayed", account, 4, 0, time.Second},
+		{"second delayed", account, 5, 0, 2 * time.Second},
+		{"third delayed", account, 6, 0, 4 * time.Second},
+		{"last delayed", account, 9, 0, 32 * time.Second},
+		{"delay partly waited", account, 9, 12 * time.Second, 20 * time.Second},
+		{"delay waited", account, 9, 32 * time.Second, 0},
+		{"delay capped", ip, 20, 0, time.Minute},
+		{"delay capped without overflow", ip, 49, 0, time.Minute},
+		{"locked", account, 10, 0, 15 * time.Minute},
+		{"locked partly waited", account, 12, 5 * time.Minute, 10 * time.Minute},
+		{"lock over", account, 10, 15 * time.Minute, 0},
+		{"delayed after the reset window", account, 9, 10 * time.Minute, 0},
+		{"locked outlasts the reset window", account, 10, 12 * time.Minute, 3 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &models.LoginFailures{Failures: tt.failures, LastFailure: now.Add(-tt.ago)}
+			got := max(policy.wait(f, tt.limits, now), 0)
+			if got != tt.want {
+				t.Errorf("%d failures %v ago: got wait %v, want %v", tt.failures, tt.ago, got, tt.want)
+			}
+		})
+	}
+}
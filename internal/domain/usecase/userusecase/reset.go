@@ -0,0 +1,117 @@
+package userusecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/mail"
+	"filmoteka/internal/tracing"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+)
+
+// RequestPasswordReset mails a reset token to email in the background and
+// returns right away, so that neither the response nor its timing reveals
+// which accounts exist. Failures are only logged. Wait waits for the mails
+// in flight.
+func (uc *UserUseCase) RequestPasswordReset(ctx context.Context, email string) {
+	ctx = context.WithoutCancel(ctx)
+	uc.resets.Add(1)
+	go func() {
+		defer uc.resets.Done()
+		err := uc.sendPasswordReset(ctx, email)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error requesting password reset", "err", err)
+		}
+	}()
+}
+
+// Wait blocks until the password reset mails requested so far are sent.
+func (uc *UserUseCase) Wait() {
+	uc.resets.Wait()
+}
+
+// sendPasswordReset creates a reset token for the account of email and
+// mails it. Unknown emails are ignored.
+func (uc *UserUseCase) sendPasswordReset(ctx context.Context, email string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.RequestPasswordReset")
+	defer span.End()
+
+	user, err := uc.userStorage.GetUserByEmail(ctx, email)
+	if errors.Is(err, models.ErrNoRecord) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	expires := time.Now().Add(uc.resetTTL)
+	err = uc.userStorage.CreatePasswordReset(ctx, user.UserID, hashToken(token), expires)
+	if err != nil {
+		return err
+	}
+
+	return uc.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your filmoteka password",
+		Body:    uc.resetBody(token, expires),
+	})
+}
+
+// ResetPassword sets a new password with a token from RequestPasswordReset.
+// A password breaking the policy fails with *models.PasswordPolicyError
+// before the token is looked at. The token and any other outstanding tokens
+// of the user are used up.
+func (uc *UserUseCase) ResetPassword(ctx context.Context, token string, password string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.ResetPassword")
+	defer span.End()
+
+	err := uc.passwords.Check(password)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := uc.hasher.Hash(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userStorage.ResetPassword(ctx, hashToken(token), hash)
+	if errors.Is(err, models.ErrNoRecord) {
+		return nil, models.ErrInvalidResetToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	err = uc.userStorage.ClearLoginFailures(ctx, accountKey(user.Email))
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (uc *UserUseCase) resetBody(token string, expires time.Time) string {
+	action := "Use this token to choose a new password:\n\n" + token
+	if uc.resetURL != "" {
+		action = "Open this link to choose a new password:\n\n" + uc.resetURL + "?token=" + url.QueryEscape(token)
+	}
+	return fmt.Sprintf("Someone asked to reset the password of your filmoteka account.\n\n%s\n\nIt expires at %s. If you did not ask for it, ignore this mail.",
+		action, expires.UTC().Format(time.RFC1123))
+}
+
+// hashToken is how reset tokens are stored, so that a leaked table does not
+// leak usable tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
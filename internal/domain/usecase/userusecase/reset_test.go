@@ -0,0 +1,94 @@
+package userusecase
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/mail"
+	"filmoteka/internal/password"
+	"sync"
+	"testing"
+	"time"
+)
+
+type users struct {
+	mu     sync.Mutex
+	user   models.User
+	resets int
+}
+
+func (s *users) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	if email != s.user.Email {
+		return nil, models.ErrNoRecord
+	}
+	user := s.user
+	return &user, nil
+}
+
+func (s *users) GetLoginFailures(ctx context.Context, key string) (*models.LoginFailures, error) {
+	return nil, models.ErrNoRecord
+}
+
+func (s *users) RecordLoginFailure(ctx context.Context, key string, resetAfter time.Duration) (*models.LoginFailures, error) {
+	return &models.LoginFailures{}, nil
+}
+
+func (s *users) ClearLoginFailures(ctx context.Context, key string) error {
+	return nil
+}
+
+func (s *users) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.resets++
+	return nil
+}
+
+func (s *users) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (*models.User, error) {
+	return nil, models.ErrNoRecord
+}
+
+func (s *users) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	return nil
+}
+
+// mailer records the recipients of the messages it is allowed to send.
+type mailer struct {
+	mu      sync.Mutex
+	release chan struct{}
+	to      []string
+}
+
+func (m *mailer) Send(msg mail.Message) error {
+	<-m.release
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.to = append(m.to, msg.To)
+	return nil
+}
+
+func TestRequestPasswordResetInBackground(t *testing.T) {
+	hasher, err := password.NewHasher(password.Bcrypt, 4, password.Argon2Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := &users{user: models.User{UserID: 1, Email: "admin@example.com"}}
+	m := &mailer{release: make(chan struct{})}
+	uc, err := New(storage, LockoutPolicy{}, m, "", time.Hour, hasher, &password.Policy{MinLength: 1, MaxLength: password.MaxLength})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the mailer blocks until released, so both calls have to return
+	// before any mail is sent
+	uc.RequestPasswordReset(context.Background(), "admin@example.com")
+	uc.RequestPasswordReset(context.Background(), "nobody@example.com")
+	close(m.release)
+	uc.Wait()
+
+	if storage.resets != 1 {
+		t.Errorf("created %d reset tokens, want 1", storage.resets)
+	}
+	if len(m.to) != 1 || m.to[0] != "admin@example.com" {
+		t.Errorf("mailed %v, want only admin@example.com", m.to)
+	}
+}
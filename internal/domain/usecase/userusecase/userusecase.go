@@ -1,24 +1,190 @@
 package userusecase
 
+import (
+	"context"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/mail"
+	"filmoteka/internal/password"
+	"filmoteka/internal/tracing"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
 type UserUseCase struct {
 	userStorage userStorage
+	policy      LockoutPolicy
+	mailer      mail.Mailer
+	resetURL    string
+	resetTTL    time.Duration
+	hasher      *password.Hasher
+	passwords   *password.Policy
+	onLogin     []func(err error)
+	resets      sync.WaitGroup
+
+	// dummyHash is compared against when the email is unknown, so that the
+	// response takes as long as for a wrong password.
+	dummyHash string
 }
 
-func New(userStorage userStorage) *UserUseCase {
+// New creates the user usecase. Failed logins are throttled by lockout.
+// Password reset mails go through mailer and link to resetURL with the
+// token as query parameter, or carry the bare token if resetURL is empty.
+// Reset tokens can be used for resetTTL. New passwords have to satisfy
+// passwords and are hashed with hasher, which also upgrades outdated hashes
+// on login.
+func New(userStorage userStorage, lockout LockoutPolicy, mailer mail.Mailer, resetURL string, resetTTL time.Duration, hasher *password.Hasher, passwords *password.Policy) (*UserUseCase, error) {
+	dummyHash, err := hasher.Hash("filmoteka-dummy-password")
+	if err != nil {
+		return nil, fmt.Errorf("generating dummy password hash: %w", err)
+	}
 	return &UserUseCase{
 		userStorage: userStorage,
-	}
+		policy:      lockout,
+		mailer:      mailer,
+		resetURL:    resetURL,
+		resetTTL:    resetTTL,
+		hasher:      hasher,
+		passwords:   passwords,
+		dummyHash:   dummyHash,
+	}, nil
 }
 
 type userStorage interface {
-	//auth(u *models.User)
-	GetUserByEmail(email string) (string, string, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetLoginFailures(ctx context.Context, key string) (*models.LoginFailures, error)
+	RecordLoginFailure(ctx context.Context, key string, resetAfter time.Duration) (*models.LoginFailures, error)
+	ClearLoginFailures(ctx context.Context, key string) error
+	CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+}
+
+func (uc *UserUseCase) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.GetUserByEmail")
+	defer span.End()
+
+	return uc.userStorage.GetUserByEmail(ctx, email)
 }
 
-//func (uc *UserUseCase) auth(u *models.User) {
-//	uc.userStorage.auth(u)
-//}
+// OnLogin registers fn to be called with the outcome of every login
+// attempt. It is not safe to call once logins are served.
+func (uc *UserUseCase) OnLogin(fn func(err error)) {
+	uc.onLogin = append(uc.onLogin, fn)
+}
+
+// Login checks the credentials of a login attempt from the client address
+// ip. Unknown emails and wrong passwords both fail with
+// models.ErrInvalidCredentials after a hash comparison, and both count
+// towards the failures of the account and the address. Attempts made while
+// either is throttled fail with *models.LoginThrottledError without looking
+// at the password. A correct password whose hash is outdated is rehashed
+// with the current parameters.
+func (uc *UserUseCase) Login(ctx context.Context, email string, password string, ip string) (*models.User, error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Login")
+	defer span.End()
+
+	user, err := uc.login(ctx, email, password, ip)
+	for _, fn := range uc.onLogin {
+		fn(err)
+	}
+	return user, err
+}
+
+func (uc *UserUseCase) login(ctx context.Context, email string, password string, ip string) (*models.User, error) {
+	account, address := accountKey(email), ipKey(ip)
+
+	now := time.Now()
+	for _, key := range []struct {
+		name   string
+		limits Limits
+	}{{account, uc.policy.Account}, {address, uc.policy.IP}} {
+		f, err := uc.userStorage.GetLoginFailures(ctx, key.name)
+		if err != nil {
+			return nil, err
+		}
+		if wait := uc.policy.wait(f, key.limits, now); wait > 0 {
+			return nil, &models.LoginThrottledError{RetryAfter: wait}
+		}
+	}
+
+	user, err := uc.userStorage.GetUserByEmail(ctx, email)
+	if errors.Is(err, models.ErrNoRecord) {
+		_, _, _ = uc.hasher.Verify(password, uc.dummyHash)
+		return nil, uc.fail(ctx, account, address)
+	} else if err != nil {
+		return nil, err
+	}
+	ok, outdated, err := uc.hasher.Verify(password, user.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, uc.fail(ctx, account, address)
+	}
+	if outdated {
+		uc.rehash(ctx, user, password)
+	}
+
+	err = uc.userStorage.ClearLoginFailures(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// rehash replaces the outdated password hash of user. Failing to do so does
+// not fail the login, it is tried again next time.
+func (uc *UserUseCase) rehash(ctx context.Context, user *models.User, password string) {
+	hash, err := uc.hasher.Hash(password)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error rehashing password", "err", err)
+		return
+	}
+	err = uc.userStorage.UpdatePassword(ctx, user.UserID, hash)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error storing rehashed password", "err", err)
+		return
+	}
+	user.Password = hash
+}
+
+// fail records a failed attempt against every key.
+func (uc *UserUseCase) fail(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		_, err := uc.userStorage.RecordLoginFailure(ctx, key, uc.policy.ResetAfter)
+		if err != nil {
+			return err
+		}
+	}
+	return models.ErrInvalidCredentials
+}
+
+// Unlock forgets the failed logins of an account, an address or both.
+func (uc *UserUseCase) Unlock(ctx context.Context, email string, ip string) error {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Unlock")
+	defer span.End()
+
+	if email != "" {
+		if err := uc.userStorage.ClearLoginFailures(ctx, accountKey(email)); err != nil {
+			return err
+		}
+	}
+	if ip != "" {
+		if err := uc.userStorage.ClearLoginFailures(ctx, ipKey(ip)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
 
-func (uc *UserUseCase) GetUserByEmail(email string) (string, string, error) {
-	return uc.userStorage.GetUserByEmail(email)
+func ipKey(ip string) string {
+	return "ip:" + ip
 }
@@ -0,0 +1,102 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrShuttingDown = errors.New("shutting down")
+
+// Check fails if a dependency cannot serve requests.
+type Check func(ctx context.Context) error
+
+// Checker runs the readiness checks of the API. Once Shutdown is called it
+// reports not ready without running them, so that load balancers stop
+// sending traffic while requests drain.
+type Checker struct {
+	timeout      time.Duration
+	started      time.Time
+	names        []string
+	checks       []Check
+	shuttingDown atomic.Bool
+}
+
+func New(timeout time.Duration) *Checker {
+	return &Checker{
+		timeout: timeout,
+		started: time.Now(),
+	}
+}
+
+// Add registers a readiness check under name. It is not safe to call once
+// the checker is in use.
+func (c *Checker) Add(name string, check Check) {
+	c.names = append(c.names, name)
+	c.checks = append(c.checks, check)
+}
+
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+type Report struct {
+	Ready  bool                    `json:"ready"`
+	Status string                  `json:"status"`
+	Uptime string                  `json:"uptime"`
+	Checks map[string]*CheckResult `json:"checks,omitempty"`
+}
+
+type CheckResult struct {
+	OK       bool   `json:"ok"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// Live reports that the process is up. It does not look at dependencies,
+// restarting the API would not fix them.
+func (c *Checker) Live() *Report {
+	return &Report{Ready: true, Status: "ok", Uptime: c.uptime()}
+}
+
+// Ready runs every check concurrently, each limited to the checker's
+// timeout.
+func (c *Checker) Ready(ctx context.Context) *Report {
+	if c.shuttingDown.Load() {
+		return &Report{Ready: false, Status: ErrShuttingDown.Error(), Uptime: c.uptime()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]*CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := check(ctx)
+			results[i] = &CheckResult{OK: err == nil, Duration: time.Since(start).Round(time.Microsecond).String()}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := &Report{Ready: true, Status: "ok", Uptime: c.uptime(), Checks: make(map[string]*CheckResult)}
+	for i, res := range results {
+		report.Checks[c.names[i]] = res
+		if !res.OK {
+			report.Ready, report.Status = false, "unavailable"
+		}
+	}
+	return report
+}
+
+func (c *Checker) uptime() string {
+	return time.Since(c.started).Round(time.Second).String()
+}
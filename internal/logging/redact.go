@@ -0,0 +1,103 @@
+package logging
+
+import (
+	"encoding"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// sensitive are the name fragments of attributes and fields that are
+// never logged.
+var sensitive = []string{"password", "passwd", "secret", "token", "authorization", "cookie", "dsn", "hash"}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitive {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttr is the ReplaceAttr of the handlers.
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if isSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	if a.Value.Kind() == slog.KindAny {
+		a.Value = redactValue(reflect.ValueOf(a.Value.Any()), 0)
+	}
+	return a
+}
+
+var (
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+	stringerType  = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	marshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType      = reflect.TypeOf(time.Time{})
+)
+
+// redactValue turns structs and string-keyed maps into groups so that their
+// sensitive fields can be redacted. Errors, times and values that format
+// themselves are kept as they are.
+func redactValue(v reflect.Value, depth int) slog.Value {
+	if !v.IsValid() {
+		return slog.AnyValue(nil)
+	}
+	t := v.Type()
+	if depth > 4 || t.Implements(errorType) || t.Implements(stringerType) || t.Implements(marshalerType) || t == timeType {
+		return slog.AnyValue(v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return slog.AnyValue(nil)
+		}
+		return redactValue(v.Elem(), depth)
+	case reflect.Struct:
+		var attrs []slog.Attr
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			if isSensitive(name) || isSensitive(f.Name) {
+				attrs = append(attrs, slog.String(name, redacted))
+				continue
+			}
+			attrs = append(attrs, slog.Attr{Key: name, Value: redactValue(v.Field(i), depth+1)})
+		}
+		return slog.GroupValue(attrs...)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return slog.AnyValue(v.Interface())
+		}
+		var attrs []slog.Attr
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			if isSensitive(key) {
+				attrs = append(attrs, slog.String(key, redacted))
+				continue
+			}
+			attrs = append(attrs, slog.Attr{Key: key, Value: redactValue(iter.Value(), depth+1)})
+		}
+		return slog.GroupValue(attrs...)
+	default:
+		return slog.AnyValue(v.Interface())
+	}
+}
@@ -0,0 +1,49 @@
+package logging_test
+
+import (
+	"context"
+	"filmoteka/internal/delivery/http/handlers/userhandlers"
+	"filmoteka/internal/logging"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	const (
+		password   = "correct-horse-battery"
+		resetToken = "q8Zt3vJ0resetTOKEN"
+	)
+
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			var b strings.Builder
+			logger, err := logging.New(&b, "debug", format)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := logging.WithRequestID(context.Background(), "req-1")
+			logger.InfoContext(ctx, "login", "request", userhandlers.LoginRequest{Email: "admin@example.com", Password: password})
+			logger.InfoContext(ctx, "login", "request", &userhandlers.LoginRequest{Email: "admin@example.com", Password: password})
+			logger.InfoContext(ctx, "reset", "request", userhandlers.PasswordResetConfirm{Token: resetToken, Password: password})
+			logger.InfoContext(ctx, "reset", "token", resetToken)
+			logger.With("reset_token", resetToken).InfoContext(ctx, "reset")
+			logger.InfoContext(ctx, "reset", slog.Group("mail", "token", resetToken))
+			logger.InfoContext(ctx, "reset", "body", map[string]any{"password": password, "email": "admin@example.com"})
+
+			out := b.String()
+			for _, secret := range []string{password, resetToken} {
+				if strings.Contains(out, secret) {
+					t.Errorf("output contains %q:\n%s", secret, out)
+				}
+			}
+			if n := strings.Count(out, "REDACTED"); n != 8 {
+				t.Errorf("got %d redacted values, want 8:\n%s", n, out)
+			}
+			if !strings.Contains(out, "admin@example.com") {
+				t.Errorf("output lost the email:\n%s", out)
+			}
+		})
+	}
+}
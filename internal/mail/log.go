@@ -0,0 +1,30 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogMailer writes messages to w instead of sending them, for local
+// development. Point it at a file to read the reset links from there.
+type LogMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewLog(w io.Writer) *LogMailer {
+	return &LogMailer{
+		w: w,
+	}
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.w, "----- %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
@@ -0,0 +1,102 @@
+// Package metrics holds the Prometheus metrics of the API and serves them.
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const namespace = "filmoteka"
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	storageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "storage_duration_seconds",
+		Help:      "Duration of storage calls by storage, method and outcome, not counting cache hits.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"storage", "method", "outcome"})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by result: success, invalid_credentials, throttled or error.",
+	}, []string{"result"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		storageDuration,
+		logins,
+	)
+	for _, result := range []string{"success", "invalid_credentials", "throttled", "error"} {
+		logins.WithLabelValues(result)
+	}
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// RegisterDB reports the connection pool statistics of db.
+func RegisterDB(db *sql.DB) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, namespace))
+}
+
+func ObserveRequest(method string, route string, status int, d time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+// ObserveStorage records a storage call that took d. Not found is an
+// answer, not a failure.
+func ObserveStorage(storage string, method string, err error, d time.Duration) {
+	outcome := "ok"
+	if errors.Is(err, models.ErrNoRecord) {
+		outcome = "not_found"
+	} else if err != nil {
+		outcome = "error"
+	}
+	storageDuration.WithLabelValues(storage, method, outcome).Observe(d.Seconds())
+}
+
+// ObserveLogin counts a login attempt by the error it ended with.
+func ObserveLogin(err error) {
+	var throttled *models.LoginThrottledError
+	switch {
+	case err == nil:
+		logins.WithLabelValues("success").Inc()
+	case errors.Is(err, models.ErrInvalidCredentials):
+		logins.WithLabelValues("invalid_credentials").Inc()
+	case errors.As(err, &throttled):
+		logins.WithLabelValues("throttled").Inc()
+	default:
+		logins.WithLabelValues("error").Inc()
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"context"
+	"github.com/alexedwards/scs/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"log/slog"
+)
+
+var sessionsDesc = prometheus.NewDesc(namespace+"_sessions_active", "Unexpired sessions by role, anonymous for sessions without login.", []string{"role"}, nil)
+
+// sessionCollector counts the sessions in the store on every scrape.
+type sessionCollector struct {
+	manager *scs.SessionManager
+}
+
+// RegisterSessions reports the active sessions of manager, if its store can
+// be iterated.
+func RegisterSessions(manager *scs.SessionManager) {
+	switch manager.Store.(type) {
+	case scs.IterableStore, scs.IterableCtxStore:
+		registry.MustRegister(&sessionCollector{manager: manager})
+	default:
+		slog.Warn("Session store cannot be iterated, not reporting active sessions")
+	}
+}
+
+func (c *sessionCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sessionsDesc
+}
+
+func (c *sessionCollector) Collect(ch chan<- prometheus.Metric) {
+	counts := map[string]int{"anonymous": 0}
+	err := c.manager.Iterate(context.Background(), func(ctx context.Context) error {
+		role := c.manager.GetString(ctx, "role")
+		if role == "" {
+			role = "anonymous"
+		}
+		counts[role]++
+		return nil
+	})
+	if err != nil {
+		slog.Error("Error counting sessions", "err", err)
+		ch <- prometheus.NewInvalidMetric(sessionsDesc, err)
+		return
+	}
+	for role, n := range counts {
+		ch <- prometheus.MustNewConstMetric(sessionsDesc, prometheus.GaugeValue, float64(n), role)
+	}
+}
@@ -0,0 +1,67 @@
+package password
+
+import (
+	"errors"
+	"testing"
+)
+
+var (
+	argon2Params = Argon2Params{Memory: 64, Time: 1, Threads: 1}
+	argon2Slower = Argon2Params{Memory: 64, Time: 2, Threads: 1}
+)
+
+func newHasher(t *testing.T, algorithm string, bcryptCost int, argon2 Argon2Params) *Hasher {
+	t.Helper()
+	h, err := NewHasher(algorithm, bcryptCost, argon2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestVerify(t *testing.T) {
+	bcrypt4 := newHasher(t, Bcrypt, 4, Argon2Params{})
+	bcrypt5 := newHasher(t, Bcrypt, 5, Argon2Params{})
+	argon2 := newHasher(t, Argon2id, 4, argon2Params)
+	argon2Slow := newHasher(t, Argon2id, 4, argon2Slower)
+
+	tests := []struct {
+		name         string
+		hashedBy     *Hasher
+		verifiedBy   *Hasher
+		wantOutdated bool
+	}{
+		{"same bcrypt cost", bcrypt4, bcrypt4, false},
+		{"bcrypt cost changed", bcrypt4, bcrypt5, true},
+		{"bcrypt to argon2id", bcrypt4, argon2, true},
+		{"argon2id to bcrypt", argon2, bcrypt4, true},
+		{"same argon2id parameters", argon2, argon2, false},
+		{"argon2id parameters changed", argon2, argon2Slow, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := tt.hashedBy.Hash("correct horse battery staple")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ok, outdated, err := tt.verifiedBy.Verify("correct horse battery staple", hash)
+			if err != nil || !ok || outdated != tt.wantOutdated {
+				t.Errorf("right password: got ok %v, outdated %v, err %v, want ok true, outdated %v", ok, outdated, err, tt.wantOutdated)
+			}
+			ok, outdated, err = tt.verifiedBy.Verify("wrong horse battery staple", hash)
+			if err != nil || ok || outdated {
+				t.Errorf("wrong password: got ok %v, outdated %v, err %v, want ok false, outdated false", ok, outdated, err)
+			}
+		})
+	}
+}
+
+func TestVerifyUnknownHash(t *testing.T) {
+	h := newHasher(t, Bcrypt, 4, Argon2Params{})
+	for _, hash := range []string{"", "plain text", "$argon2id$v=19$m=64,t=1$c2FsdA$a2V5", "$argon2id$v=18$m=64,t=1,p=1$c2FsdA$a2V5"} {
+		if _, _, err := h.Verify("password", hash); !errors.Is(err, ErrUnknownHash) {
+			t.Errorf("hash %q: got error %v, want ErrUnknownHash", hash, err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"filmoteka/internal/domain/models"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Policy is what new passwords have to satisfy. Following NIST SP 800-63B
+// it is a length range and a blocklist rather than composition rules.
+// Breached holds the uppercase hex SHA-1 of known breached passwords.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	Breached  map[string]struct{}
+}
+
+// MaxLength is the longest password accepted, bcrypt only looks at the
+// first 72 bytes.
+const MaxLength = 72
+
+// Check returns a *models.PasswordPolicyError if password breaks the policy.
+// Password resets are the only way to choose a password: users are seeded
+// with hashes, and the rehash on login keeps the password a user has.
+func (p *Policy) Check(password string) error {
+	n := utf8.RuneCountInString(password)
+	if n < p.MinLength {
+		return &models.PasswordPolicyError{Reason: fmt.Sprintf("password must be at least %d characters", p.MinLength)}
+	}
+	if p.MaxLength > 0 && len(password) > p.MaxLength {
+		return &models.PasswordPolicyError{Reason: fmt.Sprintf("password must be at most %d bytes", p.MaxLength)}
+	}
+	if _, ok := p.Breached[sha1Hex(password)]; ok {
+		return &models.PasswordPolicyError{Reason: "password appears in a list of breached passwords"}
+	}
+	return nil
+}
+
+// LoadBreached reads a blocklist into the policy, one password per line.
+// Lines that are SHA-1 hashes, optionally followed by ":count" as in the
+// Pwned Passwords downloads, are taken as hashes.
+func (p *Policy) LoadBreached(r io.Reader) error {
+	if p.Breached == nil {
+		p.Breached = make(map[string]struct{})
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		hash, _, _ := strings.Cut(line, ":")
+		if len(hash) == 40 && isHex(hash) {
+			p.Breached[strings.ToUpper(hash)] = struct{}{}
+		} else {
+			p.Breached[sha1Hex(line)] = struct{}{}
+		}
+	}
+	return scanner.Err()
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
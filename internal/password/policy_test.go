@@ -0,0 +1,47 @@
+package password
+
+import (
+	"errors"
+	"filmoteka/internal/domain/models"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	p := &Policy{MinLength: 8, MaxLength: MaxLength}
+	// "password1" as a hash with a count, "letmein123" in plain text and
+	// "password" as a lowercase hash
+	err := p.LoadBreached(strings.NewReader("E38AD214943DAAD1D64C102FAEC29DE4AFE9DA3D:2413945\n\nletmein123\n5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"too short", "seven77", true},
+		{"shortest", "eight888", false},
+		// eight runes, but more bytes
+		{"short in runes", "пароль12", false},
+		{"longest", strings.Repeat("a", MaxLength), false},
+		{"too long", strings.Repeat("a", MaxLength+1), true},
+		{"too long in bytes", strings.Repeat("я", MaxLength/2+1), true},
+		{"breached hash", "password1", true},
+		{"breached lowercase hash", "password", true},
+		{"breached plain text", "letmein123", true},
+		{"not breached", "letmein1234", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.Check(tt.password)
+			var policyErr *models.PasswordPolicyError
+			if tt.wantErr && !errors.As(err, &policyErr) {
+				t.Errorf("got error %v, want a PasswordPolicyError", err)
+			} else if !tt.wantErr && err != nil {
+				t.Errorf("got error %v, want none", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// PostgresStore keeps the buckets in the rate_limits table so that every
+// replica draws from the same budget. Each Take is a single upsert.
+type PostgresStore struct {
+	db      *sql.DB
+	timeout time.Duration
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewPostgresStore returns a store on db that gives every query up to
+// timeout.
+func NewPostgresStore(db *sql.DB, timeout time.Duration) *PostgresStore {
+	return &PostgresStore{
+		db:        db,
+		timeout:   timeout,
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, p Policy) (Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	s.maybeSweep()
+
+	query := `INSERT INTO rate_limits AS rl (key, tokens, allowed, updated_at)
+VALUES ($1, $2::float8 - 1, true, now())
+ON CONFLICT (key) DO UPDATE SET
+    tokens     = CASE
+                     WHEN LEAST($2::float8, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at) * $3::float8) >= 1
+                         THEN LEAST($2::float8, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at) * $3::float8) - 1
+                     ELSE LEAST($2::float8, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at) * $3::float8) END,
+    allowed    = LEAST($2::float8, rl.tokens + EXTRACT(EPOCH FROM now() - rl.updated_at) * $3::float8) >= 1,
+    updated_at = now()
+RETURNING tokens, allowed`
+
+	var tokens float64
+	var allowed bool
+	err := s.db.QueryRowContext(ctx, query, key, p.Burst, p.Rate).Scan(&tokens, &allowed)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error taking a rate limit token", "err", err)
+		return Result{}, err
+	}
+	return result(tokens, allowed, p), nil
+}
+
+// maybeSweep deletes buckets untouched for an hour, at most once per
+// sweepInterval and without holding up the request.
+func (s *PostgresStore) maybeSweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = time.Now()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+		_, err := s.db.ExecContext(ctx, `DELETE FROM rate_limits WHERE updated_at < now() - interval '1 hour'`)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error deleting idle rate limit buckets", "err", err)
+		}
+	}()
+}
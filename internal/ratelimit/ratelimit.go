@@ -0,0 +1,58 @@
+// Package ratelimit implements token buckets: every client key holds up to
+// Burst tokens, refilled at Rate tokens per second, and each request takes
+// one.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Store keeps the buckets. MemoryStore serves a single instance,
+// PostgresStore shares the buckets between replicas.
+type Store interface {
+	Take(ctx context.Context, key string, p Policy) (Result, error)
+}
+
+// Policy is the budget of one group of routes. Name separates the buckets of
+// different policies for the same client. PerIP keys the bucket by client
+// address even for authenticated sessions.
+type Policy struct {
+	Name  string
+	Rate  float64
+	Burst int
+	PerIP bool
+}
+
+// Result describes the bucket after a Take. RetryAfter is set when the
+// request was rejected, Reset is the time until the bucket is full again.
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
+// refill returns the tokens of a bucket that held tokens elapsed ago.
+func refill(tokens float64, elapsed time.Duration, p Policy) float64 {
+	return math.Min(float64(p.Burst), tokens+elapsed.Seconds()*p.Rate)
+}
+
+// result describes a bucket left with tokens after the request was allowed
+// or not.
+func result(tokens float64, allowed bool, p Policy) Result {
+	res := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(p.Burst) - tokens) / p.Rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / p.Rate)
+	}
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
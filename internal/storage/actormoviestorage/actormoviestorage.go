@@ -3,38 +3,40 @@ package actormoviestorage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"filmoteka/internal/domain/models"
 	"github.com/pkg/errors"
-	"log"
+	"log/slog"
 	"time"
 )
 
-var DbTimeout = 5 * time.Second
-
 type ActorMovieStorage struct {
-	db *sql.DB
+	db      *sql.DB
+	timeout time.Duration
 }
 
-func New(db *sql.DB) *ActorMovieStorage {
+// New returns a storage on db that gives every query up to timeout.
+func New(db *sql.DB, timeout time.Duration) *ActorMovieStorage {
 	return &ActorMovieStorage{
-		db: db,
+		db:      db,
+		timeout: timeout,
 	}
 }
 
-func (s *ActorMovieStorage) GetMovieByID(id int) (*models.Movie, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *ActorMovieStorage) GetMovieByID(ctx context.Context, id int) (*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-	query := `SELECT movieid, title, description, rating, releasedate FROM movies WHERE movieid = $1`
+	query := `SELECT movieid, title, description, rating, releasedate, updated_at FROM movies WHERE movieid = $1`
 	rows, err := s.db.QueryContext(ctx, query, id)
 	if err != nil {
-		log.Println("Error getting movie by id from the table", err)
+		slog.ErrorContext(ctx, "Error getting movie by id from the table", "err", err)
 		return nil, err
 	}
 
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -46,12 +48,13 @@ func (s *ActorMovieStorage) GetMovieByID(id int) (*models.Movie, error) {
 			&movie.Description,
 			&movie.Rating,
 			&movie.ReleaseDate,
+			&movie.UpdatedAt,
 		)
 		if errors.Is(err, sql.ErrNoRows) {
-			log.Println("No results", err)
+			slog.DebugContext(ctx, "No results", "err", err)
 			return nil, models.ErrNoRecord
 		} else if err != nil {
-			log.Println("Error getting rows", err)
+			slog.ErrorContext(ctx, "Error getting rows", "err", err)
 			return nil, err
 		}
 	}
@@ -59,24 +62,23 @@ func (s *ActorMovieStorage) GetMovieByID(id int) (*models.Movie, error) {
 	if !movie.ReleaseDate.Valid {
 		return nil, models.ErrNoRecord
 	}
-	log.Println("Movie: ", movie)
 	return movie, nil
 }
 
-func (s *ActorMovieStorage) GetActorByID(id int) (*models.Actor, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *ActorMovieStorage) GetActorByID(ctx context.Context, id int) (*models.Actor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-	query := `SELECT actorid, name, gender, dateofbirth FROM actors WHERE actorid = $1`
+	query := `SELECT actorid, name, gender, dateofbirth, updated_at FROM actors WHERE actorid = $1`
 	rows, err := s.db.QueryContext(ctx, query, id)
 	if err != nil {
-		log.Println("Error getting actor by id from the table", err)
+		slog.ErrorContext(ctx, "Error getting actor by id from the table", "err", err)
 		return nil, err
 	}
 
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -87,12 +89,13 @@ func (s *ActorMovieStorage) GetActorByID(id int) (*models.Actor, error) {
 			&actor.Name,
 			&actor.Gender,
 			&actor.DateOfBirth,
+			&actor.UpdatedAt,
 		)
 		if errors.Is(err, sql.ErrNoRows) {
-			log.Println("No results", err)
+			slog.DebugContext(ctx, "No results", "err", err)
 			return nil, models.ErrNoRecord
 		} else if err != nil {
-			log.Println("Error scanning rows", err)
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
 			return nil, err
 		}
 	}
@@ -101,85 +104,89 @@ func (s *ActorMovieStorage) GetActorByID(id int) (*models.Actor, error) {
 	if !actor.DateOfBirth.Valid {
 		return nil, models.ErrNoRecord
 	}
-	log.Println("Actor: ", actor.ActorID, actor.DateOfBirth.Time, actor.DateOfBirth.Valid)
 	return actor, nil
 }
 
-func (s *ActorMovieStorage) AddActorToMovie(actorid int, movieid int) (*models.Actor, *models.Movie, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *ActorMovieStorage) AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 	a := &models.Actor{ActorID: actorid}
 	m := &models.Movie{MovieID: movieid}
-	a, err := s.GetActorByID(a.ActorID)
+	a, err := s.GetActorByID(ctx, a.ActorID)
 	if errors.Is(err, models.ErrNoRecord) {
-		log.Println("No results", err)
-		return nil, nil, models.ErrNoRecord
+		slog.DebugContext(ctx, "No results", "err", err)
+		return nil, nil, false, models.ErrNoRecord
 	} else if err != nil {
-		log.Println("Error getting actor from the table", err)
-		return nil, nil, err
+		slog.ErrorContext(ctx, "Error getting actor from the table", "err", err)
+		return nil, nil, false, err
 	}
-	m, err = s.GetMovieByID(m.MovieID)
+	m, err = s.GetMovieByID(ctx, m.MovieID)
 	if errors.Is(err, models.ErrNoRecord) {
-		log.Println("No results", err)
-		return nil, nil, err
+		slog.DebugContext(ctx, "No results", "err", err)
+		return nil, nil, false, err
 	} else if err != nil {
-		log.Println("Error getting movies for actor", err)
-		return nil, nil, err
+		slog.ErrorContext(ctx, "Error getting movies for actor", "err", err)
+		return nil, nil, false, err
 	}
-	query := `INSERT INTO actormovie (actorid, movieid) VALUES ($1, $2)`
-	_, err = s.db.ExecContext(ctx, query, actorid, movieid)
+	query := `INSERT INTO actormovie (actorid, movieid) SELECT $1, $2
+	WHERE NOT EXISTS (SELECT 1 FROM actormovie WHERE actorid = $1 AND movieid = $2)`
+	res, err := s.db.ExecContext(ctx, query, actorid, movieid)
 	if err != nil {
-		log.Println("Error adding actor to movie in the table", err)
-		return nil, nil, err
+		slog.ErrorContext(ctx, "Error adding actor to movie in the table", "err", err)
+		return nil, nil, false, err
 	}
-	return a, m, nil
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return a, m, n > 0, nil
 }
 
-func (s *ActorMovieStorage) DeleteActorFromMovie(actorid int, movieid int) (*models.Actor, *models.Movie, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *ActorMovieStorage) DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 	a := &models.Actor{ActorID: actorid}
 	m := &models.Movie{MovieID: movieid}
-	a, err := s.GetActorByID(a.ActorID)
+	a, err := s.GetActorByID(ctx, a.ActorID)
 	if errors.Is(err, models.ErrNoRecord) {
-		log.Println("No results", err)
+		slog.DebugContext(ctx, "No results", "err", err)
 		return nil, nil, err
 	} else if err != nil {
-		log.Println("Error getting actor by id from the table", err)
+		slog.ErrorContext(ctx, "Error getting actor by id from the table", "err", err)
 		return nil, nil, err
 	}
-	m, err = s.GetMovieByID(m.MovieID)
+	m, err = s.GetMovieByID(ctx, m.MovieID)
 	if errors.Is(err, models.ErrNoRecord) {
-		log.Println("No results", err)
+		slog.DebugContext(ctx, "No results", "err", err)
 		return nil, nil, err
 	} else if err != nil {
-		log.Println("Error getting movies by id from the table", err)
+		slog.ErrorContext(ctx, "Error getting movies by id from the table", "err", err)
 		return nil, nil, err
 	}
 	query := `DELETE FROM actormovie WHERE actorid = $1 AND movieid = $2`
 	_, err = s.db.ExecContext(ctx, query, actorid, movieid)
 	if err != nil {
-		log.Println("Error deleting actor from movie in the table", err)
+		slog.ErrorContext(ctx, "Error deleting actor from movie in the table", "err", err)
 		return nil, nil, err
 	}
 	return a, m, nil
 }
 
-func (s *ActorMovieStorage) GetActorsForMovie(id int) ([]*models.Actor, *models.Movie, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *ActorMovieStorage) GetActorsForMovie(ctx context.Context, id int) ([]*models.Actor, *models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	query := `SELECT a.ActorID, a.Name, a.Gender, a.DateOfBirth FROM Actors a JOIN actormovie am ON a.actorid = am.actorid WHERE am.movieid = $1`
 
 	rows, err := s.db.QueryContext(ctx, query, id)
 	if err != nil {
-		log.Println("Error getting actors for movie from the table", err)
+		slog.ErrorContext(ctx, "Error getting actors for movie from the table", "err", err)
 		return nil, nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -193,42 +200,42 @@ func (s *ActorMovieStorage) GetActorsForMovie(id int) ([]*models.Actor, *models.
 			&actor.DateOfBirth,
 		)
 		if errors.Is(err, sql.ErrNoRows) {
-			log.Println("No results", err)
+			slog.DebugContext(ctx, "No results", "err", err)
 			return nil, nil, models.ErrNoRecord
 		} else if err != nil {
-			log.Println("Error scanning rows", err)
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
 			return nil, nil, err
 		}
 
 		actors = append(actors, &actor)
 
 	}
-	movie, err := s.GetMovieByID(id)
+	movie, err := s.GetMovieByID(ctx, id)
 	if errors.Is(err, models.ErrNoRecord) {
-		log.Println("No results", err)
+		slog.DebugContext(ctx, "No results", "err", err)
 		return nil, nil, err
 	} else if err != nil {
-		log.Println("Error scanning rows", err)
+		slog.ErrorContext(ctx, "Error scanning rows", "err", err)
 		return nil, nil, err
 	}
 	return actors, movie, nil
 }
 
-func (s *ActorMovieStorage) GetMoviesForActor(actorid int) ([]*models.Movie, *models.Actor, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *ActorMovieStorage) GetMoviesForActor(ctx context.Context, actorid int) ([]*models.Movie, *models.Actor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	query := `SELECT m.movieid, m.title, m.description, m.rating, m.releasedate FROM Movies m JOIN actormovie am ON m.movieid = am.movieid WHERE am.actorid = $1`
 
 	rows, err := s.db.QueryContext(ctx, query, actorid)
 	if err != nil {
-		log.Println("Error getting movies for actor from the table", err)
+		slog.ErrorContext(ctx, "Error getting movies for actor from the table", "err", err)
 		return nil, nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -243,22 +250,22 @@ func (s *ActorMovieStorage) GetMoviesForActor(actorid int) ([]*models.Movie, *mo
 			&movie.ReleaseDate,
 		)
 		if errors.Is(err, sql.ErrNoRows) {
-			log.Println("No results", err)
+			slog.DebugContext(ctx, "No results", "err", err)
 			return nil, nil, models.ErrNoRecord
 		} else if err != nil {
-			log.Println("Error scanning rows", err)
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
 			return nil, nil, err
 		}
 
 		movies = append(movies, &movie)
 
 	}
-	actor, err := s.GetActorByID(actorid)
+	actor, err := s.GetActorByID(ctx, actorid)
 	if errors.Is(err, models.ErrNoRecord) {
-		log.Println("No results", err)
+		slog.DebugContext(ctx, "No results", "err", err)
 		return nil, nil, err
 	} else if err != nil {
-		log.Println("Error getting actor by id from the table", err)
+		slog.ErrorContext(ctx, "Error getting actor by id from the table", "err", err)
 		return nil, nil, err
 
 	}
@@ -267,11 +274,11 @@ func (s *ActorMovieStorage) GetMoviesForActor(actorid int) ([]*models.Movie, *mo
 
 }
 
-func (s *ActorMovieStorage) GetMovieByActorName(name string, surname string) ([]*models.MovieWithActor, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *ActorMovieStorage) GetMovieByActorName(ctx context.Context, name string, surname string) ([]*models.MovieWithActor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	query := `SELECT m.*, a.name AS actor_name
+	query := `SELECT m.movieid, m.title, m.description, m.rating, m.releasedate, a.name AS actor_name
 FROM Movies m
          JOIN actormovie am ON m.movieid = am.movieid
          JOIN Actors a ON am.actorid = a.actorid 
@@ -279,13 +286,13 @@ WHERE a.name ILIKE  $1 AND a.name ILIKE $2;`
 
 	rows, err := s.db.QueryContext(ctx, query, "%"+name+"%", "%"+surname+"%")
 	if err != nil {
-		log.Println("Error getting movies by actor name from the table", err)
+		slog.ErrorContext(ctx, "Error getting movies by actor name from the table", "err", err)
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -302,10 +309,10 @@ WHERE a.name ILIKE  $1 AND a.name ILIKE $2;`
 			&movie.ActorName,
 		)
 		if errors.Is(err, sql.ErrNoRows) {
-			log.Println("No results", err)
+			slog.DebugContext(ctx, "No results", "err", err)
 			return nil, models.ErrNoRecord
 		} else if err != nil {
-			log.Println("Error scanning rows", err)
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
 			return nil, err
 		}
 
@@ -316,39 +323,279 @@ WHERE a.name ILIKE  $1 AND a.name ILIKE $2;`
 
 }
 
-func (s *ActorMovieStorage) GetActorsAndMoviesForMovie(id int) ([]*models.ActorMovies, *models.Movie, error) {
-	actors, _, err := s.GetActorsForMovie(id)
-	movie := &models.Movie{}
+// GetActorsAndMoviesForMovie returns the cast of a movie with the filmography
+// of every cast member. The filmographies are aggregated in the same query,
+// so the number of queries does not depend on the size of the cast.
+func (s *ActorMovieStorage) GetActorsAndMoviesForMovie(ctx context.Context, id int, opts models.CastOptions) ([]*models.ActorMovies, *models.Movie, error) {
+	movie, err := s.GetMovieByID(ctx, id)
 	if errors.Is(err, models.ErrNoRecord) {
-		log.Println("No results", err)
+		slog.DebugContext(ctx, "No results", "err", err)
 		return nil, nil, err
 	} else if err != nil {
-		log.Println("Error getting actors for movie", err)
+		slog.ErrorContext(ctx, "Error getting movie by id from the table", "err", err)
 		return nil, nil, err
 	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT a.actorid, a.name,
+	COALESCE(json_agg(json_build_object('movieid', m.movieid, 'Title', m.title, 'description', m.description, 'rating', m.rating, 'releasedate', m.releasedate)
+		ORDER BY m.releasedate DESC) FILTER (WHERE m.movieid IS NOT NULL), '[]')
+FROM actormovie c
+         JOIN actors a ON a.actorid = c.actorid
+         LEFT JOIN LATERAL (SELECT m.movieid, m.title, m.description, m.rating, m.releasedate
+                            FROM movies m
+                                     JOIN actormovie am ON m.movieid = am.movieid
+                            WHERE am.actorid = a.actorid AND $2
+                            ORDER BY m.releasedate DESC
+                            LIMIT $3) m ON true
+WHERE c.movieid = $1
+GROUP BY a.actorid, a.name
+ORDER BY a.name`
+
+	var limit any
+	if opts.MoviesPerActor > 0 {
+		limit = opts.MoviesPerActor
+	}
+	rows, err := s.db.QueryContext(ctx, query, id, opts.Depth != 1, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting actors and movies for movie from the table", "err", err)
+		return nil, nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
 	var result []*models.ActorMovies
-	for _, actor := range actors {
-		movies, _, err := s.GetMoviesForActor(actor.ActorID)
-		if errors.Is(err, models.ErrNoRecord) {
-			log.Println("No results", err)
+	for rows.Next() {
+		var movies []byte
+		res := &models.ActorMovies{}
+		err = rows.Scan(
+			&res.ActorId,
+			&res.Name,
+			&movies,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
 			return nil, nil, err
-		} else if err != nil {
-			log.Println("Error getting movies for actor", err)
+		}
+		err = json.Unmarshal(movies, &res.Movies)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error decoding movies of actor", "actorid", res.ActorId, "err", err)
 			return nil, nil, err
 		}
-		result = append(result, &models.ActorMovies{
-			ActorId: actor.ActorID,
-			Name:    actor.Name,
-			Movies:  movies,
-		})
+
+		result = append(result, res)
 	}
-	movie, err = s.GetMovieByID(id)
-	if errors.Is(err, models.ErrNoRecord) {
-		log.Println("No results", err)
-		return nil, nil, err
-	} else if err != nil {
-		log.Println("Error scanning rows", err)
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error getting actors and movies for movie from the table", "err", err)
 		return nil, nil, err
 	}
 	return result, movie, nil
 }
+
+func (s *ActorMovieStorage) GetActorsForMovies(ctx context.Context, movieids []int) (map[int][]*models.Actor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT am.movieid, a.actorid, a.name, a.gender, a.dateofbirth FROM actors a JOIN actormovie am ON a.actorid = am.actorid WHERE am.movieid = ANY($1) ORDER BY a.name`
+
+	rows, err := s.db.QueryContext(ctx, query, movieids)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting actors for movies from the table", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	actors := make(map[int][]*models.Actor, len(movieids))
+	for rows.Next() {
+		var movieid int
+		var actor models.Actor
+		err = rows.Scan(
+			&movieid,
+			&actor.ActorID,
+			&actor.Name,
+			&actor.Gender,
+			&actor.DateOfBirth,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
+			return nil, err
+		}
+
+		actors[movieid] = append(actors[movieid], &actor)
+	}
+	return actors, rows.Err()
+}
+
+func (s *ActorMovieStorage) GetMoviesForActors(ctx context.Context, actorids []int) (map[int][]*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT am.actorid, m.movieid, m.title, m.description, m.rating, m.releasedate FROM movies m JOIN actormovie am ON m.movieid = am.movieid WHERE am.actorid = ANY($1) ORDER BY m.releasedate`
+
+	rows, err := s.db.QueryContext(ctx, query, actorids)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting movies for actors from the table", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	movies := make(map[int][]*models.Movie, len(actorids))
+	for rows.Next() {
+		var actorid int
+		var movie models.Movie
+		err = rows.Scan(
+			&actorid,
+			&movie.MovieID,
+			&movie.Title,
+			&movie.Description,
+			&movie.Rating,
+			&movie.ReleaseDate,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
+			return nil, err
+		}
+
+		movies[actorid] = append(movies[actorid], &movie)
+	}
+	return movies, rows.Err()
+}
+
+func (s *ActorMovieStorage) GetCoStars(ctx context.Context, actorids []int, opts models.PathOptions) ([]*models.CoStarLink, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT a.actorid, a.movieid, b.actorid
+FROM actormovie a
+         JOIN actormovie b ON a.movieid = b.movieid AND b.actorid <> a.actorid
+         JOIN movies m ON m.movieid = a.movieid
+WHERE a.actorid = ANY($1)
+  AND ($2::int IS NULL OR EXTRACT(YEAR FROM m.releasedate) >= $2)
+  AND ($3::int IS NULL OR EXTRACT(YEAR FROM m.releasedate) <= $3)
+ORDER BY a.actorid, b.actorid, m.releasedate`
+
+	var fromYear, toYear any
+	if opts.FromYear > 0 {
+		fromYear = opts.FromYear
+	}
+	if opts.ToYear > 0 {
+		toYear = opts.ToYear
+	}
+	rows, err := s.db.QueryContext(ctx, query, actorids, fromYear, toYear)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting co-stars from the table", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var links []*models.CoStarLink
+	for rows.Next() {
+		var link models.CoStarLink
+		err = rows.Scan(
+			&link.ActorID,
+			&link.MovieID,
+			&link.CoStarID,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
+			return nil, err
+		}
+
+		links = append(links, &link)
+	}
+	return links, rows.Err()
+}
+
+func (s *ActorMovieStorage) GetAllCredits(ctx context.Context) (map[int][]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT movieid, actorid FROM actormovie`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting credits from the table", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	credits := make(map[int][]int)
+	for rows.Next() {
+		var movieid, actorid int
+		err = rows.Scan(&movieid, &actorid)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning credit rows", "err", err)
+			return nil, err
+		}
+		credits[movieid] = append(credits[movieid], actorid)
+	}
+	return credits, rows.Err()
+}
+
+func (s *ActorMovieStorage) GetTopCoStars(ctx context.Context, actorid int, limit int) ([]*models.CoStar, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT a.actorid, a.name, COUNT(*) AS movies
+FROM actormovie self
+         JOIN actormovie other ON other.movieid = self.movieid AND other.actorid <> self.actorid
+         JOIN actors a ON a.actorid = other.actorid
+WHERE self.actorid = $1
+GROUP BY a.actorid, a.name
+ORDER BY movies DESC, a.name
+LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, actorid, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting co-stars of actor from the table", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var costars []*models.CoStar
+	for rows.Next() {
+		var costar models.CoStar
+		err = rows.Scan(
+			&costar.ActorID,
+			&costar.Name,
+			&costar.Movies,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning co-star rows", "err", err)
+			return nil, err
+		}
+		costars = append(costars, &costar)
+	}
+	return costars, rows.Err()
+}
@@ -0,0 +1,40 @@
+package actormoviestorage
+
+import (
+	"database/sql"
+	"filmoteka/internal/storage/migrations"
+	"filmoteka/internal/storage/storagetest"
+	"filmoteka/internal/tracing"
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"os"
+	"testing"
+	"time"
+)
+
+// openTestDB connects to the Postgres database named by
+// FILMOTEKA_TEST_POSTGRES_DSN, skipping the test without one.
+func openTestDB(tb testing.TB) *sql.DB {
+	dsn := os.Getenv("FILMOTEKA_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		tb.Skip("FILMOTEKA_TEST_POSTGRES_DSN not set")
+	}
+	db, err := tracing.OpenDB("pgx", dsn)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.Close() })
+	if err = migrations.Run(db, time.Minute); err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+func TestGetActorsAndMoviesForMovieStatements(t *testing.T) {
+	db := openTestDB(t)
+	storagetest.CastStatements(t, db, New(db, 5*time.Second))
+}
+
+func BenchmarkGetActorsAndMoviesForMovie(b *testing.B) {
+	db := openTestDB(b)
+	storagetest.BenchmarkCast(b, db, New(db, 5*time.Second))
+}
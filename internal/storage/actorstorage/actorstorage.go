@@ -5,39 +5,40 @@ import (
 	"database/sql"
 	"encoding/json"
 	"filmoteka/internal/domain/models"
-	"log"
+	"log/slog"
 	"time"
 )
 
-var DbTimeout = 5 * time.Second
-
 type ActorStorage struct {
-	db *sql.DB
+	db      *sql.DB
+	timeout time.Duration
 }
 
-func New(db *sql.DB) *ActorStorage {
+// New returns a storage on db that gives every query up to timeout.
+func New(db *sql.DB, timeout time.Duration) *ActorStorage {
 	return &ActorStorage{
-		db: db,
+		db:      db,
+		timeout: timeout,
 	}
 }
 
-func (s *ActorStorage) GetAllActors() ([]*models.Actor, error) {
+func (s *ActorStorage) GetAllActors(ctx context.Context) ([]*models.Actor, error) {
 	var actors []*models.Actor
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	query := `SELECT actorid, name, gender, dateofbirth
+	query := `SELECT actorid, name, gender, dateofbirth, updated_at
 	FROM actors ORDER BY name`
 
 	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
-		log.Println("Error getting all actors from the table", err)
+		slog.ErrorContext(ctx, "Error getting all actors from the table", "err", err)
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -48,16 +49,17 @@ func (s *ActorStorage) GetAllActors() ([]*models.Actor, error) {
 			&actor.Name,
 			&actor.Gender,
 			&actor.DateOfBirth,
+			&actor.UpdatedAt,
 		)
 		if err != nil {
-			log.Println("Error scanning actor rows", err)
+			slog.ErrorContext(ctx, "Error scanning actor rows", "err", err)
 			return nil, err
 		}
 
 		actors = append(actors, &actor)
 	}
 	if len(actors) < 1 {
-		log.Println("No actors found in the table")
+		slog.DebugContext(ctx, "No actors found in the table")
 		return nil, models.ErrNoRecord
 	}
 
@@ -65,36 +67,34 @@ func (s *ActorStorage) GetAllActors() ([]*models.Actor, error) {
 
 }
 
-func (s *ActorStorage) CreateActor(a *models.Actor) (*models.Actor, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *ActorStorage) CreateActor(ctx context.Context, a *models.Actor) (*models.Actor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	query := `INSERT INTO actors (name, gender, dateofbirth)
-	values ($1, $2, $3)`
-	_, err := s.db.ExecContext(ctx, query, a.Name, a.Gender, a.DateOfBirth)
+	values ($1, $2, $3) RETURNING actorid`
+	err := s.db.QueryRowContext(ctx, query, a.Name, a.Gender, a.DateOfBirth).Scan(&a.ActorID)
 	if err != nil {
-		log.Println("Error inserting actor into a table", err)
-		encoder, _ := json.Marshal(a)
-		log.Println("Actor: ", string(encoder))
+		slog.ErrorContext(ctx, "Error inserting actor into a table", "err", err)
 		return nil, err
 	}
 	return a, nil
 }
 
-func (s *ActorStorage) GetActorByID(id int) (*models.Actor, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *ActorStorage) GetActorByID(ctx context.Context, id int) (*models.Actor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-	query := `SELECT actorid, name, gender, dateofbirth FROM actors WHERE actorid = $1`
+	query := `SELECT actorid, name, gender, dateofbirth, updated_at FROM actors WHERE actorid = $1`
 	rows, err := s.db.QueryContext(ctx, query, id)
 	if err != nil {
-		log.Println("Error getting actor by id from the table", err)
+		slog.ErrorContext(ctx, "Error getting actor by id from the table", "err", err)
 		return nil, err
 	}
 
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -105,9 +105,10 @@ func (s *ActorStorage) GetActorByID(id int) (*models.Actor, error) {
 			&actor.Name,
 			&actor.Gender,
 			&actor.DateOfBirth,
+			&actor.UpdatedAt,
 		)
 		if err != nil {
-			log.Println("Error scanning actor rows", err)
+			slog.ErrorContext(ctx, "Error scanning actor rows", "err", err)
 			return nil, err
 		}
 	}
@@ -116,41 +117,58 @@ func (s *ActorStorage) GetActorByID(id int) (*models.Actor, error) {
 	if !actor.DateOfBirth.Valid {
 		return nil, models.ErrNoRecord
 	}
-	log.Println("Actor: ", actor.ActorID, actor.DateOfBirth.Time, actor.DateOfBirth.Valid)
 	return actor, nil
 }
 
-func (s *ActorStorage) UpdateActor(a *models.Actor) (*models.Actor, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *ActorStorage) UpdateActor(ctx context.Context, a *models.Actor) (*models.Actor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-	log.Println(a.DateOfBirth)
 	formattedDOB, _ := json.Marshal(a.DateOfBirth.Time)
-	log.Println("Formatted DOB: ", string(formattedDOB))
 	query := `UPDATE actors SET name = CASE WHEN $1 = '' THEN name ELSE COALESCE($1) END, gender = CASE WHEN $2 = '' THEN gender ELSE COALESCE($2) END, dateofbirth = CASE WHEN $3 < '1000-1-1' THEN dateofbirth ELSE CAST(COALESCE($3) AS DATE) END WHERE actorid = ($4)`
 	_, err := s.db.ExecContext(ctx, query, a.Name, a.Gender, formattedDOB, a.ActorID)
 	if err != nil {
-		log.Println("Error updating actor in the table", err)
-		encoder, _ := json.Marshal(a)
-		log.Println("Actor: ", string(encoder))
+		slog.ErrorContext(ctx, "Error updating actor in the table", "actorid", a.ActorID, "err", err)
 		return nil, err
 	}
-	res, err := s.GetActorByID(a.ActorID)
-	log.Println("Updated actor: ", res)
+	res, err := s.GetActorByID(ctx, a.ActorID)
 	if err != nil {
-		log.Println("Error error returning updated actor from the table", err)
+		slog.ErrorContext(ctx, "Error error returning updated actor from the table", "err", err)
 		return nil, err
 	}
 	return res, nil
 }
 
-func (s *ActorStorage) DeleteActor(id int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *ActorStorage) DeleteActor(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 	query := `DELETE FROM actors WHERE actorid = $1`
-	_, err := s.db.ExecContext(ctx, query, id)
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting actor from the table", "err", err)
+		return err
+	}
+	n, err := res.RowsAffected()
 	if err != nil {
-		log.Println("Error deleting actor from the table", err)
 		return err
 	}
+	if n == 0 {
+		return models.ErrNoRecord
+	}
 	return nil
 }
+
+// GetActorsModifiedAt returns the time of the last insert, update or delete
+// on the actors table.
+func (s *ActorStorage) GetActorsModifiedAt(ctx context.Context) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var changed time.Time
+	query := `SELECT changed_at FROM table_changes WHERE table_name = 'actors'`
+	err := s.db.QueryRowContext(ctx, query).Scan(&changed)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting last change of the actors table", "err", err)
+		return time.Time{}, err
+	}
+	return changed, nil
+}
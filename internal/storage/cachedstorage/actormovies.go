@@ -0,0 +1,145 @@
+package cachedstorage
+
+import (
+	"context"
+	"filmoteka/internal/cache"
+	"filmoteka/internal/domain/models"
+	"fmt"
+)
+
+type actorMovieStorage interface {
+	GetActorsForMovie(ctx context.Context, id int) ([]*models.Actor, *models.Movie, error)
+	GetMoviesForActor(ctx context.Context, actorid int) ([]*models.Movie, *models.Actor, error)
+	GetActorsAndMoviesForMovie(ctx context.Context, id int, opts models.CastOptions) ([]*models.ActorMovies, *models.Movie, error)
+	AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error)
+	DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error)
+	GetMovieByActorName(ctx context.Context, name string, surname string) ([]*models.MovieWithActor, error)
+	GetActorsForMovies(ctx context.Context, movieids []int) (map[int][]*models.Actor, error)
+	GetMoviesForActors(ctx context.Context, actorids []int) (map[int][]*models.Movie, error)
+	GetCoStars(ctx context.Context, actorids []int, opts models.PathOptions) ([]*models.CoStarLink, error)
+	GetTopCoStars(ctx context.Context, actorid int, limit int) ([]*models.CoStar, error)
+	GetAllCredits(ctx context.Context) (map[int][]int, error)
+	GetActorByID(ctx context.Context, id int) (*models.Actor, error)
+	GetMovieByID(ctx context.Context, id int) (*models.Movie, error)
+}
+
+// ActorMovieStorage caches the per-movie and per-actor reads. The batched
+// lookups used by the GraphQL loaders and the path search vary too much to
+// be worth caching and go straight to the wrapped storage.
+type ActorMovieStorage struct {
+	storage actorMovieStorage
+	cache   cache.Cache
+}
+
+func NewActorMovieStorage(storage actorMovieStorage, c cache.Cache) *ActorMovieStorage {
+	return &ActorMovieStorage{
+		storage: storage,
+		cache:   c,
+	}
+}
+
+type cast struct {
+	actors []*models.Actor
+	movie  *models.Movie
+}
+
+func cloneCast(c cast) cast {
+	return cast{actors: cloneAll(c.actors), movie: cloneOne(c.movie)}
+}
+
+type filmography struct {
+	movies []*models.Movie
+	actor  *models.Actor
+}
+
+func cloneFilmography(f filmography) filmography {
+	return filmography{movies: cloneAll(f.movies), actor: cloneOne(f.actor)}
+}
+
+type castFilmographies struct {
+	actors []*models.ActorMovies
+	movie  *models.Movie
+}
+
+func cloneCastFilmographies(c castFilmographies) castFilmographies {
+	return castFilmographies{actors: cloneActorMovies(c.actors), movie: cloneOne(c.movie)}
+}
+
+func (s *ActorMovieStorage) GetActorsForMovie(ctx context.Context, id int) ([]*models.Actor, *models.Movie, error) {
+	res, err := cached(s.cache, fmt.Sprintf("%scast:%d", creditsPrefix, id), cloneCast, func() (cast, error) {
+		actors, movie, err := s.storage.GetActorsForMovie(ctx, id)
+		return cast{actors: actors, movie: movie}, err
+	})
+	return res.actors, res.movie, err
+}
+
+func (s *ActorMovieStorage) GetMoviesForActor(ctx context.Context, actorid int) ([]*models.Movie, *models.Actor, error) {
+	res, err := cached(s.cache, fmt.Sprintf("%sfilmography:%d", creditsPrefix, actorid), cloneFilmography, func() (filmography, error) {
+		movies, actor, err := s.storage.GetMoviesForActor(ctx, actorid)
+		return filmography{movies: movies, actor: actor}, err
+	})
+	return res.movies, res.actor, err
+}
+
+func (s *ActorMovieStorage) GetActorsAndMoviesForMovie(ctx context.Context, id int, opts models.CastOptions) ([]*models.ActorMovies, *models.Movie, error) {
+	key := fmt.Sprintf("%scastfilmographies:%d:%d:%d", creditsPrefix, id, opts.Depth, opts.MoviesPerActor)
+	res, err := cached(s.cache, key, cloneCastFilmographies, func() (castFilmographies, error) {
+		actors, movie, err := s.storage.GetActorsAndMoviesForMovie(ctx, id, opts)
+		return castFilmographies{actors: actors, movie: movie}, err
+	})
+	return res.actors, res.movie, err
+}
+
+func (s *ActorMovieStorage) GetMovieByActorName(ctx context.Context, name string, surname string) ([]*models.MovieWithActor, error) {
+	key := fmt.Sprintf("%sactorname:%q:%q", creditsPrefix, name, surname)
+	return cached(s.cache, key, cloneAll[models.MovieWithActor], func() ([]*models.MovieWithActor, error) {
+		return s.storage.GetMovieByActorName(ctx, name, surname)
+	})
+}
+
+func (s *ActorMovieStorage) GetTopCoStars(ctx context.Context, actorid int, limit int) ([]*models.CoStar, error) {
+	key := fmt.Sprintf("%scostars:%d:%d", creditsPrefix, actorid, limit)
+	return cached(s.cache, key, cloneAll[models.CoStar], func() ([]*models.CoStar, error) {
+		return s.storage.GetTopCoStars(ctx, actorid, limit)
+	})
+}
+
+func (s *ActorMovieStorage) GetActorByID(ctx context.Context, id int) (*models.Actor, error) {
+	return cached(s.cache, actorKey(id), cloneOne[models.Actor], func() (*models.Actor, error) {
+		return s.storage.GetActorByID(ctx, id)
+	})
+}
+
+func (s *ActorMovieStorage) GetMovieByID(ctx context.Context, id int) (*models.Movie, error) {
+	return cached(s.cache, movieKey(id), cloneOne[models.Movie], func() (*models.Movie, error) {
+		return s.storage.GetMovieByID(ctx, id)
+	})
+}
+
+func (s *ActorMovieStorage) GetActorsForMovies(ctx context.Context, movieids []int) (map[int][]*models.Actor, error) {
+	return s.storage.GetActorsForMovies(ctx, movieids)
+}
+
+func (s *ActorMovieStorage) GetMoviesForActors(ctx context.Context, actorids []int) (map[int][]*models.Movie, error) {
+	return s.storage.GetMoviesForActors(ctx, actorids)
+}
+
+func (s *ActorMovieStorage) GetCoStars(ctx context.Context, actorids []int, opts models.PathOptions) ([]*models.CoStarLink, error) {
+	return s.storage.GetCoStars(ctx, actorids, opts)
+}
+
+func (s *ActorMovieStorage) GetAllCredits(ctx context.Context) (map[int][]int, error) {
+	return s.storage.GetAllCredits(ctx)
+}
+
+func (s *ActorMovieStorage) AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error) {
+	actor, movie, created, err := s.storage.AddActorToMovie(ctx, actorid, movieid)
+	s.cache.DeletePrefix(creditsPrefix)
+	return actor, movie, created, err
+}
+
+func (s *ActorMovieStorage) DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error) {
+	actor, movie, err := s.storage.DeleteActorFromMovie(ctx, actorid, movieid)
+	s.cache.DeletePrefix(creditsPrefix)
+	return actor, movie, err
+}
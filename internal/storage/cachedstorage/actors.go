@@ -0,0 +1,71 @@
+package cachedstorage
+
+import (
+	"context"
+	"filmoteka/internal/cache"
+	"filmoteka/internal/domain/models"
+	"time"
+)
+
+type actorStorage interface {
+	GetAllActors(ctx context.Context) ([]*models.Actor, error)
+	CreateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	GetActorByID(ctx context.Context, id int) (*models.Actor, error)
+	UpdateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	DeleteActor(ctx context.Context, id int) error
+	GetActorsModifiedAt(ctx context.Context) (time.Time, error)
+}
+
+type ActorStorage struct {
+	storage actorStorage
+	cache   cache.Cache
+}
+
+func NewActorStorage(storage actorStorage, c cache.Cache) *ActorStorage {
+	return &ActorStorage{
+		storage: storage,
+		cache:   c,
+	}
+}
+
+func (s *ActorStorage) GetAllActors(ctx context.Context) ([]*models.Actor, error) {
+	return cached(s.cache, actorsPrefix+"all", cloneAll[models.Actor], func() ([]*models.Actor, error) {
+		return s.storage.GetAllActors(ctx)
+	})
+}
+
+func (s *ActorStorage) GetActorByID(ctx context.Context, id int) (*models.Actor, error) {
+	return cached(s.cache, actorKey(id), cloneOne[models.Actor], func() (*models.Actor, error) {
+		return s.storage.GetActorByID(ctx, id)
+	})
+}
+
+func (s *ActorStorage) GetActorsModifiedAt(ctx context.Context) (time.Time, error) {
+	return cached(s.cache, actorsPrefix+"modified", func(t time.Time) time.Time { return t }, func() (time.Time, error) {
+		return s.storage.GetActorsModifiedAt(ctx)
+	})
+}
+
+func (s *ActorStorage) CreateActor(ctx context.Context, a *models.Actor) (*models.Actor, error) {
+	actor, err := s.storage.CreateActor(ctx, a)
+	s.cache.DeletePrefix(actorsPrefix)
+	return actor, err
+}
+
+func (s *ActorStorage) UpdateActor(ctx context.Context, a *models.Actor) (*models.Actor, error) {
+	actor, err := s.storage.UpdateActor(ctx, a)
+	s.invalidate(a.ActorID)
+	return actor, err
+}
+
+func (s *ActorStorage) DeleteActor(ctx context.Context, id int) error {
+	err := s.storage.DeleteActor(ctx, id)
+	s.invalidate(id)
+	return err
+}
+
+func (s *ActorStorage) invalidate(id int) {
+	s.cache.Delete(actorKey(id))
+	s.cache.DeletePrefix(actorsPrefix)
+	s.cache.DeletePrefix(creditsPrefix)
+}
@@ -0,0 +1,72 @@
+// Package cachedstorage decorates the storages with a read-through cache.
+// Reads are served from the cache when possible, writes go to the wrapped
+// storage and drop the entries they may have made stale.
+//
+// Cache keys are grouped by prefix: "movie:<id>" and "actor:<id>" hold single
+// entities, "movies:" and "actors:" lists and searches, and "credits:"
+// everything read through the actor-movie links, which embeds both.
+package cachedstorage
+
+import (
+	"filmoteka/internal/cache"
+	"filmoteka/internal/domain/models"
+	"fmt"
+)
+
+const (
+	moviesPrefix  = "movies:"
+	actorsPrefix  = "actors:"
+	creditsPrefix = "credits:"
+)
+
+func movieKey(id int) string {
+	return fmt.Sprintf("movie:%d", id)
+}
+
+func actorKey(id int) string {
+	return fmt.Sprintf("actor:%d", id)
+}
+
+// cached returns the value stored under key or loads and stores it. Callers
+// get their own copy so that they can modify it without touching the cache.
+// Errors are not cached, nor are values loaded while a write invalidated
+// the cache, since the load may have read the data from before the write.
+func cached[T any](c cache.Cache, key string, clone func(T) T, load func() (T, error)) (T, error) {
+	if v, ok := c.Get(key); ok {
+		return clone(v.(T)), nil
+	}
+	gen := c.Generation()
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+	c.SetAt(key, clone(v), gen)
+	return v, nil
+}
+
+func cloneOne[T any](v *T) *T {
+	if v == nil {
+		return nil
+	}
+	c := *v
+	return &c
+}
+
+func cloneAll[T any](s []*T) []*T {
+	if s == nil {
+		return nil
+	}
+	c := make([]*T, len(s))
+	for i, v := range s {
+		c[i] = cloneOne(v)
+	}
+	return c
+}
+
+func cloneActorMovies(s []*models.ActorMovies) []*models.ActorMovies {
+	c := cloneAll(s)
+	for _, am := range c {
+		am.Movies = cloneAll(am.Movies)
+	}
+	return c
+}
@@ -0,0 +1,90 @@
+package cachedstorage
+
+import (
+	"context"
+	"filmoteka/internal/cache"
+	"filmoteka/internal/domain/models"
+	"sync"
+	"testing"
+	"time"
+)
+
+// movies holds a single movie. A read takes its copy and then waits for
+// proceed, so that a write can land in between.
+type movies struct {
+	mu      sync.Mutex
+	movie   models.Movie
+	loading chan struct{}
+	proceed chan struct{}
+}
+
+func (s *movies) GetMovieByID(ctx context.Context, id int) (*models.Movie, error) {
+	s.mu.Lock()
+	movie := s.movie
+	s.mu.Unlock()
+	if s.loading != nil {
+		close(s.loading)
+		<-s.proceed
+	}
+	return &movie, nil
+}
+
+func (s *movies) UpdateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.movie = *m
+	return m, nil
+}
+
+func (s *movies) GetAllMovies(ctx context.Context, param string) ([]*models.Movie, error) {
+	return nil, models.ErrNoRecord
+}
+
+func (s *movies) CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error) {
+	return m, nil
+}
+
+func (s *movies) DeleteMovie(ctx context.Context, id int) error {
+	return nil
+}
+
+func (s *movies) GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error) {
+	return nil, models.ErrNoRecord
+}
+
+func (s *movies) GetMoviesModifiedAt(ctx context.Context) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func TestReadAcrossWriteIsNotCached(t *testing.T) {
+	ctx := context.Background()
+	backend := &movies{
+		movie:   models.Movie{MovieID: 1, Title: "old"},
+		loading: make(chan struct{}),
+		proceed: make(chan struct{}),
+	}
+	s := NewMovieStorage(backend, cache.NewLRU(10, time.Hour))
+
+	read := make(chan *models.Movie)
+	go func() {
+		movie, _ := s.GetMovieByID(ctx, 1)
+		read <- movie
+	}()
+	<-backend.loading
+	if _, err := s.UpdateMovie(ctx, &models.Movie{MovieID: 1, Title: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	close(backend.proceed)
+	if movie := <-read; movie.Title != "old" {
+		t.Fatalf("read across the write got %q, want the old title", movie.Title)
+	}
+
+	backend.loading = nil
+	movie, err := s.GetMovieByID(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movie.Title != "new" {
+		t.Errorf("got %q after the write, want %q", movie.Title, "new")
+	}
+}
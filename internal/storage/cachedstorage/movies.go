@@ -0,0 +1,78 @@
+package cachedstorage
+
+import (
+	"context"
+	"filmoteka/internal/cache"
+	"filmoteka/internal/domain/models"
+	"time"
+)
+
+type movieStorage interface {
+	GetAllMovies(ctx context.Context, param string) ([]*models.Movie, error)
+	CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	GetMovieByID(ctx context.Context, id int) (*models.Movie, error)
+	UpdateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	DeleteMovie(ctx context.Context, id int) error
+	GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error)
+	GetMoviesModifiedAt(ctx context.Context) (time.Time, error)
+}
+
+type MovieStorage struct {
+	storage movieStorage
+	cache   cache.Cache
+}
+
+func NewMovieStorage(storage movieStorage, c cache.Cache) *MovieStorage {
+	return &MovieStorage{
+		storage: storage,
+		cache:   c,
+	}
+}
+
+func (s *MovieStorage) GetAllMovies(ctx context.Context, param string) ([]*models.Movie, error) {
+	return cached(s.cache, moviesPrefix+"sort:"+param, cloneAll[models.Movie], func() ([]*models.Movie, error) {
+		return s.storage.GetAllMovies(ctx, param)
+	})
+}
+
+func (s *MovieStorage) GetMovieByID(ctx context.Context, id int) (*models.Movie, error) {
+	return cached(s.cache, movieKey(id), cloneOne[models.Movie], func() (*models.Movie, error) {
+		return s.storage.GetMovieByID(ctx, id)
+	})
+}
+
+func (s *MovieStorage) GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error) {
+	return cached(s.cache, moviesPrefix+"name:"+moviename, cloneAll[models.Movie], func() ([]*models.Movie, error) {
+		return s.storage.GetMovieByMovieName(ctx, moviename)
+	})
+}
+
+func (s *MovieStorage) GetMoviesModifiedAt(ctx context.Context) (time.Time, error) {
+	return cached(s.cache, moviesPrefix+"modified", func(t time.Time) time.Time { return t }, func() (time.Time, error) {
+		return s.storage.GetMoviesModifiedAt(ctx)
+	})
+}
+
+func (s *MovieStorage) CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error) {
+	movie, err := s.storage.CreateMovie(ctx, m)
+	s.cache.DeletePrefix(moviesPrefix)
+	return movie, err
+}
+
+func (s *MovieStorage) UpdateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error) {
+	movie, err := s.storage.UpdateMovie(ctx, m)
+	s.invalidate(m.MovieID)
+	return movie, err
+}
+
+func (s *MovieStorage) DeleteMovie(ctx context.Context, id int) error {
+	err := s.storage.DeleteMovie(ctx, id)
+	s.invalidate(id)
+	return err
+}
+
+func (s *MovieStorage) invalidate(id int) {
+	s.cache.Delete(movieKey(id))
+	s.cache.DeletePrefix(moviesPrefix)
+	s.cache.DeletePrefix(creditsPrefix)
+}
@@ -0,0 +1,121 @@
+package convert
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Counts are the numbers of rows copied.
+type Counts struct {
+	Users   int
+	Movies  int
+	Actors  int
+	Credits int
+}
+
+type table struct {
+	name    string
+	columns []string
+	// serial is the id column backed by a sequence in Postgres
+	serial string
+}
+
+// tables in an order that satisfies the foreign keys. Login failures,
+// password resets and rate limits are short-lived and not copied.
+var tables = []table{
+	{name: "users", columns: []string{"userid", "email", "password", "role"}, serial: "userid"},
+	{name: "movies", columns: []string{"movieid", "title", "description", "rating", "releasedate", "created_at", "updated_at"}, serial: "movieid"},
+	{name: "actors", columns: []string{"actorid", "name", "gender", "dateofbirth", "created_at", "updated_at"}, serial: "actorid"},
+	{name: "actormovie", columns: []string{"actorid", "movieid", "created_at"}},
+}
+
+// Copy copies the users and the catalog from src to dst in a single
+// transaction of dst, keeping their ids. Both have to be migrated, and dst
+// must not hold any users, movies or actors yet. With postgres set the id
+// sequences of dst are moved past the copied ids.
+func Copy(ctx context.Context, src *sql.DB, dst *sql.DB, postgres bool) (*Counts, error) {
+	for _, t := range tables {
+		var exists bool
+		err := dst.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+t.name+`)`).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf("destination table %s is not empty", t.name)
+		}
+	}
+
+	tx, err := dst.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	counts := make([]int, len(tables))
+	for i, t := range tables {
+		counts[i], err = copyTable(ctx, src, tx, t)
+		if err != nil {
+			return nil, fmt.Errorf("copying %s: %w", t.name, err)
+		}
+		if postgres && t.serial != "" {
+			query := fmt.Sprintf(`SELECT setval(pg_get_serial_sequence('%[1]s', '%[2]s'), COALESCE(MAX(%[2]s), 1), MAX(%[2]s) IS NOT NULL) FROM %[1]s`, t.name, t.serial)
+			_, err = tx.ExecContext(ctx, query)
+			if err != nil {
+				return nil, fmt.Errorf("resetting sequence of %s: %w", t.name, err)
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return &Counts{Users: counts[0], Movies: counts[1], Actors: counts[2], Credits: counts[3]}, nil
+}
+
+// copyTable inserts every row of t in src into tx, leaving the values to the
+// drivers to convert.
+func copyTable(ctx context.Context, src *sql.DB, tx *sql.Tx, t table) (n int, err error) {
+	columns := strings.Join(t.columns, ", ")
+	placeholders := make([]string, len(t.columns))
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+
+	insert, err := tx.PrepareContext(ctx, `INSERT INTO `+t.name+` (`+columns+`) VALUES (`+strings.Join(placeholders, ", ")+`)`)
+	if err != nil {
+		return 0, err
+	}
+	defer insert.Close()
+
+	rows, err := src.QueryContext(ctx, `SELECT `+columns+` FROM `+t.name)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		err = errors.Join(err, rows.Close())
+	}()
+
+	values := make([]any, len(t.columns))
+	dest := make([]any, len(t.columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		err = rows.Scan(dest...)
+		if err != nil {
+			return n, err
+		}
+		_, err = insert.ExecContext(ctx, values...)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, rows.Err()
+}
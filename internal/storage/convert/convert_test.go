@@ -0,0 +1,80 @@
+package convert
+
+import (
+	"context"
+	"database/sql"
+	"filmoteka/internal/storage/sqlitestorage"
+	"testing"
+	"time"
+)
+
+func openSQLite(t *testing.T, name string) *sql.DB {
+	db, err := sql.Open(sqlitestorage.DriverName, sqlitestorage.DriverDSN("sqlite:"+t.TempDir()+"/"+name+".db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err = sqlitestorage.Migrate(db, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestCopy(t *testing.T) {
+	ctx := context.Background()
+	src := openSQLite(t, "src")
+	dst := openSQLite(t, "dst")
+
+	// ids with gaps, which the copy has to keep
+	for _, query := range []string{
+		`INSERT INTO users (userid, email, password, role) VALUES (3, 'admin@example.com', 'hash', 'admin')`,
+		`INSERT INTO movies (movieid, title, description, rating, releasedate) VALUES (5, 'Solaris', '', 8.1, '1972-03-20'), (9, 'Stalker', '', 8.2, '1979-05-25')`,
+		`INSERT INTO actors (actorid, name, gender, dateofbirth) VALUES (4, 'Natalya Bondarchuk', 'female', '1950-05-10'), (8, 'Anatoly Solonitsyn', 'male', '1934-08-30')`,
+		`INSERT INTO actormovie (actorid, movieid) VALUES (4, 5), (8, 5), (8, 9)`,
+	} {
+		if _, err := src.ExecContext(ctx, query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	counts, err := Copy(ctx, src, dst, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Counts{Users: 1, Movies: 2, Actors: 2, Credits: 3}); *counts != want {
+		t.Errorf("got counts %+v, want %+v", *counts, want)
+	}
+
+	s := sqlitestorage.New(dst, 5*time.Second, 10*time.Second)
+	for actorID, want := range map[int][]string{4: {"Solaris"}, 8: {"Solaris", "Stalker"}} {
+		movies, _, err := s.GetMoviesForActor(ctx, actorID)
+		if err != nil {
+			t.Fatalf("movies of actor %d: %v", actorID, err)
+		}
+		var got []string
+		for _, m := range movies {
+			got = append(got, m.Title)
+		}
+		if len(got) != len(want) {
+			t.Errorf("actor %d: got movies %v, want %v", actorID, got, want)
+			continue
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("actor %d: got movies %v, want %v", actorID, got, want)
+				break
+			}
+		}
+	}
+	user, err := s.GetUserByEmail(ctx, "admin@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Role != "admin" {
+		t.Errorf("got role %q, want admin", user.Role)
+	}
+
+	if _, err = Copy(ctx, src, dst, false); err == nil {
+		t.Error("copy into a database with rows: got no error")
+	}
+}
@@ -0,0 +1,98 @@
+package instrumentedstorage
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"time"
+)
+
+type actorMovieStorage interface {
+	GetMovieByID(ctx context.Context, id int) (*models.Movie, error)
+	GetActorByID(ctx context.Context, id int) (*models.Actor, error)
+	AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error)
+	DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error)
+	GetActorsForMovie(ctx context.Context, id int) ([]*models.Actor, *models.Movie, error)
+	GetMoviesForActor(ctx context.Context, actorid int) ([]*models.Movie, *models.Actor, error)
+	GetMovieByActorName(ctx context.Context, name string, surname string) ([]*models.MovieWithActor, error)
+	GetActorsAndMoviesForMovie(ctx context.Context, id int, opts models.CastOptions) ([]*models.ActorMovies, *models.Movie, error)
+	GetActorsForMovies(ctx context.Context, movieids []int) (map[int][]*models.Actor, error)
+	GetMoviesForActors(ctx context.Context, actorids []int) (map[int][]*models.Movie, error)
+	GetCoStars(ctx context.Context, actorids []int, opts models.PathOptions) ([]*models.CoStarLink, error)
+	GetAllCredits(ctx context.Context) (map[int][]int, error)
+	GetTopCoStars(ctx context.Context, actorid int, limit int) ([]*models.CoStar, error)
+}
+
+type ActorMovieStorage struct {
+	storage actorMovieStorage
+}
+
+func NewActorMovieStorage(storage actorMovieStorage) *ActorMovieStorage {
+	return &ActorMovieStorage{
+		storage: storage,
+	}
+}
+
+func (s *ActorMovieStorage) GetMovieByID(ctx context.Context, id int) (movie *models.Movie, err error) {
+	defer observe("actormovie", "GetMovieByID", time.Now(), &err)
+	return s.storage.GetMovieByID(ctx, id)
+}
+
+func (s *ActorMovieStorage) GetActorByID(ctx context.Context, id int) (actor *models.Actor, err error) {
+	defer observe("actormovie", "GetActorByID", time.Now(), &err)
+	return s.storage.GetActorByID(ctx, id)
+}
+
+func (s *ActorMovieStorage) AddActorToMovie(ctx context.Context, actorid int, movieid int) (actor *models.Actor, movie *models.Movie, created bool, err error) {
+	defer observe("actormovie", "AddActorToMovie", time.Now(), &err)
+	return s.storage.AddActorToMovie(ctx, actorid, movieid)
+}
+
+func (s *ActorMovieStorage) DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (actor *models.Actor, movie *models.Movie, err error) {
+	defer observe("actormovie", "DeleteActorFromMovie", time.Now(), &err)
+	return s.storage.DeleteActorFromMovie(ctx, actorid, movieid)
+}
+
+func (s *ActorMovieStorage) GetActorsForMovie(ctx context.Context, id int) (actors []*models.Actor, movie *models.Movie, err error) {
+	defer observe("actormovie", "GetActorsForMovie", time.Now(), &err)
+	return s.storage.GetActorsForMovie(ctx, id)
+}
+
+func (s *ActorMovieStorage) GetMoviesForActor(ctx context.Context, actorid int) (movies []*models.Movie, actor *models.Actor, err error) {
+	defer observe("actormovie", "GetMoviesForActor", time.Now(), &err)
+	return s.storage.GetMoviesForActor(ctx, actorid)
+}
+
+func (s *ActorMovieStorage) GetMovieByActorName(ctx context.Context, name string, surname string) (movies []*models.MovieWithActor, err error) {
+	defer observe("actormovie", "GetMovieByActorName", time.Now(), &err)
+	return s.storage.GetMovieByActorName(ctx, name, surname)
+}
+
+func (s *ActorMovieStorage) GetActorsAndMoviesForMovie(ctx context.Context, id int, opts models.CastOptions) (actors []*models.ActorMovies, movie *models.Movie, err error) {
+	defer observe("actormovie", "GetActorsAndMoviesForMovie", time.Now(), &err)
+	return s.storage.GetActorsAndMoviesForMovie(ctx, id, opts)
+}
+
+func (s *ActorMovieStorage) GetActorsForMovies(ctx context.Context, movieids []int) (actors map[int][]*models.Actor, err error) {
+	defer observe("actormovie", "GetActorsForMovies", time.Now(), &err)
+	return s.storage.GetActorsForMovies(ctx, movieids)
+}
+
+func (s *ActorMovieStorage) GetMoviesForActors(ctx context.Context, actorids []int) (movies map[int][]*models.Movie, err error) {
+	defer observe("actormovie", "GetMoviesForActors", time.Now(), &err)
+	return s.storage.GetMoviesForActors(ctx, actorids)
+}
+
+func (s *ActorMovieStorage) GetCoStars(ctx context.Context, actorids []int, opts models.PathOptions) (links []*models.CoStarLink, err error) {
+	defer observe("actormovie", "GetCoStars", time.Now(), &err)
+	return s.storage.GetCoStars(ctx, actorids, opts)
+}
+
+func (s *ActorMovieStorage) GetAllCredits(ctx context.Context) (credits map[int][]int, err error) {
+	defer observe("actormovie", "GetAllCredits", time.Now(), &err)
+	return s.storage.GetAllCredits(ctx)
+}
+
+func (s *ActorMovieStorage) GetTopCoStars(ctx context.Context, actorid int, limit int) (costars []*models.CoStar, err error) {
+	defer observe("actormovie", "GetTopCoStars", time.Now(), &err)
+	return s.storage.GetTopCoStars(ctx, actorid, limit)
+}
@@ -0,0 +1,56 @@
+package instrumentedstorage
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"time"
+)
+
+type actorStorage interface {
+	GetAllActors(ctx context.Context) ([]*models.Actor, error)
+	CreateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	GetActorByID(ctx context.Context, id int) (*models.Actor, error)
+	UpdateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	DeleteActor(ctx context.Context, id int) error
+	GetActorsModifiedAt(ctx context.Context) (time.Time, error)
+}
+
+type ActorStorage struct {
+	storage actorStorage
+}
+
+func NewActorStorage(storage actorStorage) *ActorStorage {
+	return &ActorStorage{
+		storage: storage,
+	}
+}
+
+func (s *ActorStorage) GetAllActors(ctx context.Context) (actors []*models.Actor, err error) {
+	defer observe("actor", "GetAllActors", time.Now(), &err)
+	return s.storage.GetAllActors(ctx)
+}
+
+func (s *ActorStorage) CreateActor(ctx context.Context, a *models.Actor) (actor *models.Actor, err error) {
+	defer observe("actor", "CreateActor", time.Now(), &err)
+	return s.storage.CreateActor(ctx, a)
+}
+
+func (s *ActorStorage) GetActorByID(ctx context.Context, id int) (actor *models.Actor, err error) {
+	defer observe("actor", "GetActorByID", time.Now(), &err)
+	return s.storage.GetActorByID(ctx, id)
+}
+
+func (s *ActorStorage) UpdateActor(ctx context.Context, a *models.Actor) (actor *models.Actor, err error) {
+	defer observe("actor", "UpdateActor", time.Now(), &err)
+	return s.storage.UpdateActor(ctx, a)
+}
+
+func (s *ActorStorage) DeleteActor(ctx context.Context, id int) (err error) {
+	defer observe("actor", "DeleteActor", time.Now(), &err)
+	return s.storage.DeleteActor(ctx, id)
+}
+
+func (s *ActorStorage) GetActorsModifiedAt(ctx context.Context) (modified time.Time, err error) {
+	defer observe("actor", "GetActorsModifiedAt", time.Now(), &err)
+	return s.storage.GetActorsModifiedAt(ctx)
+}
@@ -0,0 +1,13 @@
+// Package instrumentedstorage decorates the storages with metrics: every
+// call is timed by storage and method.
+package instrumentedstorage
+
+import (
+	"filmoteka/internal/metrics"
+	"time"
+)
+
+// observe is deferred with the start of a call and its named error result.
+func observe(storage string, method string, start time.Time, err *error) {
+	metrics.ObserveStorage(storage, method, *err, time.Since(start))
+}
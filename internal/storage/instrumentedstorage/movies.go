@@ -0,0 +1,62 @@
+package instrumentedstorage
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"time"
+)
+
+type movieStorage interface {
+	GetAllMovies(ctx context.Context, sortParam string) ([]*models.Movie, error)
+	GetMovieByID(ctx context.Context, id int) (*models.Movie, error)
+	CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	UpdateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	DeleteMovie(ctx context.Context, id int) error
+	GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error)
+	GetMoviesModifiedAt(ctx context.Context) (time.Time, error)
+}
+
+type MovieStorage struct {
+	storage movieStorage
+}
+
+func NewMovieStorage(storage movieStorage) *MovieStorage {
+	return &MovieStorage{
+		storage: storage,
+	}
+}
+
+func (s *MovieStorage) GetAllMovies(ctx context.Context, sortParam string) (movies []*models.Movie, err error) {
+	defer observe("movie", "GetAllMovies", time.Now(), &err)
+	return s.storage.GetAllMovies(ctx, sortParam)
+}
+
+func (s *MovieStorage) GetMovieByID(ctx context.Context, id int) (movie *models.Movie, err error) {
+	defer observe("movie", "GetMovieByID", time.Now(), &err)
+	return s.storage.GetMovieByID(ctx, id)
+}
+
+func (s *MovieStorage) CreateMovie(ctx context.Context, m *models.Movie) (movie *models.Movie, err error) {
+	defer observe("movie", "CreateMovie", time.Now(), &err)
+	return s.storage.CreateMovie(ctx, m)
+}
+
+func (s *MovieStorage) UpdateMovie(ctx context.Context, m *models.Movie) (movie *models.Movie, err error) {
+	defer observe("movie", "UpdateMovie", time.Now(), &err)
+	return s.storage.UpdateMovie(ctx, m)
+}
+
+func (s *MovieStorage) DeleteMovie(ctx context.Context, id int) (err error) {
+	defer observe("movie", "DeleteMovie", time.Now(), &err)
+	return s.storage.DeleteMovie(ctx, id)
+}
+
+func (s *MovieStorage) GetMovieByMovieName(ctx context.Context, moviename string) (movies []*models.Movie, err error) {
+	defer observe("movie", "GetMovieByMovieName", time.Now(), &err)
+	return s.storage.GetMovieByMovieName(ctx, moviename)
+}
+
+func (s *MovieStorage) GetMoviesModifiedAt(ctx context.Context) (modified time.Time, err error) {
+	defer observe("movie", "GetMoviesModifiedAt", time.Now(), &err)
+	return s.storage.GetMoviesModifiedAt(ctx)
+}
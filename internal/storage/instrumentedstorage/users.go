@@ -0,0 +1,62 @@
+package instrumentedstorage
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"time"
+)
+
+type userStorage interface {
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	GetLoginFailures(ctx context.Context, key string) (*models.LoginFailures, error)
+	RecordLoginFailure(ctx context.Context, key string, resetAfter time.Duration) (*models.LoginFailures, error)
+	ClearLoginFailures(ctx context.Context, key string) error
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+	CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (*models.User, error)
+}
+
+type UserStorage struct {
+	storage userStorage
+}
+
+func NewUserStorage(storage userStorage) *UserStorage {
+	return &UserStorage{
+		storage: storage,
+	}
+}
+
+func (s *UserStorage) GetUserByEmail(ctx context.Context, email string) (user *models.User, err error) {
+	defer observe("user", "GetUserByEmail", time.Now(), &err)
+	return s.storage.GetUserByEmail(ctx, email)
+}
+
+func (s *UserStorage) GetLoginFailures(ctx context.Context, key string) (failures *models.LoginFailures, err error) {
+	defer observe("user", "GetLoginFailures", time.Now(), &err)
+	return s.storage.GetLoginFailures(ctx, key)
+}
+
+func (s *UserStorage) RecordLoginFailure(ctx context.Context, key string, resetAfter time.Duration) (failures *models.LoginFailures, err error) {
+	defer observe("user", "RecordLoginFailure", time.Now(), &err)
+	return s.storage.RecordLoginFailure(ctx, key, resetAfter)
+}
+
+func (s *UserStorage) ClearLoginFailures(ctx context.Context, key string) (err error) {
+	defer observe("user", "ClearLoginFailures", time.Now(), &err)
+	return s.storage.ClearLoginFailures(ctx, key)
+}
+
+func (s *UserStorage) UpdatePassword(ctx context.Context, userID int, passwordHash string) (err error) {
+	defer observe("user", "UpdatePassword", time.Now(), &err)
+	return s.storage.UpdatePassword(ctx, userID, passwordHash)
+}
+
+func (s *UserStorage) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) (err error) {
+	defer observe("user", "CreatePasswordReset", time.Now(), &err)
+	return s.storage.CreatePasswordReset(ctx, userID, tokenHash, expiresAt)
+}
+
+func (s *UserStorage) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (user *models.User, err error) {
+	defer observe("user", "ResetPassword", time.Now(), &err)
+	return s.storage.ResetPassword(ctx, tokenHash, passwordHash)
+}
@@ -0,0 +1,329 @@
+package memorystorage
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"log/slog"
+	"sort"
+)
+
+func (s *Storage) AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, m, err := s.creditOf(ctx, actorid, movieid)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	c := credit{actorID: actorid, movieID: movieid}
+	if _, ok := s.credits[c]; ok {
+		return a, m, false, nil
+	}
+	s.credits[c] = s.now()
+	return a, m, true, nil
+}
+
+func (s *Storage) DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, m, err := s.creditOf(ctx, actorid, movieid)
+	if err != nil {
+		return nil, nil, err
+	}
+	delete(s.credits, credit{actorID: actorid, movieID: movieid})
+	return a, m, nil
+}
+
+// creditOf looks up both sides of a credit, models.ErrNoRecord if either is
+// missing. The caller holds the lock.
+func (s *Storage) creditOf(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error) {
+	a, err := s.actor(actorid)
+	if err != nil {
+		slog.DebugContext(ctx, "No results", "err", err)
+		return nil, nil, err
+	}
+	m, err := s.movie(movieid)
+	if err != nil {
+		slog.DebugContext(ctx, "No results", "err", err)
+		return nil, nil, err
+	}
+	return a, m, nil
+}
+
+func (s *Storage) GetActorsForMovie(ctx context.Context, id int) ([]*models.Actor, *models.Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	movie, err := s.movie(id)
+	if err != nil {
+		slog.DebugContext(ctx, "No results", "err", err)
+		return nil, nil, err
+	}
+
+	var actors []*models.Actor
+	for _, row := range s.castOf(id) {
+		actors = append(actors, actorWithoutUpdatedAt(row.actor))
+	}
+	return actors, movie, nil
+}
+
+func (s *Storage) GetMoviesForActor(ctx context.Context, actorid int) ([]*models.Movie, *models.Actor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	actor, err := s.actor(actorid)
+	if err != nil {
+		slog.DebugContext(ctx, "No results", "err", err)
+		return nil, nil, err
+	}
+
+	var movies []*models.Movie
+	for _, row := range s.moviesOf(actorid) {
+		movies = append(movies, withoutUpdatedAt(row.movie))
+	}
+	return movies, actor, nil
+}
+
+func (s *Storage) GetMovieByActorName(ctx context.Context, name string, surname string) ([]*models.MovieWithActor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var movies []*models.MovieWithActor
+	for _, c := range s.sortedCredits() {
+		a, m := s.actors[c.actorID].actor, s.movies[c.movieID].movie
+		if !ilike(a.Name, name) || !ilike(a.Name, surname) {
+			continue
+		}
+		movies = append(movies, &models.MovieWithActor{
+			MovieID:     m.MovieID,
+			Title:       m.Title,
+			Description: m.Description,
+			Rating:      m.Rating,
+			ReleaseDate: m.ReleaseDate,
+			ActorName:   a.Name,
+		})
+	}
+	return movies, nil
+}
+
+// GetActorsAndMoviesForMovie returns the cast of a movie, ordered by name,
+// with the filmography of every cast member, newest first.
+func (s *Storage) GetActorsAndMoviesForMovie(ctx context.Context, id int, opts models.CastOptions) ([]*models.ActorMovies, *models.Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	movie, err := s.movie(id)
+	if err != nil {
+		slog.DebugContext(ctx, "No results", "err", err)
+		return nil, nil, err
+	}
+
+	cast := s.castOf(id)
+	sort.SliceStable(cast, func(i, j int) bool { return lessString(cast[i].actor.Name, cast[j].actor.Name) })
+
+	var result []*models.ActorMovies
+	for _, row := range cast {
+		res := &models.ActorMovies{ActorId: row.actor.ActorID, Name: row.actor.Name, Movies: []*models.Movie{}}
+		if opts.Depth != 1 {
+			movies := s.moviesOf(row.actor.ActorID)
+			sort.SliceStable(movies, func(i, j int) bool {
+				return movies[i].movie.ReleaseDate.Time.After(movies[j].movie.ReleaseDate.Time)
+			})
+			if opts.MoviesPerActor > 0 && len(movies) > opts.MoviesPerActor {
+				movies = movies[:opts.MoviesPerActor]
+			}
+			for _, m := range movies {
+				res.Movies = append(res.Movies, withoutUpdatedAt(m.movie))
+			}
+		}
+		result = append(result, res)
+	}
+	return result, movie, nil
+}
+
+func (s *Storage) GetActorsForMovies(ctx context.Context, movieids []int) (map[int][]*models.Actor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	actors := make(map[int][]*models.Actor, len(movieids))
+	for _, movieid := range uniq(movieids) {
+		cast := s.castOf(movieid)
+		sort.SliceStable(cast, func(i, j int) bool { return lessString(cast[i].actor.Name, cast[j].actor.Name) })
+		for _, row := range cast {
+			actors[movieid] = append(actors[movieid], actorWithoutUpdatedAt(row.actor))
+		}
+	}
+	return actors, nil
+}
+
+func (s *Storage) GetMoviesForActors(ctx context.Context, actorids []int) (map[int][]*models.Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	movies := make(map[int][]*models.Movie, len(actorids))
+	for _, actorid := range uniq(actorids) {
+		rows := s.moviesOf(actorid)
+		sort.SliceStable(rows, func(i, j int) bool {
+			return rows[i].movie.ReleaseDate.Time.Before(rows[j].movie.ReleaseDate.Time)
+		})
+		for _, row := range rows {
+			movies[actorid] = append(movies[actorid], withoutUpdatedAt(row.movie))
+		}
+	}
+	return movies, nil
+}
+
+func (s *Storage) GetCoStars(ctx context.Context, actorids []int, opts models.PathOptions) ([]*models.CoStarLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	type link struct {
+		models.CoStarLink
+		released models.Date
+	}
+	var links []link
+	for _, actorid := range uniq(actorids) {
+		for _, m := range s.moviesOf(actorid) {
+			year := yearOf(m.movie.ReleaseDate)
+			if (opts.FromYear > 0 && year < opts.FromYear) || (opts.ToYear > 0 && year > opts.ToYear) {
+				continue
+			}
+			for _, costar := range s.castOf(m.movie.MovieID) {
+				if costar.actor.ActorID == actorid {
+					continue
+				}
+				links = append(links, link{
+					CoStarLink: models.CoStarLink{ActorID: actorid, MovieID: m.movie.MovieID, CoStarID: costar.actor.ActorID},
+					released:   m.movie.ReleaseDate,
+				})
+			}
+		}
+	}
+	sort.SliceStable(links, func(i, j int) bool {
+		a, b := links[i], links[j]
+		if a.ActorID != b.ActorID {
+			return a.ActorID < b.ActorID
+		}
+		if a.CoStarID != b.CoStarID {
+			return a.CoStarID < b.CoStarID
+		}
+		return a.released.Time.Before(b.released.Time)
+	})
+
+	var result []*models.CoStarLink
+	for _, l := range links {
+		costar := l.CoStarLink
+		result = append(result, &costar)
+	}
+	return result, nil
+}
+
+func (s *Storage) GetAllCredits(ctx context.Context) (map[int][]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	credits := make(map[int][]int)
+	for _, c := range s.sortedCredits() {
+		credits[c.movieID] = append(credits[c.movieID], c.actorID)
+	}
+	return credits, nil
+}
+
+func (s *Storage) GetTopCoStars(ctx context.Context, actorid int, limit int) ([]*models.CoStar, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[int]*models.CoStar)
+	for _, m := range s.moviesOf(actorid) {
+		for _, row := range s.castOf(m.movie.MovieID) {
+			if row.actor.ActorID == actorid {
+				continue
+			}
+			costar, ok := counts[row.actor.ActorID]
+			if !ok {
+				costar = &models.CoStar{ActorID: row.actor.ActorID, Name: row.actor.Name}
+				counts[row.actor.ActorID] = costar
+			}
+			costar.Movies++
+		}
+	}
+
+	costars := make([]*models.CoStar, 0, len(counts))
+	for _, costar := range counts {
+		costars = append(costars, costar)
+	}
+	sort.Slice(costars, func(i, j int) bool {
+		if costars[i].Movies != costars[j].Movies {
+			return costars[i].Movies > costars[j].Movies
+		}
+		if costars[i].Name != costars[j].Name {
+			return lessString(costars[i].Name, costars[j].Name)
+		}
+		return costars[i].ActorID < costars[j].ActorID
+	})
+	return capped(costars, limit), nil
+}
+
+// sortedCredits returns every credit ordered by movie, then actor. The
+// caller holds the lock.
+func (s *Storage) sortedCredits() []credit {
+	credits := make([]credit, 0, len(s.credits))
+	for c := range s.credits {
+		credits = append(credits, c)
+	}
+	sort.Slice(credits, func(i, j int) bool {
+		if credits[i].movieID != credits[j].movieID {
+			return credits[i].movieID < credits[j].movieID
+		}
+		return credits[i].actorID < credits[j].actorID
+	})
+	return credits
+}
+
+// castOf returns the actors of a movie in the order of their ids. The
+// caller holds the lock.
+func (s *Storage) castOf(movieid int) []*actorRow {
+	var cast []*actorRow
+	for c := range s.credits {
+		if c.movieID == movieid {
+			cast = append(cast, s.actors[c.actorID])
+		}
+	}
+	sort.Slice(cast, func(i, j int) bool { return cast[i].actor.ActorID < cast[j].actor.ActorID })
+	return cast
+}
+
+// moviesOf returns the movies of an actor in the order of their ids. The
+// caller holds the lock.
+func (s *Storage) moviesOf(actorid int) []*movieRow {
+	var movies []*movieRow
+	for c := range s.credits {
+		if c.actorID == actorid {
+			movies = append(movies, s.movies[c.movieID])
+		}
+	}
+	sort.Slice(movies, func(i, j int) bool { return movies[i].movie.MovieID < movies[j].movie.MovieID })
+	return movies
+}
+
+// uniq drops repeated ids, like the = ANY($1) of a query.
+func uniq(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	var res []int
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// capped applies a LIMIT to list.
+func capped[T any](list []T, limit int) []T {
+	if limit >= 0 && len(list) > limit {
+		return list[:limit]
+	}
+	return list
+}
@@ -0,0 +1,122 @@
+package memorystorage
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+func (s *Storage) GetAllActors(ctx context.Context) ([]*models.Actor, error) {
+	s.mu.RLock()
+	actors := s.allActors()
+	s.mu.RUnlock()
+
+	if len(actors) < 1 {
+		slog.DebugContext(ctx, "No actors found in the table")
+		return nil, models.ErrNoRecord
+	}
+	sort.SliceStable(actors, func(i, j int) bool { return lessString(actors[i].Name, actors[j].Name) })
+	return actors, nil
+}
+
+func (s *Storage) CreateActor(ctx context.Context, a *models.Actor) (*models.Actor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a.ActorID = s.nextActorID
+	s.insertActor(*a, s.now())
+	return a, nil
+}
+
+func (s *Storage) GetActorByID(ctx context.Context, id int) (*models.Actor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.actor(id)
+}
+
+func (s *Storage) UpdateActor(ctx context.Context, a *models.Actor) (*models.Actor, error) {
+	s.mu.Lock()
+	now := s.now()
+	if row, ok := s.actors[a.ActorID]; ok {
+		if a.Name != "" {
+			row.actor.Name = a.Name
+		}
+		if a.Gender != "" {
+			row.actor.Gender = a.Gender
+		}
+		if a.DateOfBirth.Time.Year() >= 1000 {
+			row.actor.DateOfBirth = a.DateOfBirth
+		}
+		row.actor.UpdatedAt = now
+	}
+	s.actorsChanged = now
+	s.mu.Unlock()
+
+	res, err := s.GetActorByID(ctx, a.ActorID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error returning updated actor from the table", "err", err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *Storage) DeleteActor(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.actors[id]; !ok {
+		return models.ErrNoRecord
+	}
+	delete(s.actors, id)
+	for c := range s.credits {
+		if c.actorID == id {
+			delete(s.credits, c)
+		}
+	}
+	s.actorsChanged = s.now()
+	return nil
+}
+
+// GetActorsModifiedAt returns the time of the last insert, update or delete
+// of an actor.
+func (s *Storage) GetActorsModifiedAt(ctx context.Context) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.actorsChanged, nil
+}
+
+// actor copies the actor with id. Like the Postgres storage it treats an
+// actor without date of birth as missing. The caller holds the lock.
+func (s *Storage) actor(id int) (*models.Actor, error) {
+	row, ok := s.actors[id]
+	if !ok || !row.actor.DateOfBirth.Valid {
+		return nil, models.ErrNoRecord
+	}
+	actor := row.actor
+	return &actor, nil
+}
+
+// allActors copies every actor in the order of their ids. The caller holds
+// the lock.
+func (s *Storage) allActors() []*models.Actor {
+	actors := make([]*models.Actor, 0, len(s.actors))
+	for _, row := range s.actors {
+		actor := row.actor
+		actors = append(actors, &actor)
+	}
+	sort.Slice(actors, func(i, j int) bool { return actors[i].ActorID < actors[j].ActorID })
+	return actors
+}
+
+// insertActor stores a under its id, which must not be taken. The caller
+// holds the lock.
+func (s *Storage) insertActor(a models.Actor, createdAt time.Time) {
+	a.UpdatedAt = createdAt
+	s.actors[a.ActorID] = &actorRow{actor: a, createdAt: createdAt}
+	s.nextActorID = max(s.nextActorID, a.ActorID+1)
+	s.actorsChanged = createdAt
+}
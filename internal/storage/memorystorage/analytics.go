@@ -0,0 +1,170 @@
+package memorystorage
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"math"
+	"sort"
+)
+
+func (s *Storage) GetTotals(ctx context.Context) (*models.CatalogTotals, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	totals := &models.CatalogTotals{
+		Movies:  len(s.movies),
+		Actors:  len(s.actors),
+		Credits: len(s.credits),
+	}
+	cast, roles := s.creditCounts()
+	for id := range s.movies {
+		if cast[id] == 0 {
+			totals.MoviesWithoutCast++
+		}
+	}
+	for id := range s.actors {
+		if roles[id] == 0 {
+			totals.ActorsWithoutMovies++
+		}
+	}
+	return totals, nil
+}
+
+func (s *Storage) GetMoviesPerYear(ctx context.Context) ([]*models.YearCount, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[int]int)
+	for _, row := range s.movies {
+		if row.movie.ReleaseDate.Valid {
+			counts[row.movie.ReleaseDate.Time.Year()]++
+		}
+	}
+
+	var years []*models.YearCount
+	for year, count := range counts {
+		years = append(years, &models.YearCount{Year: year, Count: count})
+	}
+	sort.Slice(years, func(i, j int) bool { return years[i].Year < years[j].Year })
+	return years, nil
+}
+
+// GetRatingCounts counts movies per whole rating point, ratings of 10 and
+// above fall into bucket 9.
+func (s *Storage) GetRatingCounts(ctx context.Context) (map[int]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[int]int)
+	for _, row := range s.movies {
+		bucket := min(max(int(math.Floor(row.movie.Rating)), 0), 9)
+		counts[bucket]++
+	}
+	return counts, nil
+}
+
+func (s *Storage) GetMostCreditedActors(ctx context.Context, limit int) ([]*models.CreditedActor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, roles := s.creditCounts()
+	var actors []*models.CreditedActor
+	for id, movies := range roles {
+		actors = append(actors, &models.CreditedActor{ActorID: id, Name: s.actors[id].actor.Name, Movies: movies})
+	}
+	sort.Slice(actors, func(i, j int) bool {
+		if actors[i].Movies != actors[j].Movies {
+			return actors[i].Movies > actors[j].Movies
+		}
+		if actors[i].Name != actors[j].Name {
+			return lessString(actors[i].Name, actors[j].Name)
+		}
+		return actors[i].ActorID < actors[j].ActorID
+	})
+	return capped(actors, limit), nil
+}
+
+func (s *Storage) GetMoviesWithoutCast(ctx context.Context, limit int) ([]*models.Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cast, _ := s.creditCounts()
+	var movies []*models.Movie
+	for _, row := range s.movies {
+		if cast[row.movie.MovieID] == 0 {
+			movies = append(movies, withoutUpdatedAt(row.movie))
+		}
+	}
+	sort.Slice(movies, func(i, j int) bool {
+		if movies[i].Title != movies[j].Title {
+			return lessString(movies[i].Title, movies[j].Title)
+		}
+		return movies[i].MovieID < movies[j].MovieID
+	})
+	return capped(movies, limit), nil
+}
+
+func (s *Storage) GetActorsWithoutMovies(ctx context.Context, limit int) ([]*models.Actor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, roles := s.creditCounts()
+	var actors []*models.Actor
+	for _, row := range s.actors {
+		if roles[row.actor.ActorID] == 0 {
+			actors = append(actors, actorWithoutUpdatedAt(row.actor))
+		}
+	}
+	sort.Slice(actors, func(i, j int) bool {
+		if actors[i].Name != actors[j].Name {
+			return lessString(actors[i].Name, actors[j].Name)
+		}
+		return actors[i].ActorID < actors[j].ActorID
+	})
+	return capped(actors, limit), nil
+}
+
+func (s *Storage) GetMonthlyGrowth(ctx context.Context) ([]*models.GrowthPoint, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	months := make(map[string]*models.GrowthPoint)
+	point := func(month string) *models.GrowthPoint {
+		p, ok := months[month]
+		if !ok {
+			p = &models.GrowthPoint{Month: month}
+			months[month] = p
+		}
+		return p
+	}
+	for _, row := range s.movies {
+		point(row.createdAt.Format("2006-01")).Movies++
+	}
+	for _, row := range s.actors {
+		point(row.createdAt.Format("2006-01")).Actors++
+	}
+
+	var growth []*models.GrowthPoint
+	for _, p := range months {
+		growth = append(growth, p)
+	}
+	sort.Slice(growth, func(i, j int) bool { return growth[i].Month < growth[j].Month })
+	var movies, actors int
+	for _, p := range growth {
+		movies += p.Movies
+		actors += p.Actors
+		p.TotalMovies, p.TotalActors = movies, actors
+	}
+	return growth, nil
+}
+
+// creditCounts counts the cast of every movie and the movies of every actor.
+// The caller holds the lock.
+func (s *Storage) creditCounts() (cast map[int]int, roles map[int]int) {
+	cast, roles = make(map[int]int), make(map[int]int)
+	for c := range s.credits {
+		cast[c.movieID]++
+		roles[c.actorID]++
+	}
+	return cast, roles
+}
@@ -0,0 +1,151 @@
+package memorystorage
+
+import (
+	"encoding/json"
+	"filmoteka/internal/domain/models"
+	"fmt"
+	"os"
+)
+
+// Fixtures is the content of a fixture file. Movies and actors use the JSON
+// of the API, credits name an actor and a movie by id, and the password of
+// a user is a hash as written by the password package. Rows without an id
+// get the next free one.
+type Fixtures struct {
+	Movies  []models.Movie  `json:"movies"`
+	Actors  []models.Actor  `json:"actors"`
+	Credits []FixtureCredit `json:"credits"`
+	Users   []FixtureUser   `json:"users"`
+}
+
+type FixtureCredit struct {
+	ActorID int `json:"actorid"`
+	MovieID int `json:"movieid"`
+}
+
+type FixtureUser struct {
+	UserID   int    `json:"userid"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+// LoadFixtures adds the fixtures in the JSON file at path.
+func (s *Storage) LoadFixtures(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("fixtures: %w", err)
+	}
+	var f Fixtures
+	if err = json.Unmarshal(b, &f); err != nil {
+		return fmt.Errorf("fixtures %s: %w", path, err)
+	}
+	if err = s.Seed(&f); err != nil {
+		return fmt.Errorf("fixtures %s: %w", path, err)
+	}
+	return nil
+}
+
+// Seed adds f to the storage. It adds nothing if an id or email is taken
+// or a credit names a missing actor or movie.
+func (s *Storage) Seed(f *Fixtures) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	movieIDs, nextMovie, err := assignIDs(len(f.Movies), func(i int) int { return f.Movies[i].MovieID }, s.nextMovieID, func(id int) bool {
+		_, ok := s.movies[id]
+		return ok
+	})
+	if err != nil {
+		return fmt.Errorf("movie %w", err)
+	}
+	actorIDs, nextActor, err := assignIDs(len(f.Actors), func(i int) int { return f.Actors[i].ActorID }, s.nextActorID, func(id int) bool {
+		_, ok := s.actors[id]
+		return ok
+	})
+	if err != nil {
+		return fmt.Errorf("actor %w", err)
+	}
+	userIDs, nextUser, err := assignIDs(len(f.Users), func(i int) int { return f.Users[i].UserID }, s.nextUserID, func(id int) bool {
+		_, ok := s.users[id]
+		return ok
+	})
+	if err != nil {
+		return fmt.Errorf("user %w", err)
+	}
+
+	emails := make(map[string]bool)
+	for _, u := range s.users {
+		emails[u.Email] = true
+	}
+	for _, u := range f.Users {
+		if u.Email == "" || emails[u.Email] {
+			return fmt.Errorf("user email %q is empty or taken", u.Email)
+		}
+		emails[u.Email] = true
+	}
+
+	for _, c := range f.Credits {
+		_, movie := s.movies[c.MovieID]
+		_, actor := s.actors[c.ActorID]
+		if !(movie || contains(movieIDs, c.MovieID)) || !(actor || contains(actorIDs, c.ActorID)) {
+			return fmt.Errorf("credit of actor %d in movie %d names a missing actor or movie", c.ActorID, c.MovieID)
+		}
+	}
+
+	now := s.now()
+	for i, m := range f.Movies {
+		m.MovieID = movieIDs[i]
+		s.insertMovie(m, now)
+	}
+	for i, a := range f.Actors {
+		a.ActorID = actorIDs[i]
+		s.insertActor(a, now)
+	}
+	for _, c := range f.Credits {
+		s.credits[credit{actorID: c.ActorID, movieID: c.MovieID}] = now
+	}
+	for i, u := range f.Users {
+		role := u.Role
+		if role == "" {
+			role = "user"
+		}
+		s.users[userIDs[i]] = &models.User{UserID: userIDs[i], Email: u.Email, Password: u.Password, Role: role}
+	}
+	s.nextMovieID, s.nextActorID, s.nextUserID = max(s.nextMovieID, nextMovie), max(s.nextActorID, nextActor), max(s.nextUserID, nextUser)
+	return nil
+}
+
+// assignIDs returns the ids of n new rows: the id given by idOf, or the next
+// free one from next on if that is zero.
+func assignIDs(n int, idOf func(i int) int, next int, taken func(id int) bool) ([]int, int, error) {
+	ids := make([]int, n)
+	used := make(map[int]bool, n)
+	for i := range n {
+		id := idOf(i)
+		if id == 0 {
+			continue
+		}
+		if id < 0 || used[id] || taken(id) {
+			return nil, 0, fmt.Errorf("id %d is invalid or taken", id)
+		}
+		ids[i], used[id] = id, true
+		next = max(next, id+1)
+	}
+	for i := range ids {
+		if ids[i] == 0 {
+			ids[i], used[next] = next, true
+			next++
+		}
+	}
+	return ids, next, nil
+}
+
+func contains(ids []int, id int) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
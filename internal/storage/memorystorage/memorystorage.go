@@ -0,0 +1,107 @@
+// Package memorystorage keeps the whole catalog in memory. It implements
+// the storage interfaces of the usecases with the semantics of the Postgres
+// storages, for local development without a database.
+package memorystorage
+
+import (
+	"filmoteka/internal/domain/models"
+	"strings"
+	"sync"
+	"time"
+)
+
+type movieRow struct {
+	movie     models.Movie
+	createdAt time.Time
+}
+
+type actorRow struct {
+	actor     models.Actor
+	createdAt time.Time
+}
+
+type credit struct {
+	actorID int
+	movieID int
+}
+
+type passwordReset struct {
+	userID    int
+	expiresAt time.Time
+	used      bool
+}
+
+type Storage struct {
+	mu sync.RWMutex
+
+	movies  map[int]*movieRow
+	actors  map[int]*actorRow
+	credits map[credit]time.Time
+	users   map[int]*models.User
+
+	loginFailures  map[string]models.LoginFailures
+	passwordResets map[string]*passwordReset
+
+	nextMovieID int
+	nextActorID int
+	nextUserID  int
+
+	moviesChanged time.Time
+	actorsChanged time.Time
+
+	now func() time.Time
+}
+
+func New() *Storage {
+	s := &Storage{
+		movies:         make(map[int]*movieRow),
+		actors:         make(map[int]*actorRow),
+		credits:        make(map[credit]time.Time),
+		users:          make(map[int]*models.User),
+		loginFailures:  make(map[string]models.LoginFailures),
+		passwordResets: make(map[string]*passwordReset),
+		nextMovieID:    1,
+		nextActorID:    1,
+		nextUserID:     1,
+		now:            time.Now,
+	}
+	s.moviesChanged = s.now()
+	s.actorsChanged = s.moviesChanged
+	return s
+}
+
+// ilike reports whether s contains substr ignoring case, like
+// s ILIKE '%substr%'.
+func ilike(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// lessString orders strings ignoring case first, close to the collation of
+// the database.
+func lessString(a, b string) bool {
+	la, lb := strings.ToLower(a), strings.ToLower(b)
+	if la != lb {
+		return la < lb
+	}
+	return a < b
+}
+
+// yearOf returns the year of a date, 0 if it is unknown.
+func yearOf(d models.Date) int {
+	if !d.Valid {
+		return 0
+	}
+	return d.Time.Year()
+}
+
+// withoutUpdatedAt copies a movie the way the joins of the Postgres storage
+// return it.
+func withoutUpdatedAt(m models.Movie) *models.Movie {
+	m.UpdatedAt = time.Time{}
+	return &m
+}
+
+func actorWithoutUpdatedAt(a models.Actor) *models.Actor {
+	a.UpdatedAt = time.Time{}
+	return &a
+}
@@ -0,0 +1,18 @@
+package memorystorage
+
+import (
+	"filmoteka/internal/storage/storagetest"
+	"testing"
+)
+
+func TestContract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		s := New()
+		return storagetest.Backend{
+			Storage: s,
+			AddUser: func(email string, passwordHash string, role string) error {
+				return s.Seed(&Fixtures{Users: []FixtureUser{{Email: email, Password: passwordHash, Role: role}}})
+			},
+		}
+	})
+}
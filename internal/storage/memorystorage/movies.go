@@ -0,0 +1,164 @@
+package memorystorage
+
+import (
+	"context"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+func (s *Storage) GetAllMovies(ctx context.Context, sortParam string) ([]*models.Movie, error) {
+	var less func(a, b *models.Movie) bool
+	switch sortParam {
+	case "date":
+		less = func(a, b *models.Movie) bool { return a.ReleaseDate.Time.Before(b.ReleaseDate.Time) }
+	case "title":
+		less = func(a, b *models.Movie) bool { return lessString(a.Title, b.Title) }
+	case "rating", "":
+		less = func(a, b *models.Movie) bool { return a.Rating > b.Rating }
+	default:
+		slog.InfoContext(ctx, "Invalid sort parameter", "sort", sortParam)
+		return nil, errors.New("invalid sort parameter")
+	}
+
+	s.mu.RLock()
+	movies := s.allMovies()
+	s.mu.RUnlock()
+
+	if len(movies) < 1 {
+		slog.DebugContext(ctx, "No movies found in the table")
+		return nil, models.ErrNoRecord
+	}
+	sort.SliceStable(movies, func(i, j int) bool { return less(movies[i], movies[j]) })
+	return movies, nil
+}
+
+func (s *Storage) GetMovieByID(ctx context.Context, id int) (*models.Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.movie(id)
+}
+
+func (s *Storage) CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m.MovieID = s.nextMovieID
+	s.insertMovie(*m, s.now())
+	return m, nil
+}
+
+func (s *Storage) UpdateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error) {
+	s.mu.Lock()
+	now := s.now()
+	if row, ok := s.movies[m.MovieID]; ok {
+		if m.Title != "" {
+			row.movie.Title = m.Title
+		}
+		if m.Description != "" {
+			row.movie.Description = m.Description
+		}
+		if m.Rating != 0 {
+			row.movie.Rating = roundRating(m.Rating)
+		}
+		if m.ReleaseDate.Time.Year() >= 1000 {
+			row.movie.ReleaseDate = m.ReleaseDate
+		}
+		row.movie.UpdatedAt = now
+	}
+	s.moviesChanged = now
+	s.mu.Unlock()
+
+	res, err := s.GetMovieByID(ctx, m.MovieID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error returning updated movie from the table ", "err", err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *Storage) DeleteMovie(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.movies[id]; !ok {
+		return models.ErrNoRecord
+	}
+	delete(s.movies, id)
+	for c := range s.credits {
+		if c.movieID == id {
+			delete(s.credits, c)
+		}
+	}
+	s.moviesChanged = s.now()
+	return nil
+}
+
+func (s *Storage) GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var movies []*models.Movie
+	for _, movie := range s.allMovies() {
+		if ilike(movie.Title, moviename) || ilike(movie.Description, moviename) {
+			movies = append(movies, movie)
+		}
+	}
+
+	if len(movies) < 1 {
+		slog.DebugContext(ctx, "No movies found in the table")
+		return nil, models.ErrNoRecord
+	}
+	return movies, nil
+}
+
+// GetMoviesModifiedAt returns the time of the last insert, update or delete
+// of a movie.
+func (s *Storage) GetMoviesModifiedAt(ctx context.Context) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.moviesChanged, nil
+}
+
+// movie copies the movie with id. Like the Postgres storage it treats a
+// movie without release date as missing. The caller holds the lock.
+func (s *Storage) movie(id int) (*models.Movie, error) {
+	row, ok := s.movies[id]
+	if !ok || !row.movie.ReleaseDate.Valid {
+		return nil, models.ErrNoRecord
+	}
+	movie := row.movie
+	return &movie, nil
+}
+
+// allMovies copies every movie in the order of their ids. The caller holds
+// the lock.
+func (s *Storage) allMovies() []*models.Movie {
+	movies := make([]*models.Movie, 0, len(s.movies))
+	for _, row := range s.movies {
+		movie := row.movie
+		movies = append(movies, &movie)
+	}
+	sort.Slice(movies, func(i, j int) bool { return movies[i].MovieID < movies[j].MovieID })
+	return movies
+}
+
+// insertMovie stores m under its id, which must not be taken. The caller
+// holds the lock.
+func (s *Storage) insertMovie(m models.Movie, createdAt time.Time) {
+	m.Rating = roundRating(m.Rating)
+	m.UpdatedAt = createdAt
+	s.movies[m.MovieID] = &movieRow{movie: m, createdAt: createdAt}
+	s.nextMovieID = max(s.nextMovieID, m.MovieID+1)
+	s.moviesChanged = createdAt
+}
+
+// roundRating keeps one decimal like the numeric(3,1) rating column.
+func roundRating(rating float64) float64 {
+	return math.Round(rating*10) / 10
+}
@@ -0,0 +1,107 @@
+package memorystorage
+
+import (
+	"context"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"time"
+)
+
+func (s *Storage) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if user.Email == email {
+			u := *user
+			return &u, nil
+		}
+	}
+	return nil, models.ErrNoRecord
+}
+
+// GetLoginFailures returns the failures recorded under key, zero if there
+// are none.
+func (s *Storage) GetLoginFailures(ctx context.Context, key string) (*models.LoginFailures, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f := s.loginFailures[key]
+	return &f, nil
+}
+
+// RecordLoginFailure adds a failure under key. A counter whose last failure
+// is older than resetAfter starts over.
+func (s *Storage) RecordLoginFailure(ctx context.Context, key string, resetAfter time.Duration) (*models.LoginFailures, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	f, ok := s.loginFailures[key]
+	if !ok || f.LastFailure.Before(now.Add(-resetAfter)) {
+		f.Failures = 0
+	}
+	f.Failures++
+	f.LastFailure = now
+	s.loginFailures[key] = f
+	return &f, nil
+}
+
+func (s *Storage) ClearLoginFailures(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.loginFailures, key)
+	return nil
+}
+
+func (s *Storage) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if user, ok := s.users[userID]; ok {
+		user.Password = passwordHash
+	}
+	return nil
+}
+
+// CreatePasswordReset stores the hash of a reset token for the user.
+func (s *Storage) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return errors.New("password reset for unknown user")
+	}
+	if _, ok := s.passwordResets[tokenHash]; ok {
+		return errors.New("duplicate password reset token")
+	}
+	s.passwordResets[tokenHash] = &passwordReset{userID: userID, expiresAt: expiresAt}
+	return nil
+}
+
+// ResetPassword sets the password of the user the unused, unexpired token
+// with tokenHash belongs to, and uses up all reset tokens of that user. It
+// returns models.ErrNoRecord if there is no such token.
+func (s *Storage) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reset, ok := s.passwordResets[tokenHash]
+	if !ok || reset.used || !reset.expiresAt.After(s.now()) {
+		return nil, models.ErrNoRecord
+	}
+	user, ok := s.users[reset.userID]
+	if !ok {
+		return nil, models.ErrNoRecord
+	}
+
+	user.Password = passwordHash
+	for _, r := range s.passwordResets {
+		if r.userID == reset.userID {
+			r.used = true
+		}
+	}
+	u := *user
+	return &u, nil
+}
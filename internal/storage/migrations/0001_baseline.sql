@@ -0,0 +1,33 @@
+-- Schema as it existed before migrations were introduced. Every statement is
+-- guarded so that it is a no-op on databases created by hand.
+CREATE TABLE IF NOT EXISTS users
+(
+    userid   SERIAL PRIMARY KEY,
+    email    VARCHAR(255) NOT NULL UNIQUE,
+    password VARCHAR(255) NOT NULL,
+    role     VARCHAR(50)  NOT NULL DEFAULT 'user'
+);
+
+CREATE TABLE IF NOT EXISTS actors
+(
+    actorid     SERIAL PRIMARY KEY,
+    name        VARCHAR(255) NOT NULL,
+    gender      VARCHAR(50),
+    dateofbirth DATE
+);
+
+CREATE TABLE IF NOT EXISTS movies
+(
+    movieid     SERIAL PRIMARY KEY,
+    title       VARCHAR(150) NOT NULL,
+    description TEXT,
+    rating      NUMERIC(3, 1),
+    releasedate DATE
+);
+
+CREATE TABLE IF NOT EXISTS actormovie
+(
+    actorid INT NOT NULL REFERENCES actors (actorid) ON DELETE CASCADE,
+    movieid INT NOT NULL REFERENCES movies (movieid) ON DELETE CASCADE,
+    PRIMARY KEY (actorid, movieid)
+);
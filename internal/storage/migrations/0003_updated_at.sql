@@ -0,0 +1,39 @@
+-- updated_at tracks the last change of a row, table_changes the last insert,
+-- update or delete on a whole table. Both back the HTTP Last-Modified header.
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE actors ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS trigger AS
+$$
+BEGIN
+    NEW.updated_at = now();
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS movies_updated_at ON movies;
+CREATE TRIGGER movies_updated_at BEFORE UPDATE ON movies FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+DROP TRIGGER IF EXISTS actors_updated_at ON actors;
+CREATE TRIGGER actors_updated_at BEFORE UPDATE ON actors FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+CREATE TABLE IF NOT EXISTS table_changes
+(
+    table_name TEXT PRIMARY KEY,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+INSERT INTO table_changes (table_name) VALUES ('movies'), ('actors'), ('actormovie') ON CONFLICT DO NOTHING;
+
+CREATE OR REPLACE FUNCTION record_table_change() RETURNS trigger AS
+$$
+BEGIN
+    UPDATE table_changes SET changed_at = now() WHERE table_name = TG_TABLE_NAME;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS movies_changed ON movies;
+CREATE TRIGGER movies_changed AFTER INSERT OR UPDATE OR DELETE ON movies FOR EACH STATEMENT EXECUTE FUNCTION record_table_change();
+DROP TRIGGER IF EXISTS actors_changed ON actors;
+CREATE TRIGGER actors_changed AFTER INSERT OR UPDATE OR DELETE ON actors FOR EACH STATEMENT EXECUTE FUNCTION record_table_change();
+DROP TRIGGER IF EXISTS actormovie_changed ON actormovie;
+CREATE TRIGGER actormovie_changed AFTER INSERT OR UPDATE OR DELETE ON actormovie FOR EACH STATEMENT EXECUTE FUNCTION record_table_change();
@@ -0,0 +1,9 @@
+-- Token buckets of ratelimit.PostgresStore. UNLOGGED because losing them in a
+-- crash only resets the budgets.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limits
+(
+    key        TEXT PRIMARY KEY,
+    tokens     DOUBLE PRECISION NOT NULL,
+    allowed    BOOLEAN          NOT NULL,
+    updated_at TIMESTAMPTZ      NOT NULL
+);
@@ -0,0 +1,8 @@
+-- Failed logins per account ("account:<email>") and per client address
+-- ("ip:<address>"). Rows are deleted on successful login or admin unlock.
+CREATE TABLE IF NOT EXISTS login_failures
+(
+    key          TEXT PRIMARY KEY,
+    failures     INT         NOT NULL,
+    last_failure TIMESTAMPTZ NOT NULL
+);
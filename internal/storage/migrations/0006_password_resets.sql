@@ -0,0 +1,12 @@
+-- Password reset tokens. Only the SHA-256 of a token is stored, the token
+-- itself is only ever sent to the user.
+CREATE TABLE IF NOT EXISTS password_resets
+(
+    token_hash TEXT PRIMARY KEY,
+    userid     INT         NOT NULL REFERENCES users (userid) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS password_resets_userid_idx ON password_resets (userid);
@@ -6,50 +6,50 @@ import (
 	"encoding/json"
 	"errors"
 	"filmoteka/internal/domain/models"
-	"log"
+	"log/slog"
 	"time"
 )
 
-var DbTimeout = 5 * time.Second
-
 type MovieStorage struct {
-	db *sql.DB
+	db      *sql.DB
+	timeout time.Duration
 }
 
-func New(db *sql.DB) *MovieStorage {
+// New returns a storage on db that gives every query up to timeout.
+func New(db *sql.DB, timeout time.Duration) *MovieStorage {
 	return &MovieStorage{
-		db: db,
+		db:      db,
+		timeout: timeout,
 	}
-
 }
 
-func (s *MovieStorage) GetAllMovies(sortParam string) ([]*models.Movie, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *MovieStorage) GetAllMovies(ctx context.Context, sortParam string) ([]*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	var query string
 	switch sortParam {
 	case "date":
-		query = `SELECT movieid, title, description, rating, releasedate FROM movies ORDER BY releasedate`
+		query = `SELECT movieid, title, description, rating, releasedate, updated_at FROM movies ORDER BY releasedate`
 	case "title":
-		query = `SELECT movieid, title, description, rating, releasedate FROM movies ORDER BY title`
+		query = `SELECT movieid, title, description, rating, releasedate, updated_at FROM movies ORDER BY title`
 	case "rating":
 		fallthrough
 	case "":
-		query = `SELECT movieid, title, description, rating, releasedate FROM movies ORDER BY rating DESC`
+		query = `SELECT movieid, title, description, rating, releasedate, updated_at FROM movies ORDER BY rating DESC`
 	default:
-		log.Println("Invalid sort parameter")
+		slog.InfoContext(ctx, "Invalid sort parameter", "sort", sortParam)
 		return nil, errors.New("invalid sort parameter")
 	}
 	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
-		log.Println("Error getting all movies from the table", err)
+		slog.ErrorContext(ctx, "Error getting all movies from the table", "err", err)
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
 		}
 	}(rows)
 	var movies []*models.Movie
@@ -62,35 +62,36 @@ func (s *MovieStorage) GetAllMovies(sortParam string) ([]*models.Movie, error) {
 			&movie.Description,
 			&movie.Rating,
 			&movie.ReleaseDate,
+			&movie.UpdatedAt,
 		)
 		if err != nil {
-			log.Println("Error scanning movie rows", err)
+			slog.ErrorContext(ctx, "Error scanning movie rows", "err", err)
 			return nil, err
 		}
 
 		movies = append(movies, &movie)
 	}
 	if len(movies) < 1 {
-		log.Println("No movies found in the table")
+		slog.DebugContext(ctx, "No movies found in the table")
 		return nil, models.ErrNoRecord
 	}
 	return movies, nil
 }
 
-func (s *MovieStorage) GetMovieByID(id int) (*models.Movie, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *MovieStorage) GetMovieByID(ctx context.Context, id int) (*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-	query := `SELECT movieid, title, description, rating, releasedate FROM movies WHERE movieid = $1`
+	query := `SELECT movieid, title, description, rating, releasedate, updated_at FROM movies WHERE movieid = $1`
 	rows, err := s.db.QueryContext(ctx, query, id)
 	if err != nil {
-		log.Println("Error getting movie by id from the table", err)
+		slog.ErrorContext(ctx, "Error getting movie by id from the table", "err", err)
 		return nil, err
 	}
 
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -102,84 +103,89 @@ func (s *MovieStorage) GetMovieByID(id int) (*models.Movie, error) {
 			&movie.Description,
 			&movie.Rating,
 			&movie.ReleaseDate,
+			&movie.UpdatedAt,
 		)
 		if err != nil {
-			log.Println("Error scanning movie rows", err)
+			slog.ErrorContext(ctx, "Error scanning movie rows", "err", err)
 			return nil, err
 		}
 	}
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error iterating movie rows", "err", err)
+		return nil, err
+	}
 
 	if !movie.ReleaseDate.Valid {
-		return nil, errors.New("no movie found")
+		return nil, models.ErrNoRecord
 	}
-	log.Println("Movie: ", movie)
 	return movie, nil
 }
 
-func (s *MovieStorage) CreateMovie(m *models.Movie) (*models.Movie, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *MovieStorage) CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	query := `INSERT INTO movies (title, description, rating, releasedate)
-	values ($1, $2, $3, $4)`
-	_, err := s.db.ExecContext(ctx, query, m.Title, m.Description, m.Rating, m.ReleaseDate)
+	values ($1, $2, $3, $4) RETURNING movieid`
+	err := s.db.QueryRowContext(ctx, query, m.Title, m.Description, m.Rating, m.ReleaseDate).Scan(&m.MovieID)
 	if err != nil {
-		log.Println("Error inserting movie into a table", err)
-		encoder, _ := json.Marshal(m)
-		log.Println("Movie: ", string(encoder))
+		slog.ErrorContext(ctx, "Error inserting movie into a table", "err", err)
 		return nil, err
 	}
 	return m, nil
 }
 
-func (s *MovieStorage) UpdateMovie(m *models.Movie) (*models.Movie, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *MovieStorage) UpdateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 	formattedRD, _ := json.Marshal(m.ReleaseDate.Time)
-	log.Println("Formatted DOB: ", string(formattedRD))
 	query := `UPDATE movies SET title = CASE WHEN $1 = '' THEN title ELSE COALESCE($1) END, description = CASE WHEN $2 = '' THEN description ELSE COALESCE($2) END, rating = CASE WHEN $3 = 0 THEN rating ELSE COALESCE($3) END, releasedate = CASE WHEN $4 < '1000-1-1' THEN releasedate ELSE CAST(COALESCE($4) AS DATE) END WHERE movieid = ($5)`
 	_, err := s.db.ExecContext(ctx, query, m.Title, m.Description, m.Rating, formattedRD, m.MovieID)
 	if err != nil {
-		log.Println("Error updating movie in the table", err)
-		encoder, _ := json.Marshal(m)
-		log.Println("Movie: ", string(encoder))
+		slog.ErrorContext(ctx, "Error updating movie in the table", "movieid", m.MovieID, "err", err)
 		return nil, err
 	}
-	res, err := s.GetMovieByID(m.MovieID)
-	log.Println("Updated movie: ", res)
+	res, err := s.GetMovieByID(ctx, m.MovieID)
 	if err != nil {
-		log.Println("Error returning updated movie from the table ", err)
+		slog.ErrorContext(ctx, "Error returning updated movie from the table ", "err", err)
 		return nil, err
 	}
 	return res, nil
 }
 
-func (s *MovieStorage) DeleteMovie(id int) error {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *MovieStorage) DeleteMovie(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 	query := `DELETE FROM movies WHERE movieid = $1`
-	_, err := s.db.ExecContext(ctx, query, id)
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting movie from the table", "err", err)
+		return err
+	}
+	n, err := res.RowsAffected()
 	if err != nil {
-		log.Println("Error deleting movie from the table", err)
 		return err
 	}
+	if n == 0 {
+		return models.ErrNoRecord
+	}
 	return nil
 }
 
-func (s *MovieStorage) GetMovieByMovieName(moviename string) ([]*models.Movie, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+func (s *MovieStorage) GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
-	query := `SELECT movieid, title, description, rating, releasedate FROM movies WHERE title ILIKE $1 OR description ILIKE $2;`
+	query := `SELECT movieid, title, description, rating, releasedate, updated_at FROM movies WHERE title ILIKE $1 OR description ILIKE $2;`
 
 	rows, err := s.db.QueryContext(ctx, query, "%"+moviename+"%", "%"+moviename+"%")
 	if err != nil {
-		log.Println("Error getting movies by movie name from the table", err)
+		slog.ErrorContext(ctx, "Error getting movies by movie name from the table", "err", err)
 		return nil, err
 	}
 	defer func(rows *sql.Rows) {
 		err = rows.Close()
 		if err != nil {
-			log.Println("Error closing rows", err)
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
 		}
 	}(rows)
 
@@ -193,9 +199,10 @@ func (s *MovieStorage) GetMovieByMovieName(moviename string) ([]*models.Movie, e
 			&movie.Description,
 			&movie.Rating,
 			&movie.ReleaseDate,
+			&movie.UpdatedAt,
 		)
 		if err != nil {
-			log.Println("Error scanning actor rows", err)
+			slog.ErrorContext(ctx, "Error scanning actor rows", "err", err)
 			return nil, err
 		}
 
@@ -203,8 +210,24 @@ func (s *MovieStorage) GetMovieByMovieName(moviename string) ([]*models.Movie, e
 	}
 
 	if len(movies) < 1 {
-		log.Println("No movies found in the table")
+		slog.DebugContext(ctx, "No movies found in the table")
 		return nil, models.ErrNoRecord
 	}
 	return movies, nil
 }
+
+// GetMoviesModifiedAt returns the time of the last insert, update or delete
+// on the movies table.
+func (s *MovieStorage) GetMoviesModifiedAt(ctx context.Context) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var changed time.Time
+	query := `SELECT changed_at FROM table_changes WHERE table_name = 'movies'`
+	err := s.db.QueryRowContext(ctx, query).Scan(&changed)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting last change of the movies table", "err", err)
+		return time.Time{}, err
+	}
+	return changed, nil
+}
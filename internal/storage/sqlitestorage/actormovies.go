@@ -0,0 +1,454 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"filmoteka/internal/domain/models"
+	"log/slog"
+	"strings"
+)
+
+func (s *Storage) AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error) {
+	a, m, err := s.creditOf(ctx, actorid, movieid)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `INSERT INTO actormovie (actorid, movieid) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	res, err := s.db.ExecContext(ctx, query, actorid, movieid)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error adding actor to movie in the table", "err", err)
+		return nil, nil, false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return a, m, n > 0, nil
+}
+
+func (s *Storage) DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error) {
+	a, m, err := s.creditOf(ctx, actorid, movieid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `DELETE FROM actormovie WHERE actorid = $1 AND movieid = $2`
+	_, err = s.db.ExecContext(ctx, query, actorid, movieid)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting actor from movie in the table", "err", err)
+		return nil, nil, err
+	}
+	return a, m, nil
+}
+
+// creditOf looks up both sides of a credit, models.ErrNoRecord if either is
+// missing.
+func (s *Storage) creditOf(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error) {
+	a, err := s.GetActorByID(ctx, actorid)
+	if err != nil {
+		slog.DebugContext(ctx, "No results", "err", err)
+		return nil, nil, err
+	}
+	m, err := s.GetMovieByID(ctx, movieid)
+	if err != nil {
+		slog.DebugContext(ctx, "No results", "err", err)
+		return nil, nil, err
+	}
+	return a, m, nil
+}
+
+func (s *Storage) GetActorsForMovie(ctx context.Context, id int) ([]*models.Actor, *models.Movie, error) {
+	movie, err := s.GetMovieByID(ctx, id)
+	if err != nil {
+		slog.DebugContext(ctx, "No results", "err", err)
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT am.movieid, a.actorid, a.name, a.gender, a.dateofbirth
+FROM actors a
+         JOIN actormovie am ON a.actorid = am.actorid
+WHERE am.movieid = $1
+ORDER BY a.actorid`
+	actors, err := s.queryActors(ctx, query, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting actors for movie from the table", "err", err)
+		return nil, nil, err
+	}
+	return actors[id], movie, nil
+}
+
+func (s *Storage) GetMoviesForActor(ctx context.Context, actorid int) ([]*models.Movie, *models.Actor, error) {
+	actor, err := s.GetActorByID(ctx, actorid)
+	if err != nil {
+		slog.DebugContext(ctx, "No results", "err", err)
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT am.actorid, m.movieid, m.title, m.description, m.rating, m.releasedate
+FROM movies m
+         JOIN actormovie am ON m.movieid = am.movieid
+WHERE am.actorid = $1
+ORDER BY m.movieid`
+	movies, err := s.queryCreditedMovies(ctx, query, actorid)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting movies for actor from the table", "err", err)
+		return nil, nil, err
+	}
+	return movies[actorid], actor, nil
+}
+
+func (s *Storage) GetMovieByActorName(ctx context.Context, name string, surname string) ([]*models.MovieWithActor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT m.movieid, m.title, m.description, m.rating, m.releasedate, a.name AS actor_name
+FROM movies m
+         JOIN actormovie am ON m.movieid = am.movieid
+         JOIN actors a ON am.actorid = a.actorid
+WHERE fold(a.name) LIKE $1 AND fold(a.name) LIKE $2`
+
+	rows, err := s.db.QueryContext(ctx, query, "%"+strings.ToLower(name)+"%", "%"+strings.ToLower(surname)+"%")
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting movies by actor name from the table", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var movies []*models.MovieWithActor
+	for rows.Next() {
+		var movie models.MovieWithActor
+		err = rows.Scan(
+			&movie.MovieID,
+			&movie.Title,
+			&movie.Description,
+			&movie.Rating,
+			&movie.ReleaseDate,
+			&movie.ActorName,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
+			return nil, err
+		}
+		movies = append(movies, &movie)
+	}
+	return movies, rows.Err()
+}
+
+// GetActorsAndMoviesForMovie returns the cast of a movie with the filmography
+// of every cast member in a single query, numbering the movies of each actor
+// to apply MoviesPerActor.
+func (s *Storage) GetActorsAndMoviesForMovie(ctx context.Context, id int, opts models.CastOptions) ([]*models.ActorMovies, *models.Movie, error) {
+	movie, err := s.GetMovieByID(ctx, id)
+	if err != nil {
+		slog.DebugContext(ctx, "No results", "err", err)
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT a.actorid, a.name, m.movieid, m.title, m.description, m.rating, m.releasedate
+FROM actormovie c
+         JOIN actors a ON a.actorid = c.actorid
+         LEFT JOIN (SELECT am.actorid, m.movieid, m.title, m.description, m.rating, m.releasedate,
+                           ROW_NUMBER() OVER (PARTITION BY am.actorid ORDER BY m.releasedate DESC) AS n
+                    FROM movies m
+                             JOIN actormovie am ON m.movieid = am.movieid
+                    WHERE am.actorid IN (SELECT actorid FROM actormovie WHERE movieid = $1)) m
+                   ON m.actorid = a.actorid AND $2 AND ($3 IS NULL OR m.n <= $3)
+WHERE c.movieid = $1
+ORDER BY a.name COLLATE NOCASE, a.actorid, m.n`
+
+	var limit any
+	if opts.MoviesPerActor > 0 {
+		limit = opts.MoviesPerActor
+	}
+	rows, err := s.db.QueryContext(ctx, query, id, opts.Depth != 1, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting actors and movies for movie from the table", "err", err)
+		return nil, nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var result []*models.ActorMovies
+	for rows.Next() {
+		var actorid int
+		var name string
+		var movieid sql.NullInt64
+		var title, description sql.NullString
+		var rating sql.NullFloat64
+		var releasedate models.Date
+		err = rows.Scan(&actorid, &name, &movieid, &title, &description, &rating, &releasedate)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
+			return nil, nil, err
+		}
+
+		if len(result) == 0 || result[len(result)-1].ActorId != actorid {
+			result = append(result, &models.ActorMovies{ActorId: actorid, Name: name, Movies: []*models.Movie{}})
+		}
+		if movieid.Valid {
+			res := result[len(result)-1]
+			res.Movies = append(res.Movies, &models.Movie{
+				MovieID:     int(movieid.Int64),
+				Title:       title.String,
+				Description: description.String,
+				Rating:      rating.Float64,
+				ReleaseDate: releasedate,
+			})
+		}
+	}
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error getting actors and movies for movie from the table", "err", err)
+		return nil, nil, err
+	}
+	return result, movie, nil
+}
+
+func (s *Storage) GetActorsForMovies(ctx context.Context, movieids []int) (map[int][]*models.Actor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT am.movieid, a.actorid, a.name, a.gender, a.dateofbirth
+FROM actors a
+         JOIN actormovie am ON a.actorid = am.actorid
+WHERE am.movieid IN (SELECT value FROM json_each($1))
+ORDER BY a.name COLLATE NOCASE`
+	actors, err := s.queryActors(ctx, query, idList(movieids))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting actors for movies from the table", "err", err)
+		return nil, err
+	}
+	return actors, nil
+}
+
+func (s *Storage) GetMoviesForActors(ctx context.Context, actorids []int) (map[int][]*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT am.actorid, m.movieid, m.title, m.description, m.rating, m.releasedate
+FROM movies m
+         JOIN actormovie am ON m.movieid = am.movieid
+WHERE am.actorid IN (SELECT value FROM json_each($1))
+ORDER BY m.releasedate`
+	movies, err := s.queryCreditedMovies(ctx, query, idList(actorids))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting movies for actors from the table", "err", err)
+		return nil, err
+	}
+	return movies, nil
+}
+
+func (s *Storage) GetCoStars(ctx context.Context, actorids []int, opts models.PathOptions) ([]*models.CoStarLink, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT a.actorid, a.movieid, b.actorid
+FROM actormovie a
+         JOIN actormovie b ON a.movieid = b.movieid AND b.actorid <> a.actorid
+         JOIN movies m ON m.movieid = a.movieid
+WHERE a.actorid IN (SELECT value FROM json_each($1))
+  AND ($2 IS NULL OR CAST(strftime('%Y', m.releasedate) AS INTEGER) >= $2)
+  AND ($3 IS NULL OR CAST(strftime('%Y', m.releasedate) AS INTEGER) <= $3)
+ORDER BY a.actorid, b.actorid, m.releasedate`
+
+	var fromYear, toYear any
+	if opts.FromYear > 0 {
+		fromYear = opts.FromYear
+	}
+	if opts.ToYear > 0 {
+		toYear = opts.ToYear
+	}
+	rows, err := s.db.QueryContext(ctx, query, idList(actorids), fromYear, toYear)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting co-stars from the table", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var links []*models.CoStarLink
+	for rows.Next() {
+		var link models.CoStarLink
+		err = rows.Scan(
+			&link.ActorID,
+			&link.MovieID,
+			&link.CoStarID,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
+			return nil, err
+		}
+		links = append(links, &link)
+	}
+	return links, rows.Err()
+}
+
+func (s *Storage) GetAllCredits(ctx context.Context) (map[int][]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT movieid, actorid FROM actormovie`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting credits from the table", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	credits := make(map[int][]int)
+	for rows.Next() {
+		var movieid, actorid int
+		err = rows.Scan(&movieid, &actorid)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning credit rows", "err", err)
+			return nil, err
+		}
+		credits[movieid] = append(credits[movieid], actorid)
+	}
+	return credits, rows.Err()
+}
+
+func (s *Storage) GetTopCoStars(ctx context.Context, actorid int, limit int) ([]*models.CoStar, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT a.actorid, a.name, COUNT(*) AS movies
+FROM actormovie self
+         JOIN actormovie other ON other.movieid = self.movieid AND other.actorid <> self.actorid
+         JOIN actors a ON a.actorid = other.actorid
+WHERE self.actorid = $1
+GROUP BY a.actorid, a.name
+ORDER BY movies DESC, a.name COLLATE NOCASE
+LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, actorid, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting co-stars of actor from the table", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var costars []*models.CoStar
+	for rows.Next() {
+		var costar models.CoStar
+		err = rows.Scan(
+			&costar.ActorID,
+			&costar.Name,
+			&costar.Movies,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning co-star rows", "err", err)
+			return nil, err
+		}
+		costars = append(costars, &costar)
+	}
+	return costars, rows.Err()
+}
+
+// queryActors runs a query selecting a movie id and the actor columns
+// without updated_at, and groups the actors by movie.
+func (s *Storage) queryActors(ctx context.Context, query string, args ...any) (map[int][]*models.Actor, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	actors := make(map[int][]*models.Actor)
+	for rows.Next() {
+		var movieid int
+		var actor models.Actor
+		err = rows.Scan(
+			&movieid,
+			&actor.ActorID,
+			&actor.Name,
+			&actor.Gender,
+			&actor.DateOfBirth,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
+			return nil, err
+		}
+		actors[movieid] = append(actors[movieid], &actor)
+	}
+	return actors, rows.Err()
+}
+
+// queryCreditedMovies runs a query selecting an actor id and the movie
+// columns without updated_at, and groups the movies by actor.
+func (s *Storage) queryCreditedMovies(ctx context.Context, query string, args ...any) (map[int][]*models.Movie, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	movies := make(map[int][]*models.Movie)
+	for rows.Next() {
+		var actorid int
+		var movie models.Movie
+		err = rows.Scan(
+			&actorid,
+			&movie.MovieID,
+			&movie.Title,
+			&movie.Description,
+			&movie.Rating,
+			&movie.ReleaseDate,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning rows", "err", err)
+			return nil, err
+		}
+		movies[actorid] = append(movies[actorid], &movie)
+	}
+	return movies, rows.Err()
+}
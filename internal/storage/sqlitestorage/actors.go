@@ -0,0 +1,144 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"log/slog"
+	"time"
+)
+
+const actorColumns = `actorid, name, gender, dateofbirth, updated_at`
+
+func (s *Storage) GetAllActors(ctx context.Context) ([]*models.Actor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT ` + actorColumns + ` FROM actors ORDER BY name COLLATE NOCASE`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting all actors from the table", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var actors []*models.Actor
+	for rows.Next() {
+		var actor models.Actor
+		err = rows.Scan(
+			&actor.ActorID,
+			&actor.Name,
+			&actor.Gender,
+			&actor.DateOfBirth,
+			&actor.UpdatedAt,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning actor rows", "err", err)
+			return nil, err
+		}
+		actors = append(actors, &actor)
+	}
+	if err = rows.Err(); err != nil {
+		slog.ErrorContext(ctx, "Error getting all actors from the table", "err", err)
+		return nil, err
+	}
+
+	if len(actors) < 1 {
+		slog.DebugContext(ctx, "No actors found in the table")
+		return nil, models.ErrNoRecord
+	}
+	return actors, nil
+}
+
+func (s *Storage) CreateActor(ctx context.Context, a *models.Actor) (*models.Actor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `INSERT INTO actors (name, gender, dateofbirth) VALUES ($1, $2, $3) RETURNING actorid`
+	err := s.db.QueryRowContext(ctx, query, a.Name, a.Gender, a.DateOfBirth).Scan(&a.ActorID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error inserting actor into the table", "err", err)
+		return nil, err
+	}
+	return a, nil
+}
+
+func (s *Storage) GetActorByID(ctx context.Context, id int) (*models.Actor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT ` + actorColumns + ` FROM actors WHERE actorid = $1`
+	actor := &models.Actor{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&actor.ActorID,
+		&actor.Name,
+		&actor.Gender,
+		&actor.DateOfBirth,
+		&actor.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNoRecord
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error getting actor by id from the table", "err", err)
+		return nil, err
+	}
+
+	if !actor.DateOfBirth.Valid {
+		return nil, models.ErrNoRecord
+	}
+	return actor, nil
+}
+
+func (s *Storage) UpdateActor(ctx context.Context, a *models.Actor) (*models.Actor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `UPDATE actors SET name = COALESCE(NULLIF($1, ''), name),
+	gender = COALESCE(NULLIF($2, ''), gender),
+	dateofbirth = COALESCE($3, dateofbirth),
+	updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+	WHERE actorid = $4`
+	_, err := s.db.ExecContext(ctx, query, a.Name, a.Gender, dateOrNil(a.DateOfBirth.Time), a.ActorID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating actor in the table", "actorid", a.ActorID, "err", err)
+		return nil, err
+	}
+	res, err := s.GetActorByID(ctx, a.ActorID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error returning updated actor from the table", "err", err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *Storage) DeleteActor(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `DELETE FROM actors WHERE actorid = $1`
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting actor from the table", "err", err)
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNoRecord
+	}
+	return nil
+}
+
+// GetActorsModifiedAt returns the time of the last change to the actors
+// table.
+func (s *Storage) GetActorsModifiedAt(ctx context.Context) (time.Time, error) {
+	return s.modifiedAt(ctx, "actors")
+}
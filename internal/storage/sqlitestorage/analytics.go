@@ -0,0 +1,271 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"filmoteka/internal/domain/models"
+	"log/slog"
+)
+
+func (s *Storage) GetTotals(ctx context.Context) (*models.CatalogTotals, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.analyticsTimeout)
+	defer cancel()
+
+	query := `SELECT (SELECT COUNT(*) FROM movies),
+       (SELECT COUNT(*) FROM actors),
+       (SELECT COUNT(*) FROM actormovie),
+       (SELECT COUNT(*) FROM movies m WHERE NOT EXISTS (SELECT 1 FROM actormovie am WHERE am.movieid = m.movieid)),
+       (SELECT COUNT(*) FROM actors a WHERE NOT EXISTS (SELECT 1 FROM actormovie am WHERE am.actorid = a.actorid))`
+
+	totals := &models.CatalogTotals{}
+	err := s.db.QueryRowContext(ctx, query).Scan(
+		&totals.Movies,
+		&totals.Actors,
+		&totals.Credits,
+		&totals.MoviesWithoutCast,
+		&totals.ActorsWithoutMovies,
+	)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error counting catalog totals", "err", err)
+		return nil, err
+	}
+	return totals, nil
+}
+
+func (s *Storage) GetMoviesPerYear(ctx context.Context) ([]*models.YearCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.analyticsTimeout)
+	defer cancel()
+
+	query := `SELECT CAST(strftime('%Y', releasedate) AS INTEGER) AS year, COUNT(*)
+FROM movies
+WHERE releasedate IS NOT NULL
+GROUP BY year
+ORDER BY year`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error counting movies per year", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var years []*models.YearCount
+	for rows.Next() {
+		var year models.YearCount
+		err = rows.Scan(&year.Year, &year.Count)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning year rows", "err", err)
+			return nil, err
+		}
+		years = append(years, &year)
+	}
+	return years, rows.Err()
+}
+
+// GetRatingCounts counts movies per whole rating point, ratings of 10 and
+// above fall into bucket 9.
+func (s *Storage) GetRatingCounts(ctx context.Context) (map[int]int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.analyticsTimeout)
+	defer cancel()
+
+	// the cast truncates towards zero, which only differs from FLOOR below
+	// zero, where the bucket is 0 either way
+	query := `SELECT MIN(MAX(CAST(rating AS INTEGER), 0), 9) AS bucket, COUNT(*)
+FROM movies
+WHERE rating IS NOT NULL
+GROUP BY bucket`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error counting movie ratings", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	counts := make(map[int]int)
+	for rows.Next() {
+		var bucket, count int
+		err = rows.Scan(&bucket, &count)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning rating rows", "err", err)
+			return nil, err
+		}
+		counts[bucket] = count
+	}
+	return counts, rows.Err()
+}
+
+func (s *Storage) GetMostCreditedActors(ctx context.Context, limit int) ([]*models.CreditedActor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.analyticsTimeout)
+	defer cancel()
+
+	query := `SELECT a.actorid, a.name, COUNT(*) AS movies
+FROM actors a
+         JOIN actormovie am ON am.actorid = a.actorid
+GROUP BY a.actorid, a.name
+ORDER BY movies DESC, a.name COLLATE NOCASE
+LIMIT $1`
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting most credited actors", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var actors []*models.CreditedActor
+	for rows.Next() {
+		var actor models.CreditedActor
+		err = rows.Scan(&actor.ActorID, &actor.Name, &actor.Movies)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning actor rows", "err", err)
+			return nil, err
+		}
+		actors = append(actors, &actor)
+	}
+	return actors, rows.Err()
+}
+
+func (s *Storage) GetMoviesWithoutCast(ctx context.Context, limit int) ([]*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.analyticsTimeout)
+	defer cancel()
+
+	query := `SELECT m.movieid, m.title, m.description, m.rating, m.releasedate
+FROM movies m
+WHERE NOT EXISTS (SELECT 1 FROM actormovie am WHERE am.movieid = m.movieid)
+ORDER BY m.title COLLATE NOCASE
+LIMIT $1`
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting movies without cast", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var movies []*models.Movie
+	for rows.Next() {
+		var movie models.Movie
+		err = rows.Scan(
+			&movie.MovieID,
+			&movie.Title,
+			&movie.Description,
+			&movie.Rating,
+			&movie.ReleaseDate,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning movie rows", "err", err)
+			return nil, err
+		}
+		movies = append(movies, &movie)
+	}
+	return movies, rows.Err()
+}
+
+func (s *Storage) GetActorsWithoutMovies(ctx context.Context, limit int) ([]*models.Actor, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.analyticsTimeout)
+	defer cancel()
+
+	query := `SELECT a.actorid, a.name, a.gender, a.dateofbirth
+FROM actors a
+WHERE NOT EXISTS (SELECT 1 FROM actormovie am WHERE am.actorid = a.actorid)
+ORDER BY a.name COLLATE NOCASE
+LIMIT $1`
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting actors without movies", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var actors []*models.Actor
+	for rows.Next() {
+		var actor models.Actor
+		err = rows.Scan(
+			&actor.ActorID,
+			&actor.Name,
+			&actor.Gender,
+			&actor.DateOfBirth,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning actor rows", "err", err)
+			return nil, err
+		}
+		actors = append(actors, &actor)
+	}
+	return actors, rows.Err()
+}
+
+func (s *Storage) GetMonthlyGrowth(ctx context.Context) ([]*models.GrowthPoint, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.analyticsTimeout)
+	defer cancel()
+
+	query := `WITH m AS (SELECT strftime('%Y-%m', created_at) AS month, COUNT(*) AS n FROM movies GROUP BY month),
+     a AS (SELECT strftime('%Y-%m', created_at) AS month, COUNT(*) AS n FROM actors GROUP BY month),
+     months AS (SELECT month FROM m UNION SELECT month FROM a)
+SELECT months.month,
+       COALESCE(m.n, 0),
+       COALESCE(a.n, 0),
+       SUM(COALESCE(m.n, 0)) OVER (ORDER BY months.month),
+       SUM(COALESCE(a.n, 0)) OVER (ORDER BY months.month)
+FROM months
+         LEFT JOIN m ON m.month = months.month
+         LEFT JOIN a ON a.month = months.month
+ORDER BY months.month`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting catalog growth", "err", err)
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var growth []*models.GrowthPoint
+	for rows.Next() {
+		var point models.GrowthPoint
+		err = rows.Scan(
+			&point.Month,
+			&point.Movies,
+			&point.Actors,
+			&point.TotalMovies,
+			&point.TotalActors,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning growth rows", "err", err)
+			return nil, err
+		}
+		growth = append(growth, &point)
+	}
+	return growth, rows.Err()
+}
@@ -0,0 +1,118 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations are named <version>_<description>.sql like those of Postgres,
+// but kept apart since the dialects differ.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type migration struct {
+	version int
+	name    string
+}
+
+func migrations() ([]migration, error) {
+	names, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+	var list []migration
+	for _, name := range names {
+		prefix, _, _ := strings.Cut(path.Base(name), "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version prefix", name)
+		}
+		list = append(list, migration{version: version, name: name})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].version < list[j].version
+	})
+	return list, nil
+}
+
+// LatestVersion returns the version of the newest embedded migration.
+func LatestVersion() int {
+	list, err := migrations()
+	if err != nil || len(list) == 0 {
+		return 0
+	}
+	return list[len(list)-1].version
+}
+
+// Version returns the newest migration applied to db.
+func Version(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+// Migrate applies every embedded migration that has not been applied to db
+// yet, each in its own transaction, giving up after timeout.
+func Migrate(db *sql.DB, timeout time.Duration) error {
+	list, err := migrations()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	_, err = db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations
+(
+    version    INTEGER PRIMARY KEY,
+    applied_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+)`)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range list {
+		script, err := migrationFiles.ReadFile(m.name)
+		if err != nil {
+			return err
+		}
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		// another process may have applied it since the last one
+		var applied bool
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, m.version).Scan(&applied)
+		if err != nil || applied {
+			_ = tx.Rollback()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err = tx.ExecContext(ctx, string(script)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+		if _, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, m.version); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+		slog.Info("Applied migration", "migration", m.name)
+	}
+	return nil
+}
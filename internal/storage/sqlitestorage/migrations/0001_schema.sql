@@ -0,0 +1,119 @@
+-- The schema of the Postgres migrations up to 0006, without the rate limit
+-- table, which only ratelimit.PostgresStore uses. Times are stored as text
+-- in UTC, which sorts like the times themselves.
+CREATE TABLE users
+(
+    userid   INTEGER PRIMARY KEY,
+    email    TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL,
+    role     TEXT NOT NULL DEFAULT 'user'
+);
+
+CREATE TABLE actors
+(
+    actorid     INTEGER PRIMARY KEY,
+    name        TEXT      NOT NULL,
+    gender      TEXT,
+    dateofbirth DATE,
+    created_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE movies
+(
+    movieid     INTEGER PRIMARY KEY,
+    title       TEXT      NOT NULL CHECK (length(title) <= 150),
+    description TEXT,
+    rating      REAL,
+    releasedate DATE,
+    created_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    updated_at  TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE TABLE actormovie
+(
+    actorid    INTEGER   NOT NULL REFERENCES actors (actorid) ON DELETE CASCADE,
+    movieid    INTEGER   NOT NULL REFERENCES movies (movieid) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now')),
+    PRIMARY KEY (actorid, movieid)
+);
+
+CREATE INDEX actormovie_movieid_idx ON actormovie (movieid);
+
+-- SQLite has no statement triggers, so unlike Postgres a statement that
+-- changes no row does not count as a change.
+CREATE TABLE table_changes
+(
+    table_name TEXT PRIMARY KEY,
+    changed_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+INSERT INTO table_changes (table_name) VALUES ('movies'), ('actors'), ('actormovie');
+
+CREATE TRIGGER movies_inserted AFTER INSERT ON movies
+BEGIN
+    UPDATE table_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE table_name = 'movies';
+END;
+CREATE TRIGGER movies_updated AFTER UPDATE ON movies
+BEGIN
+    UPDATE table_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE table_name = 'movies';
+END;
+CREATE TRIGGER movies_deleted AFTER DELETE ON movies
+BEGIN
+    UPDATE table_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE table_name = 'movies';
+END;
+CREATE TRIGGER actors_inserted AFTER INSERT ON actors
+BEGIN
+    UPDATE table_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE table_name = 'actors';
+END;
+CREATE TRIGGER actors_updated AFTER UPDATE ON actors
+BEGIN
+    UPDATE table_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE table_name = 'actors';
+END;
+CREATE TRIGGER actors_deleted AFTER DELETE ON actors
+BEGIN
+    UPDATE table_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE table_name = 'actors';
+END;
+CREATE TRIGGER actormovie_inserted AFTER INSERT ON actormovie
+BEGIN
+    UPDATE table_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE table_name = 'actormovie';
+END;
+CREATE TRIGGER actormovie_deleted AFTER DELETE ON actormovie
+BEGIN
+    UPDATE table_changes SET changed_at = strftime('%Y-%m-%d %H:%M:%f', 'now') WHERE table_name = 'actormovie';
+END;
+
+-- Movie search. The trigram tokenizer matches any substring of at least
+-- three characters, ignoring case, like the ILIKE search of Postgres.
+CREATE VIRTUAL TABLE movies_fts USING fts5(title, description, content='movies', content_rowid='movieid', tokenize='trigram');
+
+CREATE TRIGGER movies_fts_inserted AFTER INSERT ON movies
+BEGIN
+    INSERT INTO movies_fts (rowid, title, description) VALUES (NEW.movieid, NEW.title, NEW.description);
+END;
+CREATE TRIGGER movies_fts_updated AFTER UPDATE OF title, description ON movies
+BEGIN
+    INSERT INTO movies_fts (movies_fts, rowid, title, description) VALUES ('delete', OLD.movieid, OLD.title, OLD.description);
+    INSERT INTO movies_fts (rowid, title, description) VALUES (NEW.movieid, NEW.title, NEW.description);
+END;
+CREATE TRIGGER movies_fts_deleted AFTER DELETE ON movies
+BEGIN
+    INSERT INTO movies_fts (movies_fts, rowid, title, description) VALUES ('delete', OLD.movieid, OLD.title, OLD.description);
+END;
+
+CREATE TABLE login_failures
+(
+    key          TEXT PRIMARY KEY,
+    failures     INTEGER   NOT NULL,
+    last_failure TIMESTAMP NOT NULL
+);
+
+CREATE TABLE password_resets
+(
+    token_hash TEXT PRIMARY KEY,
+    userid     INTEGER   NOT NULL REFERENCES users (userid) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    used_at    TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
+
+CREATE INDEX password_resets_userid_idx ON password_resets (userid);
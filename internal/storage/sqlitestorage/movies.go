@@ -0,0 +1,209 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const movieColumns = `movieid, title, description, rating, releasedate, updated_at`
+
+func (s *Storage) GetAllMovies(ctx context.Context, sortParam string) ([]*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var query string
+	switch sortParam {
+	case "date":
+		query = `SELECT ` + movieColumns + ` FROM movies ORDER BY releasedate`
+	case "title":
+		query = `SELECT ` + movieColumns + ` FROM movies ORDER BY title COLLATE NOCASE`
+	case "rating", "":
+		query = `SELECT ` + movieColumns + ` FROM movies ORDER BY rating DESC`
+	default:
+		slog.InfoContext(ctx, "Invalid sort parameter", "sort", sortParam)
+		return nil, errors.New("invalid sort parameter")
+	}
+
+	movies, err := s.queryMovies(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting all movies from the table", "err", err)
+		return nil, err
+	}
+	if len(movies) < 1 {
+		slog.DebugContext(ctx, "No movies found in the table")
+		return nil, models.ErrNoRecord
+	}
+	return movies, nil
+}
+
+func (s *Storage) GetMovieByID(ctx context.Context, id int) (*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT ` + movieColumns + ` FROM movies WHERE movieid = $1`
+	movie := &models.Movie{}
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&movie.MovieID,
+		&movie.Title,
+		&movie.Description,
+		&movie.Rating,
+		&movie.ReleaseDate,
+		&movie.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNoRecord
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error getting movie by id from the table", "err", err)
+		return nil, err
+	}
+
+	if !movie.ReleaseDate.Valid {
+		return nil, models.ErrNoRecord
+	}
+	return movie, nil
+}
+
+func (s *Storage) CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `INSERT INTO movies (title, description, rating, releasedate)
+	VALUES ($1, $2, round($3, 1), $4) RETURNING movieid`
+	err := s.db.QueryRowContext(ctx, query, m.Title, m.Description, m.Rating, m.ReleaseDate).Scan(&m.MovieID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error inserting movie into a table", "err", err)
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *Storage) UpdateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `UPDATE movies SET title = COALESCE(NULLIF($1, ''), title),
+	description = COALESCE(NULLIF($2, ''), description),
+	rating = CASE WHEN $3 = 0 THEN rating ELSE round($3, 1) END,
+	releasedate = COALESCE($4, releasedate),
+	updated_at = strftime('%Y-%m-%d %H:%M:%f', 'now')
+	WHERE movieid = $5`
+	_, err := s.db.ExecContext(ctx, query, m.Title, m.Description, m.Rating, dateOrNil(m.ReleaseDate.Time), m.MovieID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating movie in the table", "movieid", m.MovieID, "err", err)
+		return nil, err
+	}
+	res, err := s.GetMovieByID(ctx, m.MovieID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error returning updated movie from the table ", "err", err)
+		return nil, err
+	}
+	return res, nil
+}
+
+func (s *Storage) DeleteMovie(ctx context.Context, id int) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `DELETE FROM movies WHERE movieid = $1`
+	res, err := s.db.ExecContext(ctx, query, id)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error deleting movie from the table", "err", err)
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return models.ErrNoRecord
+	}
+	return nil
+}
+
+// GetMovieByMovieName finds the movies whose title or description contains
+// moviename through the full text index. The trigram index cannot look up
+// shorter strings, those are matched with LIKE on the folded columns.
+func (s *Storage) GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var movies []*models.Movie
+	var err error
+	if utf8.RuneCountInString(moviename) >= 3 {
+		query := `SELECT ` + movieColumns + ` FROM movies
+		WHERE movieid IN (SELECT rowid FROM movies_fts WHERE movies_fts MATCH $1)`
+		movies, err = s.queryMovies(ctx, query, ftsPhrase(moviename))
+	} else {
+		query := `SELECT ` + movieColumns + ` FROM movies WHERE fold(title) LIKE $1 OR fold(description) LIKE $1`
+		movies, err = s.queryMovies(ctx, query, "%"+strings.ToLower(moviename)+"%")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting movies by movie name from the table", "err", err)
+		return nil, err
+	}
+
+	if len(movies) < 1 {
+		slog.DebugContext(ctx, "No movies found in the table")
+		return nil, models.ErrNoRecord
+	}
+	return movies, nil
+}
+
+// GetMoviesModifiedAt returns the time of the last change to the movies
+// table.
+func (s *Storage) GetMoviesModifiedAt(ctx context.Context) (time.Time, error) {
+	return s.modifiedAt(ctx, "movies")
+}
+
+func (s *Storage) modifiedAt(ctx context.Context, table string) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var changed time.Time
+	query := `SELECT changed_at FROM table_changes WHERE table_name = $1`
+	err := s.db.QueryRowContext(ctx, query, table).Scan(&changed)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error getting last change of a table", "table", table, "err", err)
+		return time.Time{}, err
+	}
+	return changed, nil
+}
+
+// queryMovies runs a query selecting movieColumns.
+func (s *Storage) queryMovies(ctx context.Context, query string, args ...any) ([]*models.Movie, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err = rows.Close()
+		if err != nil {
+			slog.ErrorContext(ctx, "Error closing rows", "err", err)
+		}
+	}(rows)
+
+	var movies []*models.Movie
+	for rows.Next() {
+		var movie models.Movie
+		err = rows.Scan(
+			&movie.MovieID,
+			&movie.Title,
+			&movie.Description,
+			&movie.Rating,
+			&movie.ReleaseDate,
+			&movie.UpdatedAt,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error scanning movie rows", "err", err)
+			return nil, err
+		}
+		movies = append(movies, &movie)
+	}
+	return movies, rows.Err()
+}
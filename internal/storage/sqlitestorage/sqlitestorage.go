@@ -0,0 +1,98 @@
+// Package sqlitestorage keeps the catalog in a SQLite file, for running
+// filmoteka as a single binary without Postgres. It implements the storage
+// interfaces of the usecases with the semantics of the Postgres storages.
+package sqlitestorage
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"modernc.org/sqlite"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DriverName = "sqlite"
+
+// fold(s) lowers s like strings.ToLower. The lower() and LIKE of SQLite only
+// fold ASCII, the searches fold any letter like ILIKE does in Postgres.
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("fold", 1, func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return args[0], nil
+		}
+		return strings.ToLower(s), nil
+	})
+}
+
+type Storage struct {
+	db               *sql.DB
+	timeout          time.Duration
+	analyticsTimeout time.Duration
+}
+
+// New returns a storage on db that gives every query up to timeout, and the
+// analytics queries up to analyticsTimeout.
+func New(db *sql.DB, timeout time.Duration, analyticsTimeout time.Duration) *Storage {
+	return &Storage{
+		db:               db,
+		timeout:          timeout,
+		analyticsTimeout: analyticsTimeout,
+	}
+}
+
+// IsDSN reports whether dsn names a SQLite database, sqlite:<path>.
+func IsDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, "sqlite:")
+}
+
+// DriverDSN turns sqlite:<path>[?<options>] into the DSN of the driver. It
+// enables foreign keys, which cascade deletes, waits for locks instead of
+// failing and takes the write lock at the start of every transaction.
+func DriverDSN(dsn string) string {
+	file, query, _ := strings.Cut(strings.TrimPrefix(dsn, "sqlite:"), "?")
+	if rest, ok := strings.CutPrefix(file, "//"); ok {
+		file = rest
+	}
+
+	options, err := url.ParseQuery(query)
+	if err != nil {
+		options = url.Values{}
+	}
+	options.Add("_pragma", "foreign_keys(1)")
+	options.Add("_pragma", "busy_timeout(5000)")
+	options.Add("_pragma", "journal_mode(WAL)")
+	if !options.Has("_txlock") {
+		options.Set("_txlock", "immediate")
+	}
+	// times as text SQLite can parse, see the schema
+	options.Set("_time_format", "sqlite")
+	return "file:" + file + "?" + options.Encode()
+}
+
+// ftsPhrase quotes s as an FTS5 phrase, which the trigram tokenizer matches
+// as a substring.
+func ftsPhrase(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// idList encodes ids for IN (SELECT value FROM json_each($1)), SQLite has
+// no arrays.
+func idList(ids []int) string {
+	if ids == nil {
+		ids = []int{}
+	}
+	b, _ := json.Marshal(ids)
+	return string(b)
+}
+
+// dateOrNil returns the date to store, or nil for dates before the year
+// 1000, which an update leaves unchanged.
+func dateOrNil(t time.Time) any {
+	if t.Year() < 1000 {
+		return nil
+	}
+	return t.UTC()
+}
@@ -0,0 +1,45 @@
+package sqlitestorage
+
+import (
+	"database/sql"
+	"filmoteka/internal/storage/storagetest"
+	"filmoteka/internal/tracing"
+	"testing"
+	"time"
+)
+
+// openTestDB creates a migrated database in a temporary directory.
+func openTestDB(tb testing.TB) *sql.DB {
+	db, err := tracing.OpenDB(DriverName, DriverDSN("sqlite:"+tb.TempDir()+"/test.db"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.Close() })
+	if err = Migrate(db, time.Minute); err != nil {
+		tb.Fatal(err)
+	}
+	return db
+}
+
+func TestContract(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		db := openTestDB(t)
+		return storagetest.Backend{
+			Storage: New(db, 5*time.Second, 10*time.Second),
+			AddUser: func(email string, passwordHash string, role string) error {
+				_, err := db.Exec(`INSERT INTO users (email, password, role) VALUES ($1, $2, $3)`, email, passwordHash, role)
+				return err
+			},
+		}
+	})
+}
+
+func TestGetActorsAndMoviesForMovieStatements(t *testing.T) {
+	db := openTestDB(t)
+	storagetest.CastStatements(t, db, New(db, 5*time.Second, 10*time.Second))
+}
+
+func BenchmarkGetActorsAndMoviesForMovie(b *testing.B) {
+	db := openTestDB(b)
+	storagetest.BenchmarkCast(b, db, New(db, 5*time.Second, 10*time.Second))
+}
@@ -0,0 +1,160 @@
+package sqlitestorage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"log/slog"
+	"time"
+)
+
+func (s *Storage) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT userid, email, password, role FROM users WHERE email = $1`
+
+	user := &models.User{}
+	err := s.db.QueryRowContext(ctx, query, email).Scan(&user.UserID, &user.Email, &user.Password, &user.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNoRecord
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error getting user by email from the table", "err", err)
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetLoginFailures returns the failures recorded under key, zero if there
+// are none.
+func (s *Storage) GetLoginFailures(ctx context.Context, key string) (*models.LoginFailures, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT failures, last_failure FROM login_failures WHERE key = $1`
+
+	f := &models.LoginFailures{}
+	err := s.db.QueryRowContext(ctx, query, key).Scan(&f.Failures, &f.LastFailure)
+	if errors.Is(err, sql.ErrNoRows) {
+		return f, nil
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error getting login failures from the table", "err", err)
+		return nil, err
+	}
+	return f, nil
+}
+
+// RecordLoginFailure adds a failure under key. A counter whose last failure
+// is older than resetAfter starts over.
+func (s *Storage) RecordLoginFailure(ctx context.Context, key string, resetAfter time.Duration) (*models.LoginFailures, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `INSERT INTO login_failures AS lf (key, failures, last_failure)
+VALUES ($1, 1, $2)
+ON CONFLICT (key) DO UPDATE SET
+    failures     = CASE WHEN lf.last_failure < $3 THEN 1 ELSE lf.failures + 1 END,
+    last_failure = $2
+RETURNING failures, last_failure`
+
+	now := time.Now().UTC()
+	f := &models.LoginFailures{}
+	err := s.db.QueryRowContext(ctx, query, key, now, now.Add(-resetAfter)).Scan(&f.Failures, &f.LastFailure)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error recording login failure in the table", "err", err)
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *Storage) ClearLoginFailures(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `DELETE FROM login_failures WHERE key = $1`
+	_, err := s.db.ExecContext(ctx, query, key)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error clearing login failures in the table", "err", err)
+		return err
+	}
+	return nil
+}
+
+func (s *Storage) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `UPDATE users SET password = $2 WHERE userid = $1`
+	_, err := s.db.ExecContext(ctx, query, userID, passwordHash)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating user password in the table", "err", err)
+		return err
+	}
+	return nil
+}
+
+// CreatePasswordReset stores the hash of a reset token for the user.
+func (s *Storage) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `INSERT INTO password_resets (token_hash, userid, expires_at) VALUES ($1, $2, $3)`
+	_, err := s.db.ExecContext(ctx, query, tokenHash, userID, expiresAt.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "Error inserting password reset into the table", "err", err)
+		return err
+	}
+	return nil
+}
+
+// ResetPassword sets the password of the user the unused, unexpired token
+// with tokenHash belongs to, and uses up all reset tokens of that user. It
+// returns models.ErrNoRecord if there is no such token.
+func (s *Storage) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting password reset transaction", "err", err)
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC()
+	user := &models.User{}
+	query := `UPDATE password_resets SET used_at = $2
+	WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+	RETURNING userid`
+	err = tx.QueryRowContext(ctx, query, tokenHash, now).Scan(&user.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNoRecord
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error using password reset from the table", "err", err)
+		return nil, err
+	}
+
+	query = `UPDATE users SET password = $2 WHERE userid = $1 RETURNING email, password, role`
+	err = tx.QueryRowContext(ctx, query, user.UserID, passwordHash).Scan(&user.Email, &user.Password, &user.Role)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating user password in the table", "err", err)
+		return nil, err
+	}
+
+	query = `UPDATE password_resets SET used_at = $2 WHERE userid = $1 AND used_at IS NULL`
+	_, err = tx.ExecContext(ctx, query, user.UserID, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error using other password resets from the table", "err", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error committing password reset", "err", err)
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,84 @@
+package storagetest_test
+
+import (
+	"context"
+	"filmoteka/internal/domain/models"
+	"filmoteka/internal/storage/actormoviestorage"
+	"filmoteka/internal/storage/actorstorage"
+	"filmoteka/internal/storage/migrations"
+	"filmoteka/internal/storage/moviestorage"
+	"filmoteka/internal/storage/storagetest"
+	"filmoteka/internal/storage/userstorage"
+	"filmoteka/internal/tracing"
+	_ "github.com/jackc/pgx/v4/stdlib"
+	"os"
+	"testing"
+	"time"
+)
+
+// postgres combines the Postgres storages. The credits storage also looks
+// up movies and actors, so its methods are forwarded by hand.
+type postgres struct {
+	*moviestorage.MovieStorage
+	*actorstorage.ActorStorage
+	*userstorage.UserStorage
+	credits *actormoviestorage.ActorMovieStorage
+}
+
+func (s *postgres) GetActorsForMovie(ctx context.Context, movieid int) ([]*models.Actor, *models.Movie, error) {
+	return s.credits.GetActorsForMovie(ctx, movieid)
+}
+
+func (s *postgres) GetMoviesForActor(ctx context.Context, actorid int) ([]*models.Movie, *models.Actor, error) {
+	return s.credits.GetMoviesForActor(ctx, actorid)
+}
+
+func (s *postgres) AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error) {
+	return s.credits.AddActorToMovie(ctx, actorid, movieid)
+}
+
+func (s *postgres) GetMovieByActorName(ctx context.Context, name string, surname string) ([]*models.MovieWithActor, error) {
+	return s.credits.GetMovieByActorName(ctx, name, surname)
+}
+
+func (s *postgres) DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error) {
+	return s.credits.DeleteActorFromMovie(ctx, actorid, movieid)
+}
+
+// TestPostgres runs the contract against the database named by
+// FILMOTEKA_TEST_POSTGRES_DSN, skipping without one. It leaves the rows of
+// other users of the database alone.
+func TestPostgres(t *testing.T) {
+	dsn := os.Getenv("FILMOTEKA_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("FILMOTEKA_TEST_POSTGRES_DSN not set")
+	}
+	db, err := tracing.OpenDB("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err = migrations.Run(db, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	storagetest.Run(t, func(t *testing.T) storagetest.Backend {
+		return storagetest.Backend{
+			Storage: &postgres{
+				MovieStorage: moviestorage.New(db, 5*time.Second),
+				ActorStorage: actorstorage.New(db, 5*time.Second),
+				UserStorage:  userstorage.New(db, 5*time.Second),
+				credits:      actormoviestorage.New(db, 5*time.Second),
+			},
+			AddUser: func(email string, passwordHash string, role string) error {
+				_, err := db.Exec(`INSERT INTO users (email, password, role) VALUES ($1, $2, $3)`, email, passwordHash, role)
+				if err == nil {
+					t.Cleanup(func() {
+						_, _ = db.Exec(`DELETE FROM users WHERE email = $1`, email)
+					})
+				}
+				return err
+			},
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package storagetest
+
+import (
+	"context"
+	"database/sql"
+	"filmoteka/internal/domain/models"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+	"time"
+)
+
+// CastStorage loads a cast together with the filmography of each member.
+type CastStorage interface {
+	GetActorsAndMoviesForMovie(ctx context.Context, id int, opts models.CastOptions) ([]*models.ActorMovies, *models.Movie, error)
+}
+
+// CastStatements checks that loading a cast of 40 takes as many statements
+// as a cast of 1. db has to be opened with tracing.OpenDB, s has to use it.
+func CastStatements(t *testing.T, db *sql.DB, s CastStorage) {
+	small := seedCast(t, db, 1)
+	large := seedCast(t, db, 40)
+	recorder := recordStatements(t)
+
+	smallCount := castStatements(t, s, recorder, small)
+	largeCount := castStatements(t, s, recorder, large)
+	if smallCount != largeCount {
+		t.Errorf("cast of 1 took %d statements, cast of 40 took %d", smallCount, largeCount)
+	}
+}
+
+// BenchmarkCast loads casts of 1 and 40 and reports the statements each
+// load takes, see CastStatements.
+func BenchmarkCast(b *testing.B, db *sql.DB, s CastStorage) {
+	for _, size := range []int{1, 40} {
+		movieID := seedCast(b, db, size)
+		b.Run(fmt.Sprintf("cast=%d", size), func(b *testing.B) {
+			recorder := recordStatements(b)
+			for i := 0; i < b.N; i++ {
+				castStatements(b, s, recorder, movieID)
+			}
+			b.ReportMetric(float64(statements(recorder))/float64(b.N), "statements/op")
+		})
+	}
+}
+
+// seedCast adds a movie with a cast of size actors, each of whom also played
+// in three other movies, and returns the id of the movie. The rows are
+// deleted when the test ends.
+func seedCast(tb testing.TB, db *sql.DB, size int) int {
+	ctx := context.Background()
+	var movieIDs, actorIDs []int
+	tb.Cleanup(func() {
+		for _, id := range movieIDs {
+			_, _ = db.ExecContext(ctx, `DELETE FROM movies WHERE movieid = $1`, id)
+		}
+		for _, id := range actorIDs {
+			_, _ = db.ExecContext(ctx, `DELETE FROM actors WHERE actorid = $1`, id)
+		}
+	})
+
+	insertMovie := func(title string, year int) int {
+		var id int
+		err := db.QueryRowContext(ctx, `INSERT INTO movies (title, description, rating, releasedate) VALUES ($1, '', 7, $2) RETURNING movieid`,
+			title, time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)).Scan(&id)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		movieIDs = append(movieIDs, id)
+		return id
+	}
+	credit := func(actorID int, movieID int) {
+		_, err := db.ExecContext(ctx, `INSERT INTO actormovie (actorid, movieid) VALUES ($1, $2)`, actorID, movieID)
+		if err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	movieID := insertMovie(fmt.Sprintf("cast of %d", size), 2000)
+	for i := 0; i < size; i++ {
+		var actorID int
+		err := db.QueryRowContext(ctx, `INSERT INTO actors (name, gender, dateofbirth) VALUES ($1, 'female', $2) RETURNING actorid`,
+			fmt.Sprintf("actor %d of %d", i, size), time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)).Scan(&actorID)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		actorIDs = append(actorIDs, actorID)
+		credit(actorID, movieID)
+		for j := 0; j < 3; j++ {
+			credit(actorID, insertMovie(fmt.Sprintf("movie %d of actor %d of %d", j, i, size), 1990+j))
+		}
+	}
+	return movieID
+}
+
+// recordStatements records the spans of the statements run through
+// databases opened with tracing.OpenDB until the test ends.
+func recordStatements(tb testing.TB) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	tb.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func statements(recorder *tracetest.SpanRecorder) int {
+	n := 0
+	for _, span := range recorder.Ended() {
+		if span.SpanKind() == trace.SpanKindClient {
+			n++
+		}
+	}
+	return n
+}
+
+// castStatements returns the number of statements GetActorsAndMoviesForMovie
+// runs for the movie.
+func castStatements(tb testing.TB, s CastStorage, recorder *tracetest.SpanRecorder, movieID int) int {
+	before := statements(recorder)
+	cast, _, err := s.GetActorsAndMoviesForMovie(context.Background(), movieID, models.CastOptions{})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	for _, member := range cast {
+		if len(member.Movies) != 4 {
+			tb.Fatalf("actor %d has %d movies, want 4", member.ActorId, len(member.Movies))
+		}
+	}
+	return statements(recorder) - before
+}
@@ -0,0 +1,376 @@
+// Package storagetest is the contract every storage backend has to keep
+// with the usecases. Run it from the tests of a backend.
+package storagetest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Storage is the part of a backend the contract covers.
+type Storage interface {
+	GetAllMovies(ctx context.Context, sortParam string) ([]*models.Movie, error)
+	GetMovieByID(ctx context.Context, id int) (*models.Movie, error)
+	CreateMovie(ctx context.Context, m *models.Movie) (*models.Movie, error)
+	DeleteMovie(ctx context.Context, id int) error
+	GetMovieByMovieName(ctx context.Context, moviename string) ([]*models.Movie, error)
+
+	GetActorByID(ctx context.Context, id int) (*models.Actor, error)
+	CreateActor(ctx context.Context, a *models.Actor) (*models.Actor, error)
+	DeleteActor(ctx context.Context, id int) error
+
+	GetActorsForMovie(ctx context.Context, movieid int) ([]*models.Actor, *models.Movie, error)
+	GetMoviesForActor(ctx context.Context, actorid int) ([]*models.Movie, *models.Actor, error)
+	AddActorToMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, bool, error)
+	DeleteActorFromMovie(ctx context.Context, actorid int, movieid int) (*models.Actor, *models.Movie, error)
+	GetMovieByActorName(ctx context.Context, name string, surname string) ([]*models.MovieWithActor, error)
+
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+}
+
+// Backend is a storage under test. Storages have no way to create users,
+// so AddUser inserts one the way the backend is seeded, failing if the
+// email is taken.
+type Backend struct {
+	Storage Storage
+	AddUser func(email string, passwordHash string, role string) error
+}
+
+// Run checks the backend returned by newBackend. The backend may hold other
+// rows: the checks only look at the rows they create, which carry a unique
+// tag and are deleted again.
+func Run(t *testing.T, newBackend func(t *testing.T) Backend) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, b Backend, tag string)
+	}{
+		{"Sorting", testSorting},
+		{"Search", testSearch},
+		{"SearchFoldsCase", testSearchFoldsCase},
+		{"Missing", testMissing},
+		{"Credits", testCredits},
+		{"UserEmails", testUserEmails},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tag := fmt.Sprintf("st%d", time.Now().UnixNano())
+			tt.test(t, newBackend(t), tag)
+		})
+	}
+}
+
+// missingID is not the id of any row.
+const missingID = math.MaxInt32
+
+func date(year int) models.Date {
+	return models.Date{NullTime: sql.NullTime{Time: time.Date(year, time.June, 1, 0, 0, 0, 0, time.UTC), Valid: true}}
+}
+
+func createMovie(t *testing.T, s Storage, title string, description string, rating float64, year int) int {
+	t.Helper()
+	m, err := s.CreateMovie(context.Background(), &models.Movie{Title: title, Description: description, Rating: rating, ReleaseDate: date(year)})
+	if err != nil {
+		t.Fatalf("creating movie %q: %v", title, err)
+	}
+	id := m.MovieID
+	t.Cleanup(func() {
+		_ = s.DeleteMovie(context.Background(), id)
+	})
+	return id
+}
+
+func createActor(t *testing.T, s Storage, name string) int {
+	t.Helper()
+	a, err := s.CreateActor(context.Background(), &models.Actor{Name: name, Gender: "female", DateOfBirth: date(1970)})
+	if err != nil {
+		t.Fatalf("creating actor %q: %v", name, err)
+	}
+	id := a.ActorID
+	t.Cleanup(func() {
+		_ = s.DeleteActor(context.Background(), id)
+	})
+	return id
+}
+
+// titles returns the titles of the movies in ids, in the order of movies.
+func titles(movies []*models.Movie, ids ...int) []string {
+	var list []string
+	for _, m := range movies {
+		for _, id := range ids {
+			if m.MovieID == id {
+				list = append(list, m.Title)
+			}
+		}
+	}
+	return list
+}
+
+func testSorting(t *testing.T, b Backend, tag string) {
+	ctx := context.Background()
+	alpha := createMovie(t, b.Storage, tag+" Alpha", "", 9.1, 2001)
+	bravo := createMovie(t, b.Storage, tag+" Bravo", "", 5.5, 1990)
+	charlie := createMovie(t, b.Storage, tag+" Charlie", "", 7.3, 1975)
+
+	for _, tt := range []struct {
+		sort string
+		want []string
+	}{
+		{"", []string{"Alpha", "Charlie", "Bravo"}},
+		{"rating", []string{"Alpha", "Charlie", "Bravo"}},
+		{"title", []string{"Alpha", "Bravo", "Charlie"}},
+		{"date", []string{"Charlie", "Bravo", "Alpha"}},
+	} {
+		movies, err := b.Storage.GetAllMovies(ctx, tt.sort)
+		if err != nil {
+			t.Fatalf("sort %q: %v", tt.sort, err)
+		}
+		got := strings.Join(titles(movies, alpha, bravo, charlie), ", ")
+		want := tag + " " + strings.Join(tt.want, ", "+tag+" ")
+		if got != want {
+			t.Errorf("sort %q: got %s, want %s", tt.sort, got, want)
+		}
+	}
+
+	_, err := b.Storage.GetAllMovies(ctx, "budget")
+	if err == nil || errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("unknown sort: got %v, want an error other than ErrNoRecord", err)
+	}
+}
+
+func testSearch(t *testing.T, b Backend, tag string) {
+	ctx := context.Background()
+	solaris := createMovie(t, b.Storage, tag+" Solaris", "A psychologist is sent to a station", 8.1, 1972)
+	stalker := createMovie(t, b.Storage, "Stalker", "A guide leads two men through the "+tag+" Zone", 8.2, 1979)
+
+	for _, tt := range []struct {
+		query string
+		want  []int
+	}{
+		{tag + " solaris", []int{solaris}},
+		{strings.ToUpper(tag) + " ZONE", []int{stalker}},
+		{tag, []int{solaris, stalker}},
+	} {
+		movies, err := b.Storage.GetMovieByMovieName(ctx, tt.query)
+		if err != nil {
+			t.Fatalf("search %q: %v", tt.query, err)
+		}
+		var got []int
+		for _, m := range movies {
+			if m.MovieID == solaris || m.MovieID == stalker {
+				got = append(got, m.MovieID)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) && fmt.Sprint(reversed(got)) != fmt.Sprint(tt.want) {
+			t.Errorf("search %q: got movies %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	_, err := b.Storage.GetMovieByMovieName(ctx, tag+" nowhere")
+	if !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("search without matches: got %v, want ErrNoRecord", err)
+	}
+}
+
+// testSearchFoldsCase checks that searches ignore the case of any letter,
+// not only of ASCII ones, like ILIKE does.
+func testSearchFoldsCase(t *testing.T, b Backend, tag string) {
+	ctx := context.Background()
+	mirror := createMovie(t, b.Storage, tag+" Зеркало", "", 8.1, 1975)
+	actor := createActor(t, b.Storage, tag+" Маргарита Терехова")
+	if _, _, _, err := b.Storage.AddActorToMovie(ctx, actor, mirror); err != nil {
+		t.Fatal(err)
+	}
+
+	// two letters are too short for a trigram index
+	for _, query := range []string{"ЗЕРКАЛО", "зе"} {
+		movies, err := b.Storage.GetMovieByMovieName(ctx, query)
+		if err != nil {
+			t.Fatalf("search %q: %v", query, err)
+		}
+		if titles(movies, mirror) == nil {
+			t.Errorf("search %q: %s Зеркало not found", query, tag)
+		}
+	}
+
+	movies, err := b.Storage.GetMovieByActorName(ctx, "МАРГАРИТА", strings.ToUpper(tag)+" маргарита ТЕРЕХОВА")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, m := range movies {
+		found = found || m.MovieID == mirror
+	}
+	if !found {
+		t.Errorf("search by actor name: got %d movies, want %s Зеркало", len(movies), tag)
+	}
+}
+
+func reversed(ids []int) []int {
+	r := make([]int, len(ids))
+	for i, id := range ids {
+		r[len(ids)-1-i] = id
+	}
+	return r
+}
+
+func testMissing(t *testing.T, b Backend, tag string) {
+	ctx := context.Background()
+	movie := createMovie(t, b.Storage, tag+" Present", "", 6, 2000)
+	actor := createActor(t, b.Storage, tag+" Present")
+
+	checks := map[string]error{}
+	_, checks["GetMovieByID"] = b.Storage.GetMovieByID(ctx, missingID)
+	_, checks["GetActorByID"] = b.Storage.GetActorByID(ctx, missingID)
+	checks["DeleteMovie"] = b.Storage.DeleteMovie(ctx, missingID)
+	checks["DeleteActor"] = b.Storage.DeleteActor(ctx, missingID)
+	_, _, checks["GetActorsForMovie"] = b.Storage.GetActorsForMovie(ctx, missingID)
+	_, _, checks["GetMoviesForActor"] = b.Storage.GetMoviesForActor(ctx, missingID)
+	_, _, _, checks["AddActorToMovie of a missing actor"] = b.Storage.AddActorToMovie(ctx, missingID, movie)
+	_, _, _, checks["AddActorToMovie to a missing movie"] = b.Storage.AddActorToMovie(ctx, actor, missingID)
+	_, _, checks["DeleteActorFromMovie of a missing actor"] = b.Storage.DeleteActorFromMovie(ctx, missingID, movie)
+	_, _, checks["DeleteActorFromMovie from a missing movie"] = b.Storage.DeleteActorFromMovie(ctx, actor, missingID)
+	_, checks["GetUserByEmail"] = b.Storage.GetUserByEmail(ctx, tag+"@example.com")
+	for name, err := range checks {
+		if !errors.Is(err, models.ErrNoRecord) {
+			t.Errorf("%s: got %v, want ErrNoRecord", name, err)
+		}
+	}
+}
+
+func testCredits(t *testing.T, b Backend, tag string) {
+	ctx := context.Background()
+	movie := createMovie(t, b.Storage, tag+" Ensemble", "", 7, 2010)
+	other := createMovie(t, b.Storage, tag+" Sequel", "", 6, 2012)
+	lead := createActor(t, b.Storage, tag+" Lead")
+	support := createActor(t, b.Storage, tag+" Support")
+
+	// the last credit exists already
+	for i, c := range [][2]int{{lead, movie}, {support, movie}, {lead, other}, {lead, movie}} {
+		a, m, created, err := b.Storage.AddActorToMovie(ctx, c[0], c[1])
+		if err != nil {
+			t.Fatalf("adding actor %d to movie %d: %v", c[0], c[1], err)
+		}
+		if a.ActorID != c[0] || m.MovieID != c[1] {
+			t.Errorf("adding actor %d to movie %d returned actor %d and movie %d", c[0], c[1], a.ActorID, m.MovieID)
+		}
+		if created != (i < 3) {
+			t.Errorf("adding actor %d to movie %d: got created %v, want %v", c[0], c[1], created, i < 3)
+		}
+	}
+	castOf(t, b.Storage, movie, lead, support)
+	filmographyOf(t, b.Storage, lead, movie, other)
+
+	if _, _, err := b.Storage.DeleteActorFromMovie(ctx, support, movie); err != nil {
+		t.Fatalf("removing actor from movie: %v", err)
+	}
+	castOf(t, b.Storage, movie, lead)
+	filmographyOf(t, b.Storage, support)
+
+	// removing a credit that does not exist is not an error
+	if _, _, err := b.Storage.DeleteActorFromMovie(ctx, support, movie); err != nil {
+		t.Fatalf("removing a removed credit: %v", err)
+	}
+
+	if err := b.Storage.DeleteMovie(ctx, other); err != nil {
+		t.Fatalf("deleting movie: %v", err)
+	}
+	filmographyOf(t, b.Storage, lead, movie)
+}
+
+// castOf checks that the cast of movie is want, in any order.
+func castOf(t *testing.T, s Storage, movie int, want ...int) {
+	t.Helper()
+	actors, m, err := s.GetActorsForMovie(context.Background(), movie)
+	if err != nil {
+		t.Fatalf("cast of movie %d: %v", movie, err)
+	}
+	if m.MovieID != movie {
+		t.Errorf("cast of movie %d returned movie %d", movie, m.MovieID)
+	}
+	got := make([]int, 0, len(actors))
+	for _, a := range actors {
+		got = append(got, a.ActorID)
+	}
+	if !sameIDs(got, want) {
+		t.Errorf("cast of movie %d: got actors %v, want %v", movie, got, want)
+	}
+}
+
+// filmographyOf checks that the movies of actor are want, in any order.
+func filmographyOf(t *testing.T, s Storage, actor int, want ...int) {
+	t.Helper()
+	movies, a, err := s.GetMoviesForActor(context.Background(), actor)
+	if err != nil {
+		t.Fatalf("movies of actor %d: %v", actor, err)
+	}
+	if a.ActorID != actor {
+		t.Errorf("movies of actor %d returned actor %d", actor, a.ActorID)
+	}
+	got := make([]int, 0, len(movies))
+	for _, m := range movies {
+		got = append(got, m.MovieID)
+	}
+	if !sameIDs(got, want) {
+		t.Errorf("movies of actor %d: got %v, want %v", actor, got, want)
+	}
+}
+
+func sameIDs(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[int]int)
+	for _, id := range a {
+		count[id]++
+	}
+	for _, id := range b {
+		count[id]--
+		if count[id] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// testUserEmails checks that emails are unique and looked up exactly, so
+// addresses differing in case belong to different accounts.
+func testUserEmails(t *testing.T, b Backend, tag string) {
+	ctx := context.Background()
+	email := tag + "@example.com"
+	if err := b.AddUser(email, "hash", "admin"); err != nil {
+		t.Fatalf("adding user: %v", err)
+	}
+	if err := b.AddUser(email, "other hash", "user"); err == nil {
+		t.Error("added a second user with the same email")
+	}
+
+	user, err := b.Storage.GetUserByEmail(ctx, email)
+	if err != nil {
+		t.Fatalf("getting user: %v", err)
+	}
+	if user.Email != email || user.Password != "hash" || user.Role != "admin" {
+		t.Errorf("got user %s with password %q and role %s, want %s, \"hash\", admin", user.Email, user.Password, user.Role, email)
+	}
+
+	upper := strings.ToUpper(email)
+	if _, err = b.Storage.GetUserByEmail(ctx, upper); !errors.Is(err, models.ErrNoRecord) {
+		t.Errorf("getting %s: got %v, want ErrNoRecord", upper, err)
+	}
+	if err = b.AddUser(upper, "hash", "user"); err != nil {
+		t.Fatalf("adding %s: %v", upper, err)
+	}
+	user, err = b.Storage.GetUserByEmail(ctx, upper)
+	if err != nil {
+		t.Fatalf("getting %s: %v", upper, err)
+	}
+	if user.Email != upper || user.Role != "user" {
+		t.Errorf("getting %s returned %s with role %s", upper, user.Email, user.Role)
+	}
+}
@@ -3,43 +3,169 @@ package userstorage
 import (
 	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"filmoteka/internal/domain/models"
+	"log/slog"
 	"time"
 )
 
-var DbTimeout = 5 * time.Second
-
 type UserStorage struct {
-	db *sql.DB
+	db      *sql.DB
+	timeout time.Duration
 }
 
-func New(db *sql.DB) *UserStorage {
+// New returns a storage on db that gives every query up to timeout.
+func New(db *sql.DB, timeout time.Duration) *UserStorage {
 	return &UserStorage{
-		db: db,
+		db:      db,
+		timeout: timeout,
 	}
+}
+
+func (s *UserStorage) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT userid, email, password, role FROM users WHERE email = $1`
+
+	user := &models.User{}
+	err := s.db.QueryRowContext(ctx, query, email).Scan(&user.UserID, &user.Email, &user.Password, &user.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNoRecord
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error getting user by email from the table", "err", err)
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetLoginFailures returns the failures recorded under key, zero if there
+// are none.
+func (s *UserStorage) GetLoginFailures(ctx context.Context, key string) (*models.LoginFailures, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `SELECT failures, last_failure FROM login_failures WHERE key = $1`
 
+	f := &models.LoginFailures{}
+	err := s.db.QueryRowContext(ctx, query, key).Scan(&f.Failures, &f.LastFailure)
+	if errors.Is(err, sql.ErrNoRows) {
+		return f, nil
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error getting login failures from the table", "err", err)
+		return nil, err
+	}
+	return f, nil
 }
 
-//func (s *UserStorage) auth(u *models.User) {
-//	bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(u.Password))
-//}
+// RecordLoginFailure adds a failure under key. A counter whose last failure
+// is older than resetAfter starts over.
+func (s *UserStorage) RecordLoginFailure(ctx context.Context, key string, resetAfter time.Duration) (*models.LoginFailures, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `INSERT INTO login_failures AS lf (key, failures, last_failure)
+VALUES ($1, 1, now())
+ON CONFLICT (key) DO UPDATE SET
+    failures     = CASE WHEN lf.last_failure < now() - make_interval(secs => $2) THEN 1 ELSE lf.failures + 1 END,
+    last_failure = now()
+RETURNING failures, last_failure`
 
-func (s *UserStorage) GetUserByEmail(email string) (password string, role string, err error) {
-	ctx, cancel := context.WithTimeout(context.Background(), DbTimeout)
+	f := &models.LoginFailures{}
+	err := s.db.QueryRowContext(ctx, query, key, resetAfter.Seconds()).Scan(&f.Failures, &f.LastFailure)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error recording login failure in the table", "err", err)
+		return nil, err
+	}
+	return f, nil
+}
+
+func (s *UserStorage) ClearLoginFailures(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	query := `SELECT password, role FROM users WHERE email = $1`
-	_, err = s.db.QueryContext(ctx, query, email)
+	query := `DELETE FROM login_failures WHERE key = $1`
+	_, err := s.db.ExecContext(ctx, query, key)
 	if err != nil {
-		log.Println("Error getting user by email from the table", err)
-		return "", "", err
+		slog.ErrorContext(ctx, "Error clearing login failures in the table", "err", err)
+		return err
 	}
+	return nil
+}
 
-	err = s.db.QueryRowContext(ctx, query, email).Scan(&password, &role)
+func (s *UserStorage) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `UPDATE users SET password = $2 WHERE userid = $1`
+	_, err := s.db.ExecContext(ctx, query, userID, passwordHash)
 	if err != nil {
-		log.Println("Error getting user by email from the table", err)
-		return "", "", err
+		slog.ErrorContext(ctx, "Error updating user password in the table", "err", err)
+		return err
 	}
+	return nil
+}
 
-	return password, role, err
+// CreatePasswordReset stores the hash of a reset token for the user.
+func (s *UserStorage) CreatePasswordReset(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	query := `INSERT INTO password_resets (token_hash, userid, expires_at) VALUES ($1, $2, $3)`
+	_, err := s.db.ExecContext(ctx, query, tokenHash, userID, expiresAt)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error inserting password reset into the table", "err", err)
+		return err
+	}
+	return nil
+}
+
+// ResetPassword sets the password of the user the unused, unexpired token
+// with tokenHash belongs to, and uses up all reset tokens of that user. It
+// returns models.ErrNoRecord if there is no such token.
+func (s *UserStorage) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error starting password reset transaction", "err", err)
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	user := &models.User{}
+	query := `UPDATE password_resets SET used_at = now()
+	WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+	RETURNING userid`
+	err = tx.QueryRowContext(ctx, query, tokenHash).Scan(&user.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrNoRecord
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Error using password reset from the table", "err", err)
+		return nil, err
+	}
+
+	query = `UPDATE users SET password = $2 WHERE userid = $1 RETURNING email, password, role`
+	err = tx.QueryRowContext(ctx, query, user.UserID, passwordHash).Scan(&user.Email, &user.Password, &user.Role)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error updating user password in the table", "err", err)
+		return nil, err
+	}
+
+	query = `UPDATE password_resets SET used_at = now() WHERE userid = $1 AND used_at IS NULL`
+	_, err = tx.ExecContext(ctx, query, user.UserID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error using other password resets from the table", "err", err)
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		slog.ErrorContext(ctx, "Error committing password reset", "err", err)
+		return nil, err
+	}
+	return user, nil
 }
@@ -0,0 +1,195 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"strings"
+)
+
+// OpenDB opens dsn like sql.Open, but every statement run through the pool
+// gets a client span carrying the statement text and the number of rows it
+// returned or affected. driverName has to be registered already.
+func OpenDB(driverName string, dsn string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, "")
+	if err != nil {
+		return nil, err
+	}
+	d := db.Driver()
+	_ = db.Close()
+
+	var c driver.Connector = dsnConnector{dsn: dsn, driver: d}
+	if dc, ok := d.(driver.DriverContext); ok {
+		if c, err = dc.OpenConnector(dsn); err != nil {
+			return nil, err
+		}
+	}
+	return sql.OpenDB(&connector{Connector: c, system: dbSystem(driverName)}), nil
+}
+
+// dbSystem names the database behind driverName the way the semantic
+// conventions do.
+func dbSystem(driverName string) string {
+	switch driverName {
+	case "pgx", "postgres":
+		return "postgresql"
+	default:
+		return driverName
+	}
+}
+
+type dsnConnector struct {
+	dsn    string
+	driver driver.Driver
+}
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type connector struct {
+	driver.Connector
+	system string
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	dc, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: dc, system: c.system}, nil
+}
+
+// startStatement starts the span of a statement, named after its first
+// keyword.
+func startStatement(ctx context.Context, system string, query string) (context.Context, trace.Span) {
+	name := "SQL"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		name = strings.ToUpper(fields[0])
+	}
+	return tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", system),
+			attribute.String("db.query.text", query),
+		),
+	)
+}
+
+// conn passes everything on to the connection of the driver, tracing
+// queries and execs.
+type conn struct {
+	driver.Conn
+	system string
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	q, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startStatement(ctx, c.system, query)
+	rows, err := q.QueryContext(ctx, query, args)
+	if err != nil {
+		if !errors.Is(err, driver.ErrSkip) {
+			Fail(span, err)
+		}
+		span.End()
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	e, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	ctx, span := startStatement(ctx, c.system, query)
+	defer span.End()
+	res, err := e.ExecContext(ctx, query, args)
+	if err != nil {
+		if !errors.Is(err, driver.ErrSkip) {
+			Fail(span, err)
+		}
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", n))
+	}
+	return res, nil
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *conn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *conn) CheckNamedValue(v *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(v)
+	}
+	return driver.ErrSkip
+}
+
+func (c *conn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *conn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// tracedRows counts the rows read and ends the span of the query when the
+// rows are closed.
+type tracedRows struct {
+	driver.Rows
+	span trace.Span
+	n    int64
+}
+
+func (r *tracedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	if err == nil {
+		r.n++
+	} else if !errors.Is(err, io.EOF) {
+		Fail(r.span, err)
+	}
+	return err
+}
+
+func (r *tracedRows) Close() error {
+	r.span.SetAttributes(attribute.Int64("db.response.returned_rows", r.n))
+	r.span.End()
+	return r.Rows.Close()
+}
@@ -0,0 +1,30 @@
+// Package tracing creates the OpenTelemetry spans of the API: one per HTTP
+// request or gRPC call, one per usecase call and one per SQL statement.
+// Spans go to the global tracer provider, which is a no-op unless main
+// installs one.
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "filmoteka"
+
+func tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Start starts a span named name as child of the span in ctx, if any.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// Fail records err on span and marks the span as failed.
+func Fail(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
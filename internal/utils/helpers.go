@@ -3,9 +3,11 @@ package utils
 import (
 	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type JsonResponse struct {
@@ -69,8 +71,35 @@ func StringToInt(w http.ResponseWriter, integer *int, str string) {
 	var err error
 	*integer, err = strconv.Atoi(str)
 	if err != nil {
-		log.Println("Error converting values", err)
+		slog.Info("Error converting values", "err", err)
 		ErrorJSON(w, errors.New("invalid parameter"), http.StatusBadRequest)
 		return
 	}
 }
+
+func PathID(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil || id < 1 {
+		slog.InfoContext(r.Context(), "Invalid path parameter", "param", name, "value", r.PathValue(name))
+		return 0, errors.New("invalid " + name + " parameter")
+	}
+	return id, nil
+}
+
+// SetLastModified sets the Last-Modified header unless t is zero. It must be
+// called before the response is written.
+func SetLastModified(w http.ResponseWriter, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	w.Header().Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+}
+
+// ClientIP returns the address of the client without the port.
+func ClientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}